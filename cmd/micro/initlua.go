@@ -47,6 +47,7 @@ func luaImportMicro() *lua.LTable {
 	ulua.L.SetField(pkg, "InfoBar", luar.New(ulua.L, action.GetInfoBar))
 	ulua.L.SetField(pkg, "Log", luar.New(ulua.L, log.Println))
 	ulua.L.SetField(pkg, "SetStatusInfoFn", luar.New(ulua.L, display.SetStatusInfoFnLua))
+	ulua.L.SetField(pkg, "RecordingMacro", luar.New(ulua.L, action.RecordingMacro))
 	ulua.L.SetField(pkg, "CurPane", luar.New(ulua.L, func() action.Pane {
 		return action.MainTab().CurPane()
 	}))
@@ -65,6 +66,9 @@ func luaImportMicroConfig() *lua.LTable {
 	ulua.L.SetField(pkg, "HelpComplete", luar.New(ulua.L, action.HelpComplete))
 	ulua.L.SetField(pkg, "OptionComplete", luar.New(ulua.L, action.OptionComplete))
 	ulua.L.SetField(pkg, "OptionValueComplete", luar.New(ulua.L, action.OptionValueComplete))
+	ulua.L.SetField(pkg, "BindingComplete", luar.New(ulua.L, action.BindingComplete))
+	ulua.L.SetField(pkg, "TabComplete", luar.New(ulua.L, action.TabComplete))
+	ulua.L.SetField(pkg, "FlagComplete", luar.New(ulua.L, action.FlagComplete))
 	ulua.L.SetField(pkg, "NoComplete", luar.New(ulua.L, nil))
 	ulua.L.SetField(pkg, "TryBindKey", luar.New(ulua.L, action.TryBindKey))
 	ulua.L.SetField(pkg, "Reload", luar.New(ulua.L, action.ReloadConfig))
@@ -78,6 +82,7 @@ func luaImportMicroConfig() *lua.LTable {
 	ulua.L.SetField(pkg, "RTSyntax", luar.New(ulua.L, config.RTSyntax))
 	ulua.L.SetField(pkg, "RTHelp", luar.New(ulua.L, config.RTHelp))
 	ulua.L.SetField(pkg, "RTPlugin", luar.New(ulua.L, config.RTPlugin))
+	ulua.L.SetField(pkg, "RTSnippet", luar.New(ulua.L, config.RTSnippet))
 	ulua.L.SetField(pkg, "RegisterCommonOption", luar.New(ulua.L, config.RegisterCommonOptionPlug))
 	ulua.L.SetField(pkg, "RegisterGlobalOption", luar.New(ulua.L, config.RegisterGlobalOptionPlug))
 	ulua.L.SetField(pkg, "GetGlobalOption", luar.New(ulua.L, config.GetGlobalOption))
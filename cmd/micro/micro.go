@@ -32,6 +32,8 @@ var (
 	flagDebug     = flag.Bool("debug", false, "Enable debug mode (prints debug info to ./log.txt)")
 	flagPlugin    = flag.String("plugin", "", "Plugin command")
 	flagClean     = flag.Bool("clean", false, "Clean configuration directory")
+	flagReadonly  = flag.Bool("r", false, "Open the files read-only")
+	flagPager     = flag.Bool("pager", false, "Open the files read-only with keys like less")
 	optionFlags   map[string]*string
 )
 
@@ -46,6 +48,10 @@ func InitFlags() {
 		fmt.Println("    \tSpecify a line and column to start the cursor at when opening a buffer")
 		fmt.Println("-options")
 		fmt.Println("    \tShow all option help")
+		fmt.Println("-r")
+		fmt.Println("    \tOpen the files read-only")
+		fmt.Println("-pager")
+		fmt.Println("    \tOpen the files read-only with the pager keymap (keys like less)")
 		fmt.Println("-debug")
 		fmt.Println("    \tEnable debug mode (enables logging to ./log.txt)")
 		fmt.Println("-version")
@@ -256,8 +262,25 @@ func main() {
 		runtime.Goexit()
 	}
 
+	// -r and -pager only apply to the files opened from the command line,
+	// not to the buffers opened later
+	if *flagReadonly || *flagPager {
+		for _, buf := range b {
+			buf.SetOptionNative("readonly", true)
+			if *flagPager {
+				buf.SetOptionNative("keymap", "pager")
+			}
+		}
+	}
+
 	action.InitTabs(b)
 	action.InitGlobals()
+	buffer.StartFileWatch()
+	buffer.StartBackupTimer()
+	if a := config.GetGlobalOption("autosave").(float64); a > 0 {
+		config.SetAutoTime(int(a))
+		config.StartAutoSave()
+	}
 
 	err = config.RunPluginFn("init")
 	if err != nil {
@@ -299,7 +322,7 @@ func main() {
 		screen.Screen.Fill(' ', config.DefStyle)
 		screen.Screen.HideCursor()
 		action.Tabs.Display()
-		for _, ep := range action.MainTab().Panes {
+		for _, ep := range action.MainTab().VisiblePanes() {
 			ep.Display()
 		}
 		action.MainTab().Display()
@@ -314,9 +337,12 @@ func main() {
 			// If a new job has finished while running in the background we should execute the callback
 			f.Function(f.Output, f.Args)
 		case <-config.Autosave:
-			for _, b := range buffer.OpenBuffers {
-				b.Save()
-			}
+			action.AutoSave()
+		case <-buffer.FileWatch:
+			action.CheckExternalChanges()
+			buffer.RefreshDiffBases()
+		case <-buffer.BackupTimer:
+			buffer.BackupRequested()
 		case <-shell.CloseTerms:
 		case event = <-events:
 		case <-screen.DrawChan:
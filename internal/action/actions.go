@@ -1,38 +1,65 @@
 package action
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
 	"unicode/utf8"
 
 	shellquote "github.com/kballard/go-shellquote"
 	"github.com/zyedidia/clipboard"
 	"github.com/zyedidia/micro/internal/buffer"
 	"github.com/zyedidia/micro/internal/config"
+	"github.com/zyedidia/micro/internal/display"
 	"github.com/zyedidia/micro/internal/screen"
 	"github.com/zyedidia/micro/internal/shell"
 	"github.com/zyedidia/micro/internal/util"
+	"github.com/zyedidia/micro/internal/views"
 	"github.com/zyedidia/tcell"
 )
 
 // ScrollUp is not an action
 func (h *BufPane) ScrollUp(n int) {
 	v := h.GetView()
-	if v.StartLine >= n {
-		v.StartLine -= n
-		h.SetView(v)
-	} else {
-		v.StartLine = 0
-	}
+	v.StartLine = h.Buf.MoveLines(v.StartLine, -n)
+	h.SetView(v)
 }
 
 // ScrollDown is not an action
 func (h *BufPane) ScrollDown(n int) {
 	v := h.GetView()
 	if v.StartLine <= h.Buf.LinesNum()-1-n {
-		v.StartLine += n
+		v.StartLine = h.Buf.MoveLines(v.StartLine, n)
+		h.SetView(v)
+	}
+}
+
+// ScrollLeft is not an action
+func (h *BufPane) ScrollLeft(n int) {
+	v := h.GetView()
+	v.StartCol = util.Max(v.StartCol-n, 0)
+	h.SetView(v)
+}
+
+// ScrollRight is not an action
+func (h *BufPane) ScrollRight(n int) {
+	v := h.GetView()
+	// stop when the longest visible line is scrolled out of the view
+	width := 0
+	tabsize := h.Buf.TabWidth()
+	for y := v.StartLine; y < util.Min(v.StartLine+v.Height, h.Buf.LinesNum()); y++ {
+		line := h.Buf.LineBytes(y)
+		width = util.Max(width, util.StringWidth(line, utf8.RuneCount(line), tabsize))
+	}
+	if v.StartCol+n < width {
+		v.StartCol += n
 		h.SetView(v)
 	}
 }
@@ -42,6 +69,17 @@ func (h *BufPane) ScrollDown(n int) {
 func (h *BufPane) MousePress(e *tcell.EventMouse) bool {
 	b := h.Buf
 	mx, my := e.Position()
+
+	if w, ok := h.BWindow.(*display.BufWindow); ok {
+		if line, ok := w.MinimapLine(buffer.Loc{mx, my}); ok {
+			// clicking the minimap jumps to the corresponding line
+			h.Cursor.ResetSelection()
+			h.Cursor.GotoLoc(buffer.Loc{0, line})
+			h.Center()
+			return true
+		}
+	}
+
 	mouseLoc := h.LocFromVisual(buffer.Loc{mx, my})
 	h.Cursor.Loc = mouseLoc
 	if h.mouseReleased {
@@ -52,7 +90,16 @@ func (h *BufPane) MousePress(e *tcell.EventMouse) bool {
 			h.Cursor.Loc = mouseLoc
 		}
 		if time.Since(h.lastClickTime)/time.Millisecond < config.DoubleClickThreshold && (mouseLoc.X == h.lastLoc.X && mouseLoc.Y == h.lastLoc.Y) {
-			if h.doubleClick {
+			if h.tripleClick {
+				// Quadruple click
+				h.lastClickTime = time.Now()
+
+				h.quadClick = true
+				h.tripleClick = false
+
+				h.Cursor.SelectParagraph()
+				h.Cursor.CopySelection("primary")
+			} else if h.doubleClick {
 				// Triple click
 				h.lastClickTime = time.Now()
 
@@ -67,6 +114,7 @@ func (h *BufPane) MousePress(e *tcell.EventMouse) bool {
 
 				h.doubleClick = true
 				h.tripleClick = false
+				h.quadClick = false
 
 				h.Cursor.SelectWord()
 				h.Cursor.CopySelection("primary")
@@ -74,6 +122,7 @@ func (h *BufPane) MousePress(e *tcell.EventMouse) bool {
 		} else {
 			h.doubleClick = false
 			h.tripleClick = false
+			h.quadClick = false
 			h.lastClickTime = time.Now()
 
 			h.Cursor.OrigSelection[0] = h.Cursor.Loc
@@ -82,7 +131,7 @@ func (h *BufPane) MousePress(e *tcell.EventMouse) bool {
 		}
 		h.mouseReleased = false
 	} else if !h.mouseReleased {
-		if h.tripleClick {
+		if h.tripleClick || h.quadClick {
 			h.Cursor.AddLineToSelection()
 		} else if h.doubleClick {
 			h.Cursor.AddWordToSelection()
@@ -96,15 +145,43 @@ func (h *BufPane) MousePress(e *tcell.EventMouse) bool {
 	return true
 }
 
+// scrollSpeed returns the number of lines scrolled by the scroll actions,
+// the mouse wheel scrolls by mousescrollspeed, or by scrollspeed while it
+// is 0, and the keys by scrollspeed
+func (h *BufPane) scrollSpeed() int {
+	if speed := util.IntOpt(h.Buf.Settings["mousescrollspeed"]); h.mouseAction && speed > 0 {
+		return speed
+	}
+	return util.IntOpt(h.Buf.Settings["scrollspeed"])
+}
+
 // ScrollUpAction scrolls the view up
 func (h *BufPane) ScrollUpAction() bool {
-	h.ScrollUp(util.IntOpt(h.Buf.Settings["scrollspeed"]))
+	h.ScrollUp(h.scrollSpeed())
 	return true
 }
 
 // ScrollDownAction scrolls the view up
 func (h *BufPane) ScrollDownAction() bool {
-	h.ScrollDown(util.IntOpt(h.Buf.Settings["scrollspeed"]))
+	h.ScrollDown(h.scrollSpeed())
+	return true
+}
+
+// ScrollLeftAction scrolls the view left, when softwrap is off
+func (h *BufPane) ScrollLeftAction() bool {
+	if h.Buf.Settings["softwrap"].(bool) {
+		return false
+	}
+	h.ScrollLeft(h.scrollSpeed())
+	return true
+}
+
+// ScrollRightAction scrolls the view right, when softwrap is off
+func (h *BufPane) ScrollRightAction() bool {
+	if h.Buf.Settings["softwrap"].(bool) {
+		return false
+	}
+	h.ScrollRight(h.scrollSpeed())
 	return true
 }
 
@@ -143,6 +220,11 @@ func (h *BufPane) CursorDown() bool {
 func (h *BufPane) CursorLeft() bool {
 	if h.Cursor.HasSelection() {
 		h.Cursor.Deselect(true)
+	} else if h.Cursor.Virtual > 0 {
+		h.Cursor.Virtual--
+		h.Cursor.StoreVisualX()
+	} else if h.Cursor.X == 0 && !h.Buf.Settings["cursorwrap"].(bool) {
+		return false
 	} else {
 		tabstospaces := h.Buf.Settings["tabstospaces"].(bool)
 		tabmovement := h.Buf.Settings["tabmovement"].(bool)
@@ -169,6 +251,12 @@ func (h *BufPane) CursorRight() bool {
 	if h.Cursor.HasSelection() {
 		h.Cursor.Deselect(false)
 		h.Cursor.Loc = h.Cursor.Loc.Move(1, h.Buf)
+	} else if h.Cursor.X >= utf8.RuneCount(h.Buf.LineBytes(h.Cursor.Y)) && h.Buf.Settings["virtualedit"].(bool) {
+		// move into the virtual space past the end of the line
+		h.Cursor.Virtual++
+		h.Cursor.StoreVisualX()
+	} else if h.Cursor.X >= utf8.RuneCount(h.Buf.LineBytes(h.Cursor.Y)) && !h.Buf.Settings["cursorwrap"].(bool) {
+		return false
 	} else {
 		tabstospaces := h.Buf.Settings["tabstospaces"].(bool)
 		tabmovement := h.Buf.Settings["tabmovement"].(bool)
@@ -291,10 +379,24 @@ func (h *BufPane) StartOfText() bool {
 	return true
 }
 
-// StartOfLine moves the cursor to the start of the line
+// StartOfTextToggle moves the cursor to the start of the text of the line,
+// or to the start of the line if it is already there
+func (h *BufPane) StartOfTextToggle() bool {
+	h.Cursor.Deselect(true)
+	h.Cursor.StartOfTextToggle()
+	h.Relocate()
+	return true
+}
+
+// StartOfLine moves the cursor to the start of the line, or behaves like
+// StartOfTextToggle if smarthome is on
 func (h *BufPane) StartOfLine() bool {
 	h.Cursor.Deselect(true)
-	h.Cursor.Start()
+	if h.Buf.Settings["smarthome"].(bool) {
+		h.Cursor.StartOfTextToggle()
+	} else {
+		h.Cursor.Start()
+	}
 	h.Relocate()
 	return true
 }
@@ -330,7 +432,23 @@ func (h *BufPane) SelectToStartOfLine() bool {
 	if !h.Cursor.HasSelection() {
 		h.Cursor.OrigSelection[0] = h.Cursor.Loc
 	}
-	h.Cursor.Start()
+	if h.Buf.Settings["smarthome"].(bool) {
+		h.Cursor.StartOfTextToggle()
+	} else {
+		h.Cursor.Start()
+	}
+	h.Cursor.SelectTo(h.Cursor.Loc)
+	h.Relocate()
+	return true
+}
+
+// SelectToStartOfTextToggle selects to the start of the text of the line,
+// or to the start of the line if the cursor is already there
+func (h *BufPane) SelectToStartOfTextToggle() bool {
+	if !h.Cursor.HasSelection() {
+		h.Cursor.OrigSelection[0] = h.Cursor.Loc
+	}
+	h.Cursor.StartOfTextToggle()
 	h.Cursor.SelectTo(h.Cursor.Loc)
 	h.Relocate()
 	return true
@@ -383,6 +501,58 @@ func (h *BufPane) ParagraphNext() bool {
 	return true
 }
 
+// lineBytes returns the lines of the buffer
+func (h *BufPane) lineBytes() [][]byte {
+	lines := make([][]byte, h.Buf.LinesNum())
+	for i := range lines {
+		lines[i] = h.Buf.LineBytes(i)
+	}
+	return lines
+}
+
+// siblingLine moves the cursor to the start of the text of the next line,
+// or the previous one, which is not more indented than the line of the cursor
+func (h *BufPane) siblingLine(dir int) bool {
+	y := buffer.SiblingLine(h.lineBytes(), h.Cursor.Y, dir, h.Buf.TabWidth())
+	if y < 0 {
+		return false
+	}
+	h.Cursor.Deselect(true)
+	h.Cursor.GotoLoc(buffer.Loc{X: 0, Y: y})
+	h.Cursor.StartOfText()
+	h.Relocate()
+	return true
+}
+
+// NextSiblingLine moves the cursor to the next line which is indented as
+// much as the line of the cursor or less, skipping the more indented lines
+func (h *BufPane) NextSiblingLine() bool {
+	return h.siblingLine(1)
+}
+
+// PrevSiblingLine moves the cursor to the previous line which is indented
+// as much as the line of the cursor or less, skipping the more indented lines
+func (h *BufPane) PrevSiblingLine() bool {
+	return h.siblingLine(-1)
+}
+
+// SelectBlock selects the lines of the block delimited by indentation which
+// the line of the cursor heads or belongs to
+func (h *BufPane) SelectBlock() bool {
+	start, end := buffer.IndentBlock(h.lineBytes(), h.Cursor.Y, h.Buf.TabWidth())
+	h.Cursor.GotoLoc(buffer.Loc{X: 0, Y: start})
+	h.Cursor.SetSelectionStart(h.Cursor.Loc)
+	h.Cursor.GotoLoc(buffer.Loc{X: 0, Y: end})
+	h.Cursor.End()
+	if end < h.Buf.LinesNum()-1 {
+		h.Cursor.GotoLoc(buffer.Loc{X: 0, Y: end + 1})
+	}
+	h.Cursor.SetSelectionEnd(h.Cursor.Loc)
+	h.Cursor.OrigSelection = h.Cursor.CurSelection
+	h.Relocate()
+	return true
+}
+
 // Retab changes all tabs to spaces or all spaces to tabs depending
 // on the user's settings
 func (h *BufPane) Retab() bool {
@@ -459,7 +629,7 @@ func (h *BufPane) InsertNewline() bool {
 			h.Buf.Remove(buffer.Loc{X: 0, Y: h.Cursor.Y - 1}, buffer.Loc{X: utf8.RuneCount(line), Y: h.Cursor.Y - 1})
 		}
 	}
-	h.Cursor.LastVisualX = h.Cursor.GetVisualX()
+	h.Cursor.StoreVisualX()
 	h.Relocate()
 	return true
 }
@@ -469,6 +639,10 @@ func (h *BufPane) Backspace() bool {
 	if h.Cursor.HasSelection() {
 		h.Cursor.DeleteSelection()
 		h.Cursor.ResetSelection()
+	} else if h.Cursor.Virtual > 0 {
+		// there is nothing to delete in the virtual space
+		h.Cursor.Virtual--
+		h.Cursor.StoreVisualX()
 	} else if h.Cursor.Loc.GreaterThan(h.Buf.Start()) {
 		// We have to do something a bit hacky here because we want to
 		// delete the line by first moving left and then deleting backwards
@@ -489,7 +663,7 @@ func (h *BufPane) Backspace() bool {
 			h.Buf.Remove(loc.Move(-1, h.Buf), loc)
 		}
 	}
-	h.Cursor.LastVisualX = h.Cursor.GetVisualX()
+	h.Cursor.StoreVisualX()
 	h.Relocate()
 	return true
 }
@@ -583,6 +757,30 @@ func (h *BufPane) OutdentLine() bool {
 	return true
 }
 
+// setIndent replaces the leading whitespace of line y by ws
+func (h *BufPane) setIndent(y int, ws []byte) {
+	cur := util.GetLeadingWhitespace(h.Buf.LineBytes(y))
+	if bytes.Equal(cur, ws) {
+		return
+	}
+	h.Buf.Replace(buffer.Loc{X: 0, Y: y}, buffer.Loc{X: utf8.RuneCount(cur), Y: y}, string(ws))
+}
+
+// IndentToPreviousLine indents the line of the cursor exactly like the
+// previous line which is not blank
+func (h *BufPane) IndentToPreviousLine() bool {
+	y := h.Cursor.Y - 1
+	for y >= 0 && util.IsBytesWhitespace(h.Buf.LineBytes(y)) {
+		y--
+	}
+	if y < 0 {
+		return false
+	}
+	h.setIndent(h.Cursor.Y, util.GetLeadingWhitespace(h.Buf.LineBytes(y)))
+	h.Relocate()
+	return true
+}
+
 // OutdentSelection takes the current selection and moves it back one indent level
 func (h *BufPane) OutdentSelection() bool {
 	if h.Cursor.HasSelection() {
@@ -624,6 +822,13 @@ func (h *BufPane) Autocomplete() bool {
 		b.CycleAutocomplete(true)
 		return true
 	}
+	if b.Autocomplete(buffer.PathComplete) {
+		return true
+	}
+	if x := h.Cursor.X; x > 0 && !util.IsWhitespace(b.RuneAt(buffer.Loc{X: x - 1, Y: h.Cursor.Y})) && h.lspAutocomplete() {
+		// the completions of the language server are shown when they arrive
+		return true
+	}
 	return b.Autocomplete(buffer.BufferComplete)
 }
 
@@ -713,6 +918,8 @@ func (h *BufPane) saveBufToFile(filename string, action string) bool {
 						h.Buf.Path = filename
 						h.Buf.SetName(filename)
 						InfoBar.Message("Saved " + filename)
+						h.runLinter()
+						h.lspDidSave()
 					}
 					h.completeAction(action)
 				}
@@ -725,10 +932,51 @@ func (h *BufPane) saveBufToFile(filename string, action string) bool {
 		h.Buf.Path = filename
 		h.Buf.SetName(filename)
 		InfoBar.Message("Saved " + filename)
+		h.runLinter()
+		h.lspDidSave()
 	}
 	return true
 }
 
+// RevertBuffer discards the unsaved changes of the buffer and reloads it
+// from disk, asking for confirmation if the buffer is modified
+func (h *BufPane) RevertBuffer() bool {
+	if h.Buf.Path == "" || h.Buf.Type != buffer.BTDefault {
+		InfoBar.Error("Cannot revert a buffer without a file")
+		return false
+	}
+
+	revert := func() {
+		loc := h.Cursor.Loc
+		if err := h.Buf.ReOpen(); err != nil {
+			InfoBar.Error(err)
+			return
+		}
+		// the changes made since the last save are gone for good
+		h.Buf.ClearHistory()
+
+		h.Buf.ClearCursors()
+		h.Cursor = h.Buf.GetActiveCursor()
+		h.Cursor.ResetSelection()
+		h.Cursor.GotoLoc(loc)
+		h.Cursor.Relocate()
+		h.Cursor.StoreVisualX()
+		h.Relocate()
+		InfoBar.Message("Reverted ", h.Buf.GetName())
+	}
+
+	if !h.Buf.Modified() {
+		revert()
+		return true
+	}
+	InfoBar.YNPrompt("Discard unsaved changes and revert to the saved file? (y,n)", func(yes, canceled bool) {
+		if yes && !canceled {
+			revert()
+		}
+	})
+	return false
+}
+
 // Find opens a prompt and searches forward for the input
 func (h *BufPane) Find() bool {
 	h.searchOrig = h.Cursor.Loc
@@ -827,8 +1075,103 @@ func (h *BufPane) FindPrevious() bool {
 	return true
 }
 
+// NextWhitespaceIssue moves the cursor to the next line with trailing
+// whitespace or indentation mixing tabs and spaces, wrapping around at the
+// end of the buffer
+func (h *BufPane) NextWhitespaceIssue() bool {
+	n := h.Buf.LinesNum()
+	for i := 1; i <= n; i++ {
+		y := (h.Cursor.Y + i) % n
+		if x, ok := util.WhitespaceIssue(h.Buf.LineBytes(y)); ok {
+			h.Cursor.ResetSelection()
+			h.Cursor.GotoLoc(buffer.Loc{X: x, Y: y})
+			h.Relocate()
+			return true
+		}
+	}
+	InfoBar.Message("No whitespace issues")
+	return false
+}
+
+// RenameInBuffer prompts for a new name for the word under the cursor and
+// replaces all the whole-word occurrences of it in the buffer
+func (h *BufPane) RenameInBuffer() bool {
+	if !h.Cursor.HasSelection() {
+		h.Cursor.SelectWord()
+	}
+	word := string(h.Cursor.GetSelection())
+	if r, _ := utf8.DecodeRuneInString(word); !util.IsWordChar(r) || strings.ContainsRune(word, '\n') {
+		h.Cursor.ResetSelection()
+		InfoBar.Error("No word under the cursor")
+		return false
+	}
+
+	InfoBar.Prompt("Rename "+word+" to: ", word, "RenameInBuffer", nil, func(resp string, canceled bool) {
+		h.Cursor.ResetSelection()
+		if canceled || resp == word {
+			return
+		}
+
+		nreplaced := h.renameWord(word, resp)
+		h.Buf.RelocateCursors()
+		h.Relocate()
+
+		if nreplaced == 1 {
+			InfoBar.Message("Renamed 1 occurrence of ", word)
+		} else {
+			InfoBar.Message("Renamed ", nreplaced, " occurrences of ", word)
+		}
+	})
+	return true
+}
+
+// renameWord replaces the whole-word occurrences of word by name in a single
+// undo step and returns their number. The occurrences must not be preceded
+// or followed by a word character, letters of any script included
+func (h *BufPane) renameWord(word, name string) int {
+	found := 0
+	var deltas []buffer.Delta
+	for y := 0; y < h.Buf.LinesNum(); y++ {
+		line := h.Buf.LineBytes(y)
+		var newLine []byte
+		last := 0
+		for i := bytes.Index(line, []byte(word)); i >= 0; {
+			end := i + len(word)
+			before, _ := utf8.DecodeLastRune(line[:i])
+			after, _ := utf8.DecodeRune(line[end:])
+			if (i == 0 || !util.IsWordChar(before)) && (end == len(line) || !util.IsWordChar(after)) {
+				found++
+				newLine = append(append(newLine, line[last:i]...), name...)
+				last = end
+			}
+			next := bytes.Index(line[end:], []byte(word))
+			if next < 0 {
+				break
+			}
+			i = end + next
+		}
+		if newLine == nil {
+			continue
+		}
+		newLine = append(newLine, line[last:]...)
+		deltas = append(deltas, buffer.Delta{
+			Text:  newLine,
+			Start: buffer.Loc{X: 0, Y: y},
+			End:   buffer.Loc{X: utf8.RuneCount(line), Y: y},
+		})
+	}
+	if len(deltas) > 0 {
+		h.Buf.MultipleReplace(deltas)
+	}
+	return found
+}
+
 // Undo undoes the last action
 func (h *BufPane) Undo() bool {
+	if h.Buf.Type.Readonly {
+		InfoBar.Message("The buffer is read-only")
+		return false
+	}
 	h.Buf.Undo()
 	InfoBar.Message("Undid action")
 	h.Relocate()
@@ -837,16 +1180,95 @@ func (h *BufPane) Undo() bool {
 
 // Redo redoes the last action
 func (h *BufPane) Redo() bool {
+	if h.Buf.Type.Readonly {
+		InfoBar.Message("The buffer is read-only")
+		return false
+	}
 	h.Buf.Redo()
 	InfoBar.Message("Redid action")
 	h.Relocate()
 	return true
 }
 
-// Copy the selection to the system clipboard
-func (h *BufPane) Copy() bool {
-	if h.Cursor.HasSelection() {
+// undoBranch switches to the older or newer undo branch
+func (h *BufPane) undoBranch(older bool) bool {
+	if h.Buf.Type.Readonly {
+		InfoBar.Message("The buffer is read-only")
+		return false
+	}
+	if !h.Buf.SwitchBranch(older) {
+		InfoBar.Message("No other undo branch")
+		return false
+	}
+	InfoBar.Message("Switched undo branch")
+	h.Relocate()
+	return true
+}
+
+// UndoBranchOlder replaces the changes after the fork of an undo branch by
+// the branch which was made before them
+func (h *BufPane) UndoBranchOlder() bool {
+	return h.undoBranch(true)
+}
+
+// UndoBranchNewer replaces the changes after the fork of an undo branch by
+// the branch which was made after them
+func (h *BufPane) UndoBranchNewer() bool {
+	return h.undoBranch(false)
+}
+
+// ClearHistory empties the undo and redo history of the buffer, so that
+// the changes made so far cannot be undone
+func (h *BufPane) ClearHistory() bool {
+	h.Buf.ClearHistory()
+	InfoBar.Message("Cleared the undo history")
+	return true
+}
+
+// clipEntries are the selections of the cursors which were last copied to
+// the clipboard together, one per line. They are pasted back one per cursor
+// when there are as many cursors
+var clipEntries []string
+
+// copyCursors copies the selections of all the cursors to the system
+// clipboard, one per line, and returns whether there was any. With a single
+// cursor only its selection is copied
+func (h *BufPane) copyCursors() bool {
+	clipEntries = nil
+	if h.Buf.NumCursors() == 1 {
+		if !h.Cursor.HasSelection() {
+			return false
+		}
 		h.Cursor.CopySelection("clipboard")
+		return true
+	}
+	for _, c := range h.Buf.GetCursors() {
+		if c.HasSelection() {
+			clipEntries = append(clipEntries, string(c.GetSelection()))
+		}
+	}
+	if len(clipEntries) == 0 {
+		return false
+	}
+	clipboard.WriteAll(strings.Join(clipEntries, "\n"), "clipboard")
+	return true
+}
+
+// readClipEntries returns the entries of the clipboard: the selections which
+// were copied together if the clipboard still holds them, otherwise the
+// whole clipboard as a single entry
+func readClipEntries() []string {
+	clip, _ := clipboard.ReadAll("clipboard")
+	if len(clipEntries) > 1 && clip == strings.Join(clipEntries, "\n") {
+		return clipEntries
+	}
+	return []string{clip}
+}
+
+// Copy the selection to the system clipboard. With multiple cursors the
+// selections of all the cursors are copied, one per line
+func (h *BufPane) Copy() bool {
+	if h.copyCursors() {
 		h.freshClip = true
 		if clipboard.Unsupported {
 			InfoBar.Message("Copied selection (install xclip for external clipboard)")
@@ -858,6 +1280,33 @@ func (h *BufPane) Copy() bool {
 	return true
 }
 
+// copyFilePath copies the path of the file of the buffer to the system
+// clipboard
+func (h *BufPane) copyFilePath(relative bool) bool {
+	path, ok := h.filePath(relative)
+	if !ok {
+		return false
+	}
+	if err := clipboard.WriteAll(path, "clipboard"); err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+	InfoBar.Message("Copied ", path)
+	return true
+}
+
+// CopyFilePath copies the absolute path of the file of the buffer to the
+// system clipboard
+func (h *BufPane) CopyFilePath() bool {
+	return h.copyFilePath(false)
+}
+
+// CopyRelativeFilePath copies the path of the file of the buffer relative to
+// the working directory to the system clipboard
+func (h *BufPane) CopyRelativeFilePath() bool {
+	return h.copyFilePath(true)
+}
+
 // CutLine cuts the current line to the clipboard
 func (h *BufPane) CutLine() bool {
 	h.Cursor.SelectLine()
@@ -884,10 +1333,20 @@ func (h *BufPane) CutLine() bool {
 	return true
 }
 
-// Cut the selection to the system clipboard
+// Cut the selection to the system clipboard. With multiple cursors the
+// selections of all the cursors are cut, one per line
 func (h *BufPane) Cut() bool {
 	if h.Cursor.HasSelection() {
-		h.Cursor.CopySelection("clipboard")
+		// the first cursor with a selection copies the selections of all
+		// of them, before they are deleted
+		for _, c := range h.Buf.GetCursors() {
+			if c.HasSelection() {
+				if c == h.Cursor {
+					h.copyCursors()
+				}
+				break
+			}
+		}
 		h.Cursor.DeleteSelection()
 		h.Cursor.ResetSelection()
 		h.freshClip = true
@@ -994,10 +1453,39 @@ func (h *BufPane) MoveLinesDown() bool {
 }
 
 // Paste whatever is in the system clipboard into the buffer
-// Delete and paste if the user has a selection
+// Delete and paste if the user has a selection. If the selections of
+// multiple cursors were copied and there are as many cursors, every cursor
+// pastes its own selection, otherwise every cursor pastes the whole clipboard
 func (h *BufPane) Paste() bool {
-	clip, _ := clipboard.ReadAll("clipboard")
-	h.paste(clip)
+	entries := readClipEntries()
+	if len(entries) == h.Buf.NumCursors() {
+		h.paste(entries[h.Cursor.Num])
+	} else {
+		h.paste(strings.Join(entries, "\n"))
+	}
+	h.Relocate()
+	return true
+}
+
+// PasteAsLines pastes the entries of the clipboard as separate lines below
+// the line of the cursor, whatever the number of cursors. The other cursors
+// are removed
+func (h *BufPane) PasteAsLines() bool {
+	entries := readClipEntries()
+	var text strings.Builder
+	for _, e := range entries {
+		text.WriteString("\n" + strings.TrimSuffix(e, "\n"))
+	}
+
+	h.Buf.ClearCursors()
+	h.Cursor = h.Buf.GetActiveCursor()
+	h.Cursor.ResetSelection()
+	h.Cursor.End()
+	// the insertion leaves the cursor at the end of the last entry
+	h.Buf.Insert(h.Cursor.Loc, text.String())
+	h.Cursor.X = 0
+	h.freshClip = false
+	InfoBar.Message("Pasted ", len(entries), " lines")
 	h.Relocate()
 	return true
 }
@@ -1010,6 +1498,23 @@ func (h *BufPane) PastePrimary() bool {
 	return true
 }
 
+// MousePastePrimary pastes the primary selection at the location of the
+// click, unless the middleclickpaste option is off
+func (h *BufPane) MousePastePrimary(e *tcell.EventMouse) bool {
+	if !h.Buf.Settings["middleclickpaste"].(bool) || !h.mouseReleased {
+		// paste once per click, not for every motion of the mouse
+		return false
+	}
+	h.mouseReleased = false
+
+	mx, my := e.Position()
+	h.Buf.ClearCursors()
+	h.Cursor = h.Buf.GetActiveCursor()
+	h.Cursor.ResetSelection()
+	h.Cursor.GotoLoc(h.LocFromVisual(buffer.Loc{X: mx, Y: my}))
+	return h.PastePrimary()
+}
+
 func (h *BufPane) paste(clip string) {
 	if h.Buf.Settings["smartpaste"].(bool) {
 		if h.Cursor.X > 0 && len(util.GetLeadingWhitespace([]byte(strings.TrimLeft(clip, "\r\n")))) == 0 {
@@ -1074,6 +1579,95 @@ func (h *BufPane) OpenFile() bool {
 	return true
 }
 
+// OpenFileUnderCursor opens the file whose path is under the cursor, at the
+// line given after the path like in file:line. A relative path is looked up
+// in the directory of the current file, then in the directories of the
+// searchpath option
+func (h *BufPane) OpenFileUnderCursor() bool {
+	token := util.PathAt(h.Buf.LineBytes(h.Cursor.Y), h.Cursor.X)
+	if token == "" {
+		InfoBar.Error("No file name under the cursor")
+		return false
+	}
+	path, pos := util.GetPathAndCursorPosition(token)
+	path, err := util.ReplaceHome(path)
+	if err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+
+	dirs := []string{"."}
+	if h.Buf.AbsPath != "" {
+		dirs[0] = filepath.Dir(h.Buf.AbsPath)
+	}
+	for _, dir := range strings.Split(h.Buf.Settings["searchpath"].(string), ",") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	var candidates []string
+	if filepath.IsAbs(path) {
+		candidates = []string{path}
+	} else {
+		for _, dir := range dirs {
+			candidates = append(candidates, filepath.Join(dir, path))
+		}
+	}
+
+	open := func(target string) {
+		if pos != nil {
+			target += ":" + strings.Join(pos, ":")
+		}
+		h.OpenCmd([]string{shellquote.Join(target)})
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			open(c)
+			return true
+		}
+	}
+
+	InfoBar.YNPrompt("File "+path+" does not exist. Create it? (y,n)", func(yes, canceled bool) {
+		if yes && !canceled {
+			open(candidates[0])
+		}
+	})
+	return true
+}
+
+// OpenURL opens the URL under the cursor in the web browser
+func (h *BufPane) OpenURL() bool {
+	url := util.URLAt(h.Buf.LineBytes(h.Cursor.Y), h.Cursor.X)
+	if url == "" {
+		return false
+	}
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+	if err := shell.OpenURL(url); err != nil {
+		InfoBar.Error("Could not open ", url, ": ", err)
+		return false
+	}
+	InfoBar.Message("Opened ", url)
+	return true
+}
+
+// RevealInFileManager opens the file manager of the system on the file of
+// the buffer
+func (h *BufPane) RevealInFileManager() bool {
+	path, ok := h.filePath(false)
+	if !ok {
+		return false
+	}
+	if err := shell.RevealFile(path); err != nil {
+		InfoBar.Error("Could not open the file manager: ", err)
+		return false
+	}
+	InfoBar.Message("Revealed ", path)
+	return true
+}
+
 // Start moves the viewport to the start of the buffer
 func (h *BufPane) Start() bool {
 	v := h.GetView()
@@ -1207,6 +1801,96 @@ func (h *BufPane) ToggleRuler() bool {
 	return true
 }
 
+// ToggleReadOnly turns the readonly option of the buffer off and on
+func (h *BufPane) ToggleReadOnly() bool {
+	if h.Buf.Type.Kind != buffer.BTDefault.Kind {
+		return false
+	}
+	if !h.Buf.Settings["readonly"].(bool) {
+		h.Buf.SetOptionNative("readonly", true)
+		InfoBar.Message("Enabled read-only")
+	} else {
+		h.Buf.SetOptionNative("readonly", false)
+		InfoBar.Message("Disabled read-only")
+	}
+	return true
+}
+
+// ToggleCenterCursor turns the centercursor option off and on, which keeps
+// the cursor on the middle line of the view
+func (h *BufPane) ToggleCenterCursor() bool {
+	if !h.Buf.Settings["centercursor"].(bool) {
+		h.Buf.SetOptionNative("centercursor", true)
+		InfoBar.Message("Enabled centered cursor")
+	} else {
+		h.Buf.SetOptionNative("centercursor", false)
+		InfoBar.Message("Disabled centered cursor")
+	}
+	h.Relocate()
+	return true
+}
+
+// ToggleShowWhitespace turns the display of whitespace characters off and on
+func (h *BufPane) ToggleShowWhitespace() bool {
+	if !h.Buf.Settings["showwhitespace"].(bool) {
+		h.Buf.Settings["showwhitespace"] = true
+		InfoBar.Message("Enabled whitespace display")
+	} else {
+		h.Buf.Settings["showwhitespace"] = false
+		InfoBar.Message("Disabled whitespace display")
+	}
+	return true
+}
+
+// ToggleIndentGuides turns indent guides off and on
+func (h *BufPane) ToggleIndentGuides() bool {
+	if !h.Buf.Settings["indentguides"].(bool) {
+		h.Buf.Settings["indentguides"] = true
+		InfoBar.Message("Enabled indent guides")
+	} else {
+		h.Buf.Settings["indentguides"] = false
+		InfoBar.Message("Disabled indent guides")
+	}
+	return true
+}
+
+// ToggleColorColumn turns the color column(s) off and on
+func (h *BufPane) ToggleColorColumn() bool {
+	cols, _ := config.ParseColorColumn(h.Buf.Settings["colorcolumn"].(string))
+	enabled := false
+	for _, c := range cols {
+		if c != 0 {
+			enabled = true
+		}
+	}
+
+	if !enabled {
+		if h.lastColorColumn == "" {
+			h.lastColorColumn = "80"
+		}
+		h.Buf.Settings["colorcolumn"] = h.lastColorColumn
+		InfoBar.Message("Enabled color column at " + h.lastColorColumn)
+	} else {
+		h.lastColorColumn = h.Buf.Settings["colorcolumn"].(string)
+		h.Buf.Settings["colorcolumn"] = "0"
+		InfoBar.Message("Disabled color column")
+	}
+	return true
+}
+
+// ToggleMinimap turns the minimap off and on
+func (h *BufPane) ToggleMinimap() bool {
+	if !h.Buf.Settings["minimap"].(bool) {
+		h.Buf.Settings["minimap"] = true
+		InfoBar.Message("Enabled minimap")
+	} else {
+		h.Buf.Settings["minimap"] = false
+		InfoBar.Message("Disabled minimap")
+	}
+	h.Relocate()
+	return true
+}
+
 // ClearStatus clears the messenger bar
 func (h *BufPane) ClearStatus() bool {
 	InfoBar.Message("")
@@ -1230,12 +1914,34 @@ func (h *BufPane) ToggleKeyMenu() bool {
 	return true
 }
 
+// AlternateBuffer switches to the alternate file, the file which was open
+// in the pane before the current one. The split showing it is made active if
+// there is one in the tab, otherwise it is opened in the pane
+func (h *BufPane) AlternateBuffer() bool {
+	if h.altPath == "" {
+		InfoBar.Error("No alternate file")
+		return false
+	}
+	for i, p := range h.tab.Panes {
+		if bp, ok := p.(*BufPane); ok && bp != h && bp.Buf.AbsPath == h.altPath {
+			h.tab.SetActive(i)
+			return true
+		}
+	}
+	h.OpenCmd([]string{shellquote.Join(h.altPath)})
+	return true
+}
+
 // ShellMode opens a terminal to run a shell command
 func (h *BufPane) ShellMode() bool {
 	InfoBar.Prompt("$ ", "", "Shell", nil, func(resp string, canceled bool) {
 		if !canceled {
+			cmd, ok := h.expandFileNames(resp)
+			if !ok {
+				return
+			}
 			// The true here is for openTerm to make the command interactive
-			shell.RunInteractiveShell(resp, true, false)
+			shell.RunInteractiveShellIn(h.wd, cmd, true, false)
 		}
 	})
 
@@ -1252,6 +1958,62 @@ func (h *BufPane) CommandMode() bool {
 	return true
 }
 
+// HistorySearch searches the history of the prompt in the command bar, and
+// does nothing in a buffer
+func (h *BufPane) HistorySearch() bool {
+	return false
+}
+
+// CommandPalette lets the user fuzzy search all commands and actions and
+// runs the chosen one. Actions show the key they are bound to, and commands
+// are opened in the command bar so that arguments can be added
+func (h *BufPane) CommandPalette() bool {
+	run := make(map[string]func())
+
+	for name, action := range paletteActions {
+		name, action := name, action
+		label := name
+		var keys []string
+		for k, v := range config.Bindings {
+			if v == name {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) > 0 {
+			sort.Strings(keys)
+			label += " (" + strings.Join(keys, ", ") + ")"
+		}
+		run[label] = func() {
+			for i, c := range h.Buf.GetCursors() {
+				h.Buf.SetCurCursor(c.Num)
+				h.Cursor = c
+				h.execAction(action, name, i)
+			}
+		}
+	}
+	for name := range commands {
+		name := name
+		run["> "+name] = func() {
+			CommandEditAction(name + " ")(h)
+		}
+	}
+
+	labels := make([]string, 0, len(run))
+	for l := range run {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+
+	InfoBar.Pick("Command palette: ", "CommandPalette", func() []string {
+		return labels
+	}, func(choice string, canceled bool) {
+		if !canceled {
+			run[choice]()
+		}
+	})
+	return true
+}
+
 // ToggleOverwriteMode lets the user toggle the text overwrite mode
 func (h *BufPane) ToggleOverwriteMode() bool {
 	h.isOverwriteMode = !h.isOverwriteMode
@@ -1330,6 +2092,123 @@ func (h *BufPane) QuitAll() bool {
 	return true
 }
 
+// SwitchBuffer lets the user fuzzy search the open buffers and focuses the
+// tab and split showing the chosen one, or opens it in the current split,
+// asking first whether to save the changes of the buffer it replaces
+func (h *BufPane) SwitchBuffer() bool {
+	bufs := make(map[string]*buffer.Buffer)
+	var labels []string
+	for _, b := range buffer.OpenBuffers {
+		if b.Type == buffer.BTInfo {
+			continue
+		}
+		label := b.GetName()
+		if b.Modified() {
+			label += " +"
+		}
+		for n := 2; bufs[label] != nil; n++ {
+			label = fmt.Sprintf("%s (%d)", b.GetName(), n)
+		}
+		bufs[label] = b
+		labels = append(labels, label)
+	}
+
+	InfoBar.Pick("Switch to buffer: ", "SwitchBuffer", func() []string {
+		return labels
+	}, func(choice string, canceled bool) {
+		if canceled {
+			return
+		}
+		b := bufs[choice]
+		for i, t := range Tabs.List {
+			for j, p := range t.Panes {
+				if bp, ok := p.(*BufPane); ok && bp.Buf == b {
+					Tabs.SetActive(i)
+					t.SetActive(j)
+					return
+				}
+			}
+		}
+		if !h.Buf.Modified() || shownElsewhere(h) {
+			h.OpenBuffer(b)
+			return
+		}
+		InfoBar.YNPrompt("Save changes to "+h.Buf.GetName()+" before switching? (y,n,esc)", func(yes, canceled bool) {
+			if canceled {
+				return
+			}
+			if !yes {
+				h.OpenBuffer(b)
+				return
+			}
+			h.saveThen(func(saved bool) {
+				if saved {
+					h.OpenBuffer(b)
+				}
+			})
+		})
+	})
+	return true
+}
+
+// SuggestSpelling lists the corrections for the word under the cursor and
+// replaces the word with the chosen one. The word can also be added to the
+// personal dictionary
+func (h *BufPane) SuggestSpelling() bool {
+	line := []rune(string(h.Buf.LineBytes(h.Cursor.Y)))
+	start := util.Min(h.Cursor.X, len(line))
+	if start > 0 && (start == len(line) || !unicode.IsLetter(line[start])) {
+		start--
+	}
+	for start > 0 && (unicode.IsLetter(line[start-1]) ||
+		line[start-1] == '\'' && start > 1 && unicode.IsLetter(line[start-2])) {
+		start--
+	}
+	if start < len(line) && !unicode.IsLetter(line[start]) {
+		start = len(line)
+	}
+
+	word := string(util.SpellWord([]byte(string(line[start:]))))
+	if word == "" {
+		InfoBar.Error("No word under the cursor")
+		return false
+	}
+
+	dict := config.LoadedDictionary(nil)
+	if dict == nil {
+		InfoBar.Message("The dictionary is still loading")
+		return false
+	}
+	if dict.Len() == 0 {
+		InfoBar.Error("No dictionary found")
+		return false
+	}
+
+	addWord := fmt.Sprintf("Add \"%s\" to dictionary", word)
+	entries := append(dict.Suggest(word, 20), addWord)
+
+	loc := buffer.Loc{X: start, Y: h.Cursor.Y}
+	end := buffer.Loc{X: start + utf8.RuneCountInString(word), Y: h.Cursor.Y}
+	InfoBar.Pick(fmt.Sprintf("Replace \"%s\" with: ", word), "SuggestSpelling", func() []string {
+		return entries
+	}, func(choice string, canceled bool) {
+		if canceled {
+			return
+		}
+		if choice == addWord {
+			if err := config.AddToDictionary(word); err != nil {
+				InfoBar.Error(err)
+				return
+			}
+			InfoBar.Message("Added ", word, " to ", config.PersonalDictionary())
+			return
+		}
+		h.Buf.Replace(loc, end, choice)
+		h.Relocate()
+	})
+	return true
+}
+
 // AddTab adds a new tab with an empty buffer
 func (h *BufPane) AddTab() bool {
 	width, height := screen.Screen.Size()
@@ -1357,6 +2236,16 @@ func (h *BufPane) NextTab() bool {
 	return true
 }
 
+// MoveTabLeft moves the current tab one place to the left in the tab bar
+func (h *BufPane) MoveTabLeft() bool {
+	return Tabs.MoveTab(-1)
+}
+
+// MoveTabRight moves the current tab one place to the right in the tab bar
+func (h *BufPane) MoveTabRight() bool {
+	return Tabs.MoveTab(1)
+}
+
 // VSplitAction opens an empty vertical split
 func (h *BufPane) VSplitAction() bool {
 	h.VSplitBuf(buffer.NewBufferFromString("", "", buffer.BTDefault))
@@ -1386,6 +2275,142 @@ func (h *BufPane) Unsplit() bool {
 	return false
 }
 
+// The number of columns or lines by which GrowSplit and ShrinkSplit resize
+// the current split
+const (
+	splitResizeWidth  = 4
+	splitResizeHeight = 2
+)
+
+// resizeSplit grows the current split by the given number of steps, or
+// shrinks it if steps is negative
+func (h *BufPane) resizeSplit(steps int) bool {
+	n := h.tab.GetNode(h.splitID)
+	if n == nil || n.Parent() == nil {
+		return false
+	}
+	delta := steps * splitResizeWidth
+	if n.Parent().Kind == views.STVert {
+		delta = steps * splitResizeHeight
+	}
+	if !n.ResizeSplitBy(delta) {
+		return false
+	}
+	h.tab.Resize()
+	return true
+}
+
+// GrowSplit makes the current split larger, at the expense of its neighbor
+func (h *BufPane) GrowSplit() bool {
+	return h.resizeSplit(1)
+}
+
+// ShrinkSplit makes the current split smaller, giving the space to its
+// neighbor
+func (h *BufPane) ShrinkSplit() bool {
+	return h.resizeSplit(-1)
+}
+
+// EqualizeSplits gives the same size to all the splits of the current tab
+func (h *BufPane) EqualizeSplits() bool {
+	if len(h.tab.Panes) == 1 {
+		return false
+	}
+	h.tab.Equalize()
+	h.tab.Resize()
+	return true
+}
+
+// SwapSplit exchanges the position of the current split with the next one
+// in the layout
+func (h *BufPane) SwapSplit() bool {
+	if !h.tab.GetNode(h.splitID).SwapNext() {
+		return false
+	}
+	h.tab.Resize()
+	return true
+}
+
+// ToggleZoomSplit makes the current split fill the whole tab, hiding the
+// other splits, or restores the layout of the splits
+func (h *BufPane) ToggleZoomSplit() bool {
+	if len(h.tab.Panes) == 1 && !h.tab.Zoomed() {
+		return false
+	}
+	h.tab.SetZoomed(!h.tab.Zoomed())
+	return true
+}
+
+// MovePaneToNewTab moves the current split out of its tab into a new tab
+func (h *BufPane) MovePaneToNewTab() bool {
+	tab := h.tab
+	if len(tab.Panes) < 2 {
+		InfoBar.Error("The tab has a single split")
+		return false
+	}
+	tab.GetNode(h.splitID).Unsplit()
+	tab.RemovePane(tab.GetPane(h.splitID))
+	tab.Resize()
+	tab.SetActive(len(tab.Panes) - 1)
+
+	width, height := screen.Screen.Size()
+	iOffset := config.GetInfoBarOffset()
+	Tabs.AddTab(NewTabFromPane(0, 0, width, height-1-iOffset, h))
+	Tabs.SetActive(len(Tabs.List) - 1)
+	return true
+}
+
+// MergeTab asks for another tab and moves its active split into the current
+// tab as a vertical split. The other tab is closed if it had a single split
+func (h *BufPane) MergeTab() bool {
+	if len(Tabs.List) < 2 {
+		InfoBar.Error("There is no other tab")
+		return false
+	}
+
+	tabs := make(map[string]*Tab)
+	var labels []string
+	for i, t := range Tabs.List {
+		if t == h.tab {
+			continue
+		}
+		label := fmt.Sprintf("%d: %s", i+1, t.Name())
+		tabs[label] = t
+		labels = append(labels, label)
+	}
+
+	InfoBar.Pick("Merge tab: ", "MergeTab", func() []string {
+		return labels
+	}, func(choice string, canceled bool) {
+		src := tabs[choice]
+		if canceled || src == nil {
+			return
+		}
+		p := src.Panes[src.active]
+		if len(src.Panes) == 1 {
+			Tabs.RemoveTab(p.ID())
+		} else {
+			src.GetNode(p.ID()).Unsplit()
+			src.RemovePane(src.active)
+			src.Resize()
+			src.SetActive(len(src.Panes) - 1)
+		}
+
+		// removing the other tab may have shifted the current one
+		for i, t := range Tabs.List {
+			if t == h.tab {
+				Tabs.SetActive(i)
+			}
+		}
+		p.SetTab(h.tab)
+		p.SetID(h.tab.GetNode(h.splitID).VSplit(h.Buf.Settings["splitright"].(bool)))
+		h.tab.Panes = append(h.tab.Panes, p)
+		h.tab.Resize()
+		h.tab.SetActive(len(h.tab.Panes) - 1)
+	})
+	return true
+}
+
 // NextSplit changes the view to the next split
 func (h *BufPane) NextSplit() bool {
 	a := h.tab.active
@@ -1413,13 +2438,23 @@ func (h *BufPane) PreviousSplit() bool {
 	return true
 }
 
+// A macroCommand is a command recorded in a macro, as it was typed
+type macroCommand string
+
 var curmacro []interface{}
+var prevmacro []interface{}
 var recording_macro bool
 
+// RecordingMacro returns whether a macro is being recorded
+func RecordingMacro() bool {
+	return recording_macro
+}
+
 // ToggleMacro toggles recording of a macro
 func (h *BufPane) ToggleMacro() bool {
 	recording_macro = !recording_macro
 	if recording_macro {
+		prevmacro = curmacro
 		curmacro = []interface{}{}
 		InfoBar.Message("Recording")
 	} else {
@@ -1429,6 +2464,18 @@ func (h *BufPane) ToggleMacro() bool {
 	return true
 }
 
+// CancelMacro stops recording a macro and discards it, the previously
+// recorded macro is kept
+func (h *BufPane) CancelMacro() bool {
+	if !recording_macro {
+		return false
+	}
+	recording_macro = false
+	curmacro = prevmacro
+	InfoBar.Message("Cancelled recording")
+	return true
+}
+
 // PlayMacro plays back the most recently recorded macro
 func (h *BufPane) PlayMacro() bool {
 	if recording_macro {
@@ -1440,6 +2487,8 @@ func (h *BufPane) PlayMacro() bool {
 			h.DoRuneInsert(t)
 		case func(*BufPane) bool:
 			t(h)
+		case macroCommand:
+			h.HandleCommand(string(t))
 		}
 	}
 	h.Relocate()
@@ -1456,6 +2505,11 @@ func (h *BufPane) SpawnMultiCursor() bool {
 		return true
 	}
 
+	if h.Buf.Settings["multicursortrim"].(bool) && !spawner.TrimSelection() {
+		InfoBar.Message("The selection is only whitespace")
+		return false
+	}
+
 	sel := spawner.GetSelection()
 	searchStart := spawner.CurSelection[1]
 
@@ -1556,6 +2610,36 @@ func (h *BufPane) SpawnMultiCursorSelect() bool {
 	return true
 }
 
+// SpawnCursorsAtColumn puts a cursor on every line of the selection, at the
+// column of the cursor. The column is clamped to the end of shorter lines
+func (h *BufPane) SpawnCursorsAtColumn() bool {
+	if h.Buf.NumCursors() > 1 || !h.Cursor.HasSelection() {
+		return false
+	}
+
+	startLine, endLine := h.Cursor.CurSelection[0].Y, h.Cursor.CurSelection[1].Y
+	if startLine > endLine {
+		startLine, endLine = endLine, startLine
+	}
+	vx := h.Cursor.GetVisualX()
+	locAt := func(y int) buffer.Loc {
+		return buffer.Loc{X: h.Cursor.GetCharPosInLine(h.Buf.LineBytes(y), vx), Y: y}
+	}
+
+	h.Cursor.ResetSelection()
+	h.Cursor.GotoLoc(locAt(startLine))
+	h.Cursor.LastVisualX = vx
+	for i := startLine + 1; i <= endLine; i++ {
+		c := buffer.NewCursor(h.Buf, locAt(i))
+		// moving up and down keeps the column even from shorter lines
+		c.LastVisualX = vx
+		h.Buf.AddCursor(c)
+	}
+	h.Buf.MergeCursors()
+	InfoBar.Message("Added cursors at the column")
+	return true
+}
+
 // MouseMultiCursor is a mouse action which puts a new cursor at the mouse position
 func (h *BufPane) MouseMultiCursor(e *tcell.EventMouse) bool {
 	b := h.Buf
@@ -1571,6 +2655,11 @@ func (h *BufPane) MouseMultiCursor(e *tcell.EventMouse) bool {
 // SkipMultiCursor moves the current multiple cursor to the next available position
 func (h *BufPane) SkipMultiCursor() bool {
 	lastC := h.Buf.GetCursor(h.Buf.NumCursors() - 1)
+	if h.Buf.Settings["multicursortrim"].(bool) && lastC.HasSelection() && !lastC.TrimSelection() {
+		InfoBar.Message("The selection is only whitespace")
+		return false
+	}
+
 	sel := lastC.GetSelection()
 	searchStart := lastC.CurSelection[1]
 
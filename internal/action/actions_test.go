@@ -0,0 +1,29 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zyedidia/clipboard"
+	"github.com/zyedidia/micro/internal/buffer"
+)
+
+func TestPasteAsLines(t *testing.T) {
+	h := newTestPane("a\nb")
+	clipEntries = []string{"x", "y"}
+	clipboard.WriteAll("x\ny", "clipboard")
+
+	h.PasteAsLines()
+	assert.Equal(t, "a\nx\ny\nb", string(h.Buf.Bytes()))
+	assert.Equal(t, buffer.Loc{X: 0, Y: 2}, h.Cursor.Loc)
+}
+
+func TestRenameWord(t *testing.T) {
+	h := newTestPane("café cafés xcafé café_1\ncafé")
+	assert.Equal(t, 2, h.renameWord("café", "bar"))
+	assert.Equal(t, "bar cafés xcafé café_1\nbar", string(h.Buf.Bytes()))
+
+	h = newTestPane("名前 名前空間 名前")
+	assert.Equal(t, 2, h.renameWord("名前", "name"))
+	assert.Equal(t, "name 名前空間 name", string(h.Buf.Bytes()))
+}
@@ -0,0 +1,48 @@
+package action
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/zyedidia/micro/internal/buffer"
+)
+
+// ToggleGitBlame shows or hides the commit and the author which last changed
+// every line, in a margin left of the gutter
+func (h *BufPane) ToggleGitBlame() bool {
+	if h.Buf.ShowingBlame() {
+		h.Buf.HideBlame()
+		return true
+	}
+	if h.Buf.Path == "" || h.Buf.Type != buffer.BTDefault {
+		InfoBar.Error("Cannot blame a buffer without a file")
+		return false
+	}
+	if err := h.Buf.LoadBlame(); err != nil {
+		InfoBar.Error("git blame: ", err)
+		return false
+	}
+	return true
+}
+
+// ShowBlameCommit shows the full message of the commit which last changed
+// the line of the cursor when the git blame is shown
+func (h *BufPane) ShowBlameCommit() bool {
+	if !h.Buf.ShowingBlame() {
+		return false
+	}
+	bl := h.Buf.BlameAt(h.Cursor.Y)
+	if bl == nil {
+		InfoBar.Message("Not committed yet")
+		return true
+	}
+
+	out, err := exec.Command("git", "-C", filepath.Dir(h.Buf.AbsPath), "show", "-s", "--format=%h %an, %ar: %B", bl.Hash).Output()
+	if err != nil {
+		InfoBar.Message(bl.Hash[:7], " ", bl.Author, ": ", bl.Summary)
+		return true
+	}
+	InfoBar.Message(strings.Join(strings.Fields(string(out)), " "))
+	return true
+}
@@ -1,8 +1,10 @@
 package action
 
 import (
+	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	luar "layeh.com/gopher-luar"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/zyedidia/micro/internal/display"
 	ulua "github.com/zyedidia/micro/internal/lua"
 	"github.com/zyedidia/micro/internal/screen"
+	"github.com/zyedidia/micro/internal/util"
 	"github.com/zyedidia/tcell"
 )
 
@@ -22,10 +25,16 @@ var BufKeyBindings map[Event]BufKeyAction
 var BufKeyStrings map[Event]string
 var BufMouseBindings map[MouseEvent]BufMouseAction
 
+// paletteActions is the same map as BufKeyActions, it is assigned in
+// init because actions which list all actions would otherwise create
+// an initialization cycle
+var paletteActions map[string]BufKeyAction
+
 func init() {
 	BufKeyBindings = make(map[Event]BufKeyAction)
 	BufKeyStrings = make(map[Event]string)
 	BufMouseBindings = make(map[MouseEvent]BufMouseAction)
+	paletteActions = BufKeyActions
 }
 
 func LuaAction(fn string) func(*BufPane) bool {
@@ -54,6 +63,12 @@ func LuaAction(fn string) func(*BufPane) bool {
 // BufMapKey maps a key event to an action
 func BufMapKey(k Event, action string) {
 	BufKeyStrings[k] = action
+	BufKeyBindings[k] = bufKeyAction(action)
+}
+
+// bufKeyAction returns the function running the actions of a binding, which
+// may be chained with &, | or ,
+func bufKeyAction(action string) BufKeyAction {
 	var actionfns []func(*BufPane) bool
 	var names []string
 	var types []byte
@@ -108,11 +123,19 @@ func BufMapKey(k Event, action string) {
 		}
 		actionfns = append(actionfns, afn)
 	}
-	BufKeyBindings[k] = func(h *BufPane) bool {
-		cursors := h.Buf.GetCursors()
+	return func(h *BufPane) bool {
 		success := true
 		for i, a := range actionfns {
+			// an action may add or remove cursors, so the next one runs
+			// with the cursors it left. The list is copied since removing
+			// cursors changes the array of the buffer
+			cursors := append([]*buffer.Cursor(nil), h.Buf.GetCursors()...)
 			for j, c := range cursors {
+				if j > 0 && !MultiActions[names[i]] {
+					// the action only ran for the first cursor, its
+					// result decides the rest of the chain
+					break
+				}
 				h.Buf.SetCurCursor(c.Num)
 				h.Cursor = c
 				if i == 0 || (success && types[i-1] == '&') || (!success && types[i-1] == '|') || (types[i-1] == ',') {
@@ -120,12 +143,28 @@ func BufMapKey(k Event, action string) {
 				} else {
 					break
 				}
+				if !sameCursors(cursors, h.Buf.GetCursors()) {
+					break
+				}
 			}
 		}
 		return true
 	}
 }
 
+// sameCursors returns whether two lists of cursors hold the same cursors
+func sameCursors(a, b []*buffer.Cursor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // BufMapMouse maps a mouse event to an action
 func BufMapMouse(k MouseEvent, action string) {
 	if f, ok := BufMouseActions[action]; ok {
@@ -174,6 +213,14 @@ type BufPane struct {
 	doubleClick bool
 	// Same here, just to keep track for mouse move events
 	tripleClick bool
+	// A click after a triple click is a quadruple click, which selects
+	// the paragraph
+	quadClick bool
+	// Was the buffer read-only before the tail mode made it read-only
+	tailReadonly bool
+	// Is the action bound to a mouse event running, the scroll actions
+	// then scroll by mousescrollspeed lines
+	mouseAction bool
 
 	// Last search stores the last successful search for FindNext and FindPrev
 	lastSearch string
@@ -186,6 +233,20 @@ type BufPane struct {
 
 	// remember original location of a search in case the search is canceled
 	searchOrig buffer.Loc
+
+	// the colorcolumn value to restore when ToggleColorColumn re-enables it
+	lastColorColumn string
+
+	// the snippet whose tab stops are being filled in
+	snippet *activeSnippet
+
+	// the working directory of the pane set with lcd, the working directory
+	// of micro is used if it is empty
+	wd string
+
+	// the path of the file which was shown in the pane before the current
+	// one, the alternate file
+	altPath string
 }
 
 func NewBufPane(buf *buffer.Buffer, win display.BWindow, tab *Tab) *BufPane {
@@ -243,6 +304,9 @@ func (h *BufPane) PluginCBRune(cb string, r rune) bool {
 }
 
 func (h *BufPane) OpenBuffer(b *buffer.Buffer) {
+	if h.Buf.Path != "" && h.Buf.AbsPath != b.AbsPath {
+		h.altPath = h.Buf.AbsPath
+	}
 	h.Buf.Close()
 	h.Buf = b
 	h.BWindow.SetBuffer(b)
@@ -270,17 +334,97 @@ func (h *BufPane) Name() string {
 	return h.Buf.GetName()
 }
 
-// HandleEvent executes the tcell event properly
-func (h *BufPane) HandleEvent(event tcell.Event) {
-	if h.Buf.ExternallyModified() {
-		InfoBar.YNPrompt("The file on disk has changed. Reload file? (y,n)", func(yes, canceled bool) {
-			if !yes || canceled {
-				h.Buf.UpdateModTime()
-			} else {
-				h.Buf.ReOpen()
+// externalChange handles a modification of the file on disk by another
+// process. With autoreload an unmodified buffer is reloaded right away,
+// otherwise the user is asked whether to reload it
+func (h *BufPane) externalChange() {
+	if h.Buf.Settings["autoreload"].(bool) && !h.Buf.Modified() {
+		if err := h.Buf.ReOpen(); err != nil {
+			InfoBar.Error(err)
+			return
+		}
+		h.Relocate()
+		InfoBar.Message("Reloaded ", h.Buf.GetName(), " which changed on disk")
+		return
+	}
+
+	InfoBar.YNPrompt("The file on disk has changed. Reload file? (y,n)", func(yes, canceled bool) {
+		if !yes || canceled {
+			h.Buf.UpdateModTime()
+		} else {
+			h.Buf.ReOpen()
+		}
+	})
+}
+
+// CheckExternalChanges checks the files of the buffers using the autoreload
+// option for modifications made by other processes, without waiting for an
+// event in their pane
+func CheckExternalChanges() {
+	if InfoBar.HasPrompt {
+		return
+	}
+	for _, t := range Tabs.List {
+		for _, p := range t.Panes {
+			h, ok := p.(*BufPane)
+			if ok && h.Buf.Tailing() {
+				h.followTail()
+				continue
 			}
-		})
+			if !ok || h.Buf.Type != buffer.BTDefault || !h.Buf.Settings["autoreload"].(bool) {
+				continue
+			}
+			if h.Buf.ExternalChangeSettled() {
+				h.externalChange()
+				if InfoBar.HasPrompt {
+					return
+				}
+			}
+		}
+	}
+}
+
+// AutoSave saves the modified buffers which have a file, when the timer of
+// the autosave option fires. The buffers whose file was changed by another
+// process or cannot be written are skipped, since saving them would need a
+// prompt. A buffer which fails to save does not stop the others from being
+// saved, and the failures are reported once all of them were tried
+func AutoSave() {
+	var saved, failed []string
+	var saveErr error
+	for _, b := range buffer.OpenBuffers {
+		if !b.Modified() || b.Path == "" || b.Type != buffer.BTDefault || b.ExternallyModified() {
+			continue
+		}
+		if f, err := os.OpenFile(b.AbsPath, os.O_WRONLY, 0); err == nil {
+			f.Close()
+		} else if !os.IsNotExist(err) {
+			continue
+		}
+		if err := b.Save(); err != nil {
+			failed = append(failed, b.GetName())
+			saveErr = err
+			continue
+		}
+		saved = append(saved, b.GetName())
+	}
+	if len(failed) == 1 {
+		InfoBar.Error("Autosave of ", failed[0], " failed: ", saveErr)
+	} else if len(failed) > 1 {
+		InfoBar.Error("Autosave of ", strings.Join(failed, ", "), " failed")
+	} else if len(saved) == 1 {
+		InfoBar.Message("Autosaved ", saved[0])
+	} else if len(saved) > 1 {
+		InfoBar.Message("Autosaved ", len(saved), " files")
+	}
+}
 
+// HandleEvent executes the tcell event properly
+func (h *BufPane) HandleEvent(event tcell.Event) {
+	if h.Buf.Tailing() {
+		h.followTail()
+	} else if h.Buf.ExternallyModified() {
+		h.externalChange()
 	}
 
 	switch e := event.(type) {
@@ -291,6 +435,7 @@ func (h *BufPane) HandleEvent(event tcell.Event) {
 		h.DoKeyEvent(re)
 	case *tcell.EventPaste:
 		h.paste(e.Text())
+		h.editDenied()
 		h.Relocate()
 	case *tcell.EventKey:
 		ke := KeyEvent{
@@ -299,9 +444,10 @@ func (h *BufPane) HandleEvent(event tcell.Event) {
 			r:    e.Rune(),
 		}
 
-		done := h.DoKeyEvent(ke)
+		done := h.DoKeyLayerEvent(ke) || h.DoKeyEvent(ke)
 		if !done && e.Key() == tcell.KeyRune {
 			h.DoRuneInsert(e.Rune())
+			h.editDenied()
 		}
 	case *tcell.EventMouse:
 		cancel := false
@@ -386,10 +532,15 @@ func (h *BufPane) execAction(action func(*BufPane) bool, name string, cursor int
 	if (!isMulti && cursor == 0) || isMulti {
 		if h.PluginCB("pre" + name) {
 			success := action(h)
+			if h.editDenied() {
+				success = false
+			}
 			success = success && h.PluginCB("on"+name)
 
 			if isMulti {
-				if recording_macro {
+				// the keys typed in a prompt belong to the command which
+				// opened it and are not recorded
+				if recording_macro && h.Buf.Type != buffer.BTInfo {
 					if name != "ToggleMacro" && name != "PlayMacro" {
 						curmacro = append(curmacro, action)
 					}
@@ -403,6 +554,16 @@ func (h *BufPane) execAction(action func(*BufPane) bool, name string, cursor int
 	return false
 }
 
+// editDenied tells the user if the last action tried to edit the buffer
+// while it is read-only, and returns whether it did
+func (h *BufPane) editDenied() bool {
+	if !h.Buf.EditDenied() {
+		return false
+	}
+	InfoBar.Message("The buffer is read-only")
+	return true
+}
+
 func (h *BufPane) completeAction(action string) {
 	h.PluginCB("on" + action)
 }
@@ -421,6 +582,8 @@ func (h *BufPane) DoMouseEvent(e MouseEvent, te *tcell.EventMouse) bool {
 		}
 		return true
 	} else if h.HasKeyEvent(e) {
+		h.mouseAction = true
+		defer func() { h.mouseAction = false }()
 		return h.DoKeyEvent(e)
 	}
 	return false
@@ -442,6 +605,13 @@ func (h *BufPane) DoRuneInsert(r rune) {
 			c.ResetSelection()
 		}
 
+		if c.Virtual > 0 {
+			// pad the line up to the cursor in the virtual space
+			spaces := strings.Repeat(" ", c.Virtual)
+			c.Virtual = 0
+			h.Buf.Insert(c.Loc, spaces)
+		}
+
 		if h.isOverwriteMode {
 			next := c.Loc
 			next.X++
@@ -449,16 +619,38 @@ func (h *BufPane) DoRuneInsert(r rune) {
 		} else {
 			h.Buf.Insert(c.Loc, string(r))
 		}
-		if recording_macro {
+		if !h.isOverwriteMode && h.Buf.Settings["smartindent"].(bool) {
+			h.outdentClosingBrace(r)
+		}
+		if recording_macro && h.Buf.Type != buffer.BTInfo {
 			curmacro = append(curmacro, r)
 		}
 		h.PluginCBRune("onRune", r)
 	}
 }
 
+// outdentClosingBrace indents a closing brace which was just typed alone
+// on its line like the line of the opening brace
+func (h *BufPane) outdentClosingBrace(r rune) {
+	line := h.Buf.LineBytes(h.Cursor.Y)
+	ws := util.GetLeadingWhitespace(line)
+	if len(ws)+utf8.RuneLen(r) != len(line) {
+		return
+	}
+	for _, bp := range buffer.BracePairs {
+		if r != bp[1] {
+			continue
+		}
+		if open, _ := h.Buf.FindMatchingBrace(bp, h.Cursor.Loc); open != h.Cursor.Loc {
+			h.setIndent(h.Cursor.Y, util.GetLeadingWhitespace(h.Buf.LineBytes(open.Y)))
+		}
+	}
+}
+
 func (h *BufPane) VSplitIndex(buf *buffer.Buffer, right bool) *BufPane {
 	e := NewBufPaneFromBuf(buf, h.tab)
 	e.splitID = MainTab().GetNode(h.splitID).VSplit(right)
+	e.wd = h.wd
 	MainTab().Panes = append(MainTab().Panes, e)
 	MainTab().Resize()
 	MainTab().SetActive(len(MainTab().Panes) - 1)
@@ -467,6 +659,7 @@ func (h *BufPane) VSplitIndex(buf *buffer.Buffer, right bool) *BufPane {
 func (h *BufPane) HSplitIndex(buf *buffer.Buffer, bottom bool) *BufPane {
 	e := NewBufPaneFromBuf(buf, h.tab)
 	e.splitID = MainTab().GetNode(h.splitID).HSplit(bottom)
+	e.wd = h.wd
 	MainTab().Panes = append(MainTab().Panes, e)
 	MainTab().Resize()
 	MainTab().SetActive(len(MainTab().Panes) - 1)
@@ -505,104 +698,180 @@ func (h *BufPane) SetActive(b bool) {
 
 // BufKeyActions contains the list of all possible key actions the bufhandler could execute
 var BufKeyActions = map[string]BufKeyAction{
-	"CursorUp":               (*BufPane).CursorUp,
-	"CursorDown":             (*BufPane).CursorDown,
-	"CursorPageUp":           (*BufPane).CursorPageUp,
-	"CursorPageDown":         (*BufPane).CursorPageDown,
-	"CursorLeft":             (*BufPane).CursorLeft,
-	"CursorRight":            (*BufPane).CursorRight,
-	"CursorStart":            (*BufPane).CursorStart,
-	"CursorEnd":              (*BufPane).CursorEnd,
-	"SelectToStart":          (*BufPane).SelectToStart,
-	"SelectToEnd":            (*BufPane).SelectToEnd,
-	"SelectUp":               (*BufPane).SelectUp,
-	"SelectDown":             (*BufPane).SelectDown,
-	"SelectLeft":             (*BufPane).SelectLeft,
-	"SelectRight":            (*BufPane).SelectRight,
-	"WordRight":              (*BufPane).WordRight,
-	"WordLeft":               (*BufPane).WordLeft,
-	"SelectWordRight":        (*BufPane).SelectWordRight,
-	"SelectWordLeft":         (*BufPane).SelectWordLeft,
-	"DeleteWordRight":        (*BufPane).DeleteWordRight,
-	"DeleteWordLeft":         (*BufPane).DeleteWordLeft,
-	"SelectLine":             (*BufPane).SelectLine,
-	"SelectToStartOfLine":    (*BufPane).SelectToStartOfLine,
-	"SelectToStartOfText":    (*BufPane).SelectToStartOfText,
-	"SelectToEndOfLine":      (*BufPane).SelectToEndOfLine,
-	"ParagraphPrevious":      (*BufPane).ParagraphPrevious,
-	"ParagraphNext":          (*BufPane).ParagraphNext,
-	"InsertNewline":          (*BufPane).InsertNewline,
-	"Backspace":              (*BufPane).Backspace,
-	"Delete":                 (*BufPane).Delete,
-	"InsertTab":              (*BufPane).InsertTab,
-	"Save":                   (*BufPane).Save,
-	"SaveAll":                (*BufPane).SaveAll,
-	"SaveAs":                 (*BufPane).SaveAs,
-	"Find":                   (*BufPane).Find,
-	"FindNext":               (*BufPane).FindNext,
-	"FindPrevious":           (*BufPane).FindPrevious,
-	"Center":                 (*BufPane).Center,
-	"Undo":                   (*BufPane).Undo,
-	"Redo":                   (*BufPane).Redo,
-	"Copy":                   (*BufPane).Copy,
-	"Cut":                    (*BufPane).Cut,
-	"CutLine":                (*BufPane).CutLine,
-	"DuplicateLine":          (*BufPane).DuplicateLine,
-	"DeleteLine":             (*BufPane).DeleteLine,
-	"MoveLinesUp":            (*BufPane).MoveLinesUp,
-	"MoveLinesDown":          (*BufPane).MoveLinesDown,
-	"IndentSelection":        (*BufPane).IndentSelection,
-	"OutdentSelection":       (*BufPane).OutdentSelection,
-	"Autocomplete":           (*BufPane).Autocomplete,
-	"CycleAutocompleteBack":  (*BufPane).CycleAutocompleteBack,
-	"OutdentLine":            (*BufPane).OutdentLine,
-	"Paste":                  (*BufPane).Paste,
-	"PastePrimary":           (*BufPane).PastePrimary,
-	"SelectAll":              (*BufPane).SelectAll,
-	"OpenFile":               (*BufPane).OpenFile,
-	"Start":                  (*BufPane).Start,
-	"End":                    (*BufPane).End,
-	"PageUp":                 (*BufPane).PageUp,
-	"PageDown":               (*BufPane).PageDown,
-	"SelectPageUp":           (*BufPane).SelectPageUp,
-	"SelectPageDown":         (*BufPane).SelectPageDown,
-	"HalfPageUp":             (*BufPane).HalfPageUp,
-	"HalfPageDown":           (*BufPane).HalfPageDown,
-	"StartOfText":            (*BufPane).StartOfText,
-	"StartOfLine":            (*BufPane).StartOfLine,
-	"EndOfLine":              (*BufPane).EndOfLine,
-	"ToggleHelp":             (*BufPane).ToggleHelp,
-	"ToggleKeyMenu":          (*BufPane).ToggleKeyMenu,
-	"ToggleRuler":            (*BufPane).ToggleRuler,
-	"ClearStatus":            (*BufPane).ClearStatus,
-	"ShellMode":              (*BufPane).ShellMode,
-	"CommandMode":            (*BufPane).CommandMode,
-	"ToggleOverwriteMode":    (*BufPane).ToggleOverwriteMode,
-	"Escape":                 (*BufPane).Escape,
-	"Quit":                   (*BufPane).Quit,
-	"QuitAll":                (*BufPane).QuitAll,
-	"AddTab":                 (*BufPane).AddTab,
-	"PreviousTab":            (*BufPane).PreviousTab,
-	"NextTab":                (*BufPane).NextTab,
-	"NextSplit":              (*BufPane).NextSplit,
-	"PreviousSplit":          (*BufPane).PreviousSplit,
-	"Unsplit":                (*BufPane).Unsplit,
-	"VSplit":                 (*BufPane).VSplitAction,
-	"HSplit":                 (*BufPane).HSplitAction,
-	"ToggleMacro":            (*BufPane).ToggleMacro,
-	"PlayMacro":              (*BufPane).PlayMacro,
-	"Suspend":                (*BufPane).Suspend,
-	"ScrollUp":               (*BufPane).ScrollUpAction,
-	"ScrollDown":             (*BufPane).ScrollDownAction,
-	"SpawnMultiCursor":       (*BufPane).SpawnMultiCursor,
-	"SpawnMultiCursorUp":     (*BufPane).SpawnMultiCursorUp,
-	"SpawnMultiCursorDown":   (*BufPane).SpawnMultiCursorDown,
-	"SpawnMultiCursorSelect": (*BufPane).SpawnMultiCursorSelect,
-	"RemoveMultiCursor":      (*BufPane).RemoveMultiCursor,
-	"RemoveAllMultiCursors":  (*BufPane).RemoveAllMultiCursors,
-	"SkipMultiCursor":        (*BufPane).SkipMultiCursor,
-	"JumpToMatchingBrace":    (*BufPane).JumpToMatchingBrace,
-	"None":                   (*BufPane).None,
+	"CursorUp":                  (*BufPane).CursorUp,
+	"CursorDown":                (*BufPane).CursorDown,
+	"CursorPageUp":              (*BufPane).CursorPageUp,
+	"CursorPageDown":            (*BufPane).CursorPageDown,
+	"CursorLeft":                (*BufPane).CursorLeft,
+	"CursorRight":               (*BufPane).CursorRight,
+	"CursorStart":               (*BufPane).CursorStart,
+	"CursorEnd":                 (*BufPane).CursorEnd,
+	"SelectToStart":             (*BufPane).SelectToStart,
+	"SelectToEnd":               (*BufPane).SelectToEnd,
+	"SelectUp":                  (*BufPane).SelectUp,
+	"SelectDown":                (*BufPane).SelectDown,
+	"SelectLeft":                (*BufPane).SelectLeft,
+	"SelectRight":               (*BufPane).SelectRight,
+	"WordRight":                 (*BufPane).WordRight,
+	"WordLeft":                  (*BufPane).WordLeft,
+	"SelectWordRight":           (*BufPane).SelectWordRight,
+	"SelectWordLeft":            (*BufPane).SelectWordLeft,
+	"DeleteWordRight":           (*BufPane).DeleteWordRight,
+	"DeleteWordLeft":            (*BufPane).DeleteWordLeft,
+	"SelectLine":                (*BufPane).SelectLine,
+	"SelectToStartOfLine":       (*BufPane).SelectToStartOfLine,
+	"SelectToStartOfText":       (*BufPane).SelectToStartOfText,
+	"SelectToStartOfTextToggle": (*BufPane).SelectToStartOfTextToggle,
+	"SelectToEndOfLine":         (*BufPane).SelectToEndOfLine,
+	"ParagraphPrevious":         (*BufPane).ParagraphPrevious,
+	"ParagraphNext":             (*BufPane).ParagraphNext,
+	"NextSiblingLine":           (*BufPane).NextSiblingLine,
+	"PrevSiblingLine":           (*BufPane).PrevSiblingLine,
+	"SelectBlock":               (*BufPane).SelectBlock,
+	"InsertNewline":             (*BufPane).InsertNewline,
+	"Backspace":                 (*BufPane).Backspace,
+	"Delete":                    (*BufPane).Delete,
+	"InsertTab":                 (*BufPane).InsertTab,
+	"InsertDateTime":            (*BufPane).InsertDateTime,
+	"InsertUUID":                (*BufPane).InsertUUID,
+	"InsertFilePath":            (*BufPane).InsertFilePath,
+	"InsertRelativeFilePath":    (*BufPane).InsertRelativeFilePath,
+	"EvalMath":                  (*BufPane).EvalMath,
+	"SumColumn":                 (*BufPane).SumColumn,
+	"IncrementByCursorIndex":    (*BufPane).IncrementByCursorIndex,
+	"DecrementByCursorIndex":    (*BufPane).DecrementByCursorIndex,
+	"Save":                      (*BufPane).Save,
+	"SaveAll":                   (*BufPane).SaveAll,
+	"SaveAs":                    (*BufPane).SaveAs,
+	"RevertBuffer":              (*BufPane).RevertBuffer,
+	"DiffWithDisk":              (*BufPane).DiffWithDisk,
+	"DiffNext":                  (*BufPane).DiffNext,
+	"DiffPrevious":              (*BufPane).DiffPrevious,
+	"RevertHunk":                (*BufPane).RevertHunk,
+	"StageHunk":                 (*BufPane).StageHunk,
+	"ToggleGitBlame":            (*BufPane).ToggleGitBlame,
+	"ShowBlameCommit":           (*BufPane).ShowBlameCommit,
+	"Find":                      (*BufPane).Find,
+	"FindNext":                  (*BufPane).FindNext,
+	"FindPrevious":              (*BufPane).FindPrevious,
+	"NextWhitespaceIssue":       (*BufPane).NextWhitespaceIssue,
+	"NextLintError":             (*BufPane).NextLintError,
+	"PrevLintError":             (*BufPane).PrevLintError,
+	"Center":                    (*BufPane).Center,
+	"Undo":                      (*BufPane).Undo,
+	"Redo":                      (*BufPane).Redo,
+	"UndoBranchOlder":           (*BufPane).UndoBranchOlder,
+	"UndoBranchNewer":           (*BufPane).UndoBranchNewer,
+	"ClearHistory":              (*BufPane).ClearHistory,
+	"Copy":                      (*BufPane).Copy,
+	"CopyFilePath":              (*BufPane).CopyFilePath,
+	"CopyRelativeFilePath":      (*BufPane).CopyRelativeFilePath,
+	"Cut":                       (*BufPane).Cut,
+	"CutLine":                   (*BufPane).CutLine,
+	"DuplicateLine":             (*BufPane).DuplicateLine,
+	"DuplicateAndComment":       (*BufPane).DuplicateAndComment,
+	"JoinWithSeparator":         (*BufPane).JoinWithSeparator,
+	"SplitOnDelimiter":          (*BufPane).SplitOnDelimiter,
+	"DeleteLine":                (*BufPane).DeleteLine,
+	"MoveLinesUp":               (*BufPane).MoveLinesUp,
+	"MoveLinesDown":             (*BufPane).MoveLinesDown,
+	"SortLines":                 (*BufPane).SortLines,
+	"SortLinesNumeric":          (*BufPane).SortLinesNumeric,
+	"SortByKey":                 (*BufPane).SortByKey,
+	"UniqueLines":               (*BufPane).UniqueLines,
+	"ShuffleLines":              (*BufPane).ShuffleLines,
+	"ReverseLines":              (*BufPane).ReverseLines,
+	"NumberLines":               (*BufPane).NumberLines,
+	"StripLineNumbers":          (*BufPane).StripLineNumbers,
+	"IndentSelection":           (*BufPane).IndentSelection,
+	"OutdentSelection":          (*BufPane).OutdentSelection,
+	"Autocomplete":              (*BufPane).Autocomplete,
+	"ExpandSnippet":             (*BufPane).ExpandSnippet,
+	"NextSnippetStop":           (*BufPane).NextSnippetStop,
+	"CycleAutocompleteBack":     (*BufPane).CycleAutocompleteBack,
+	"OutdentLine":               (*BufPane).OutdentLine,
+	"IndentToPreviousLine":      (*BufPane).IndentToPreviousLine,
+	"Paste":                     (*BufPane).Paste,
+	"PastePrimary":              (*BufPane).PastePrimary,
+	"PasteAsLines":              (*BufPane).PasteAsLines,
+	"SelectAll":                 (*BufPane).SelectAll,
+	"OpenFile":                  (*BufPane).OpenFile,
+	"OpenFileUnderCursor":       (*BufPane).OpenFileUnderCursor,
+	"OpenURL":                   (*BufPane).OpenURL,
+	"RevealInFileManager":       (*BufPane).RevealInFileManager,
+	"FuzzyOpen":                 (*BufPane).FuzzyOpen,
+	"Start":                     (*BufPane).Start,
+	"End":                       (*BufPane).End,
+	"PageUp":                    (*BufPane).PageUp,
+	"PageDown":                  (*BufPane).PageDown,
+	"SelectPageUp":              (*BufPane).SelectPageUp,
+	"SelectPageDown":            (*BufPane).SelectPageDown,
+	"HalfPageUp":                (*BufPane).HalfPageUp,
+	"HalfPageDown":              (*BufPane).HalfPageDown,
+	"StartOfText":               (*BufPane).StartOfText,
+	"StartOfTextToggle":         (*BufPane).StartOfTextToggle,
+	"StartOfLine":               (*BufPane).StartOfLine,
+	"EndOfLine":                 (*BufPane).EndOfLine,
+	"ToggleHelp":                (*BufPane).ToggleHelp,
+	"ToggleKeyMenu":             (*BufPane).ToggleKeyMenu,
+	"ToggleRuler":               (*BufPane).ToggleRuler,
+	"ToggleReadOnly":            (*BufPane).ToggleReadOnly,
+	"ToggleTail":                (*BufPane).ToggleTail,
+	"ToggleCenterCursor":        (*BufPane).ToggleCenterCursor,
+	"ToggleShowWhitespace":      (*BufPane).ToggleShowWhitespace,
+	"ToggleIndentGuides":        (*BufPane).ToggleIndentGuides,
+	"ToggleColorColumn":         (*BufPane).ToggleColorColumn,
+	"ToggleMinimap":             (*BufPane).ToggleMinimap,
+	"ClearStatus":               (*BufPane).ClearStatus,
+	"ShellMode":                 (*BufPane).ShellMode,
+	"CommandMode":               (*BufPane).CommandMode,
+	"HistorySearch":             (*BufPane).HistorySearch,
+	"CommandPalette":            (*BufPane).CommandPalette,
+	"ToggleOverwriteMode":       (*BufPane).ToggleOverwriteMode,
+	"Escape":                    (*BufPane).Escape,
+	"Quit":                      (*BufPane).Quit,
+	"QuitAll":                   (*BufPane).QuitAll,
+	"AddTab":                    (*BufPane).AddTab,
+	"PreviousTab":               (*BufPane).PreviousTab,
+	"NextTab":                   (*BufPane).NextTab,
+	"MoveTabLeft":               (*BufPane).MoveTabLeft,
+	"MoveTabRight":              (*BufPane).MoveTabRight,
+	"SwitchBuffer":              (*BufPane).SwitchBuffer,
+	"AlternateBuffer":           (*BufPane).AlternateBuffer,
+	"SuggestSpelling":           (*BufPane).SuggestSpelling,
+	"NextSplit":                 (*BufPane).NextSplit,
+	"PreviousSplit":             (*BufPane).PreviousSplit,
+	"Unsplit":                   (*BufPane).Unsplit,
+	"EqualizeSplits":            (*BufPane).EqualizeSplits,
+	"GrowSplit":                 (*BufPane).GrowSplit,
+	"ShrinkSplit":               (*BufPane).ShrinkSplit,
+	"SwapSplit":                 (*BufPane).SwapSplit,
+	"ToggleZoomSplit":           (*BufPane).ToggleZoomSplit,
+	"MovePaneToNewTab":          (*BufPane).MovePaneToNewTab,
+	"MergeTab":                  (*BufPane).MergeTab,
+	"VSplit":                    (*BufPane).VSplitAction,
+	"HSplit":                    (*BufPane).HSplitAction,
+	"ToggleMacro":               (*BufPane).ToggleMacro,
+	"CancelMacro":               (*BufPane).CancelMacro,
+	"PlayMacro":                 (*BufPane).PlayMacro,
+	"Suspend":                   (*BufPane).Suspend,
+	"ScrollUp":                  (*BufPane).ScrollUpAction,
+	"ScrollDown":                (*BufPane).ScrollDownAction,
+	"ScrollLeft":                (*BufPane).ScrollLeftAction,
+	"ScrollRight":               (*BufPane).ScrollRightAction,
+	"SpawnMultiCursor":          (*BufPane).SpawnMultiCursor,
+	"RenameInBuffer":            (*BufPane).RenameInBuffer,
+	"GotoDefinition":            (*BufPane).GotoDefinition,
+	"Hover":                     (*BufPane).Hover,
+	"SpawnMultiCursorUp":        (*BufPane).SpawnMultiCursorUp,
+	"SpawnMultiCursorDown":      (*BufPane).SpawnMultiCursorDown,
+	"SpawnMultiCursorSelect":    (*BufPane).SpawnMultiCursorSelect,
+	"SpawnCursorsAtColumn":      (*BufPane).SpawnCursorsAtColumn,
+	"RemoveMultiCursor":         (*BufPane).RemoveMultiCursor,
+	"RemoveAllMultiCursors":     (*BufPane).RemoveAllMultiCursors,
+	"SkipMultiCursor":           (*BufPane).SkipMultiCursor,
+	"JumpToMatchingBrace":       (*BufPane).JumpToMatchingBrace,
+	"None":                      (*BufPane).None,
 
 	// This was changed to InsertNewline but I don't want to break backwards compatibility
 	"InsertEnter": (*BufPane).InsertNewline,
@@ -610,8 +879,9 @@ var BufKeyActions = map[string]BufKeyAction{
 
 // BufMouseActions contains the list of all possible mouse actions the bufhandler could execute
 var BufMouseActions = map[string]BufMouseAction{
-	"MousePress":       (*BufPane).MousePress,
-	"MouseMultiCursor": (*BufPane).MouseMultiCursor,
+	"MousePress":        (*BufPane).MousePress,
+	"MouseMultiCursor":  (*BufPane).MouseMultiCursor,
+	"MousePastePrimary": (*BufPane).MousePastePrimary,
 }
 
 // MultiActions is a list of actions that should be executed multiple
@@ -619,53 +889,64 @@ var BufMouseActions = map[string]BufMouseAction{
 // Generally actions that modify global editor state like quitting or
 // saving should not be included in this list
 var MultiActions = map[string]bool{
-	"CursorUp":            true,
-	"CursorDown":          true,
-	"CursorPageUp":        true,
-	"CursorPageDown":      true,
-	"CursorLeft":          true,
-	"CursorRight":         true,
-	"CursorStart":         true,
-	"CursorEnd":           true,
-	"SelectToStart":       true,
-	"SelectToEnd":         true,
-	"SelectUp":            true,
-	"SelectDown":          true,
-	"SelectLeft":          true,
-	"SelectRight":         true,
-	"WordRight":           true,
-	"WordLeft":            true,
-	"SelectWordRight":     true,
-	"SelectWordLeft":      true,
-	"DeleteWordRight":     true,
-	"DeleteWordLeft":      true,
-	"SelectLine":          true,
-	"SelectToStartOfLine": true,
-	"SelectToStartOfText": true,
-	"SelectToEndOfLine":   true,
-	"ParagraphPrevious":   true,
-	"ParagraphNext":       true,
-	"InsertNewline":       true,
-	"Backspace":           true,
-	"Delete":              true,
-	"InsertTab":           true,
-	"FindNext":            true,
-	"FindPrevious":        true,
-	"Cut":                 true,
-	"CutLine":             true,
-	"DuplicateLine":       true,
-	"DeleteLine":          true,
-	"MoveLinesUp":         true,
-	"MoveLinesDown":       true,
-	"IndentSelection":     true,
-	"OutdentSelection":    true,
-	"OutdentLine":         true,
-	"Paste":               true,
-	"PastePrimary":        true,
-	"SelectPageUp":        true,
-	"SelectPageDown":      true,
-	"StartOfLine":         true,
-	"StartOfText":         true,
-	"EndOfLine":           true,
-	"JumpToMatchingBrace": true,
+	"CursorUp":                  true,
+	"CursorDown":                true,
+	"CursorPageUp":              true,
+	"CursorPageDown":            true,
+	"CursorLeft":                true,
+	"CursorRight":               true,
+	"CursorStart":               true,
+	"CursorEnd":                 true,
+	"SelectToStart":             true,
+	"SelectToEnd":               true,
+	"SelectUp":                  true,
+	"SelectDown":                true,
+	"SelectLeft":                true,
+	"SelectRight":               true,
+	"WordRight":                 true,
+	"WordLeft":                  true,
+	"SelectWordRight":           true,
+	"SelectWordLeft":            true,
+	"DeleteWordRight":           true,
+	"DeleteWordLeft":            true,
+	"SelectLine":                true,
+	"SelectToStartOfLine":       true,
+	"SelectToStartOfText":       true,
+	"SelectToEndOfLine":         true,
+	"ParagraphPrevious":         true,
+	"ParagraphNext":             true,
+	"InsertNewline":             true,
+	"Backspace":                 true,
+	"Delete":                    true,
+	"InsertTab":                 true,
+	"FindNext":                  true,
+	"FindPrevious":              true,
+	"Cut":                       true,
+	"CutLine":                   true,
+	"DuplicateLine":             true,
+	"DeleteLine":                true,
+	"MoveLinesUp":               true,
+	"MoveLinesDown":             true,
+	"IndentSelection":           true,
+	"OutdentSelection":          true,
+	"OutdentLine":               true,
+	"Paste":                     true,
+	"PastePrimary":              true,
+	"SelectPageUp":              true,
+	"SelectPageDown":            true,
+	"StartOfLine":               true,
+	"StartOfText":               true,
+	"EndOfLine":                 true,
+	"JumpToMatchingBrace":       true,
+	"StartOfTextToggle":         true,
+	"SelectToStartOfTextToggle": true,
+	"InsertDateTime":            true,
+	"InsertUUID":                true,
+	"EvalMath":                  true,
+	"NextSiblingLine":           true,
+	"PrevSiblingLine":           true,
+	"IndentToPreviousLine":      true,
+	"InsertFilePath":            true,
+	"InsertRelativeFilePath":    true,
+	"SelectBlock":               true,
 }
@@ -0,0 +1,75 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/config"
+	ulua "github.com/zyedidia/micro/internal/lua"
+	"github.com/zyedidia/micro/internal/screen"
+	"github.com/zyedidia/tcell"
+)
+
+// newTestPane returns the pane of a buffer holding text, in a tab on a
+// simulation screen
+func newTestPane(text string) *BufPane {
+	ulua.L = lua.NewState()
+	config.InitGlobalSettings()
+	s := tcell.NewSimulationScreen("")
+	s.Init()
+	s.SetSize(80, 24)
+	screen.Screen = s
+	InitCommands()
+	InitGlobals()
+	b := buffer.NewBufferFromString(text, "", buffer.BTDefault)
+	InitTabs([]*buffer.Buffer{b})
+	return MainTab().CurPane()
+}
+
+func TestChainMirroredSnippet(t *testing.T) {
+	text, stops := config.ExpandSnippet("${1:a} $1 ${2:b} $2$0")
+	h := newTestPane(text)
+	h.snippet = &activeSnippet{buf: h.Buf, start: h.Buf.Start(), stops: stops}
+	h.selectStop()
+	assert.Equal(t, 2, h.Buf.NumCursors())
+
+	tab := bufKeyAction("NextSnippetStop|Autocomplete|IndentSelection|InsertTab")
+	tab(h)
+	assert.Equal(t, "a a b b", string(h.Buf.Bytes()))
+	assert.Equal(t, 2, h.Buf.NumCursors())
+	assert.Equal(t, "b", string(h.Buf.GetCursor(0).GetSelection()))
+	assert.Equal(t, "b", string(h.Buf.GetCursor(1).GetSelection()))
+
+	tab(h)
+	assert.Equal(t, "a a b b", string(h.Buf.Bytes()))
+	assert.Equal(t, 1, h.Buf.NumCursors())
+	assert.Equal(t, buffer.Loc{X: 7, Y: 0}, h.Cursor.Loc)
+}
+
+func TestAutoSaveContinuesAfterError(t *testing.T) {
+	newTestPane("")
+	dir, err := ioutil.TempDir("", "micro")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the directory of the first file does not exist, so it cannot be saved
+	failing := buffer.NewBufferFromString("", filepath.Join(dir, "missing", "a.txt"), buffer.BTDefault)
+	defer failing.Close()
+	failing.Insert(failing.Start(), "a")
+	path := filepath.Join(dir, "b.txt")
+	b := buffer.NewBufferFromString("", path, buffer.BTDefault)
+	defer b.Close()
+	b.Insert(b.Start(), "b")
+
+	AutoSave()
+	assert.True(t, failing.Modified())
+	assert.False(t, b.Modified())
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+}
@@ -0,0 +1,220 @@
+package action
+
+import (
+	"strings"
+
+	shellquote "github.com/kballard/go-shellquote"
+	"github.com/zyedidia/micro/internal/config"
+)
+
+// closePane closes a pane and removes it from its tab, or removes the tab if
+// it is its only pane. The active tab and the active pane of every tab stay
+// the same unless they are removed
+func closePane(p Pane) {
+	p.Close()
+	t := p.Tab()
+	if len(t.Panes) > 1 {
+		active := t.Panes[t.active]
+		if n := t.GetNode(p.ID()); n != nil {
+			n.Unsplit()
+		}
+		t.RemovePane(t.GetPane(p.ID()))
+		t.Resize()
+		t.SetActive(t.GetPane(active.ID()))
+		return
+	}
+
+	active := Tabs.List[Tabs.Active()]
+	Tabs.RemoveTab(t.Panes[0].ID())
+	for i, tab := range Tabs.List {
+		if tab == active {
+			Tabs.SetActive(i)
+		}
+	}
+}
+
+// shownElsewhere returns whether the buffer of a pane is also shown by
+// another pane, so that closing the pane does not lose its changes
+func shownElsewhere(h *BufPane) bool {
+	for _, t := range Tabs.List {
+		for _, p := range t.Panes {
+			if bp, ok := p.(*BufPane); ok && bp != h && bp.Buf.SharedBuffer == h.Buf.SharedBuffer {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// saveThen saves the buffer of the pane, asking for a file name if it has
+// none, and then calls cont with whether the buffer was saved. cont is not
+// called if saving opens another prompt, like the one offering sudo
+func (h *BufPane) saveThen(cont func(saved bool)) {
+	if h.Buf.Path != "" {
+		if h.saveBufToFile(h.Buf.Path, "Save") {
+			cont(!h.Buf.Modified())
+		}
+		return
+	}
+	InfoBar.Prompt("Filename: ", "", "Save", nil, func(resp string, canceled bool) {
+		if canceled {
+			cont(false)
+			return
+		}
+		args, err := shellquote.Split(resp)
+		if err != nil || len(args) == 0 {
+			InfoBar.Error("No filename given")
+			cont(false)
+			return
+		}
+		if h.saveBufToFile(strings.Join(args, " "), "SaveAs") {
+			cont(!h.Buf.Modified())
+		}
+	})
+}
+
+// closePanes closes the panes one after the other. Like Quit, it asks
+// whether to save the changes of a modified buffer before closing it, and
+// escape stops closing the remaining panes, as does a buffer which could not
+// be saved. done is called with the number of panes which were closed
+func closePanes(panes []Pane, done func(closed int)) {
+	var next func(i, closed int)
+	next = func(i, closed int) {
+		if i == len(panes) {
+			done(closed)
+			return
+		}
+		p := panes[i]
+		h, ok := p.(*BufPane)
+		if !ok || !h.Buf.Modified() || shownElsewhere(h) {
+			closePane(p)
+			next(i+1, closed+1)
+			return
+		}
+		saveAndClose := func() {
+			h.saveThen(func(saved bool) {
+				if !saved {
+					done(closed)
+					return
+				}
+				closePane(p)
+				next(i+1, closed+1)
+			})
+		}
+		if config.GlobalSettings["autosave"].(float64) > 0 {
+			// autosave on means we automatically save when closing
+			saveAndClose()
+			return
+		}
+		InfoBar.YNPrompt("Save changes to "+h.Buf.GetName()+" before closing? (y,n,esc)", func(yes, canceled bool) {
+			if canceled {
+				done(closed)
+				return
+			}
+			if yes {
+				saveAndClose()
+				return
+			}
+			closePane(p)
+			next(i+1, closed+1)
+		})
+	}
+	next(0, 0)
+}
+
+// closeTabs closes all the panes of the given tabs, except the pinned tabs,
+// and reports how many tabs were closed
+func closeTabs(tabs []*Tab) {
+	unpinned := tabs[:0]
+	for _, t := range tabs {
+		if !t.pinned {
+			unpinned = append(unpinned, t)
+		}
+	}
+	tabs = unpinned
+	if len(tabs) == 0 {
+		InfoBar.Message("No tabs to close")
+		return
+	}
+	var panes []Pane
+	for _, t := range tabs {
+		panes = append(panes, t.Panes...)
+	}
+	before := len(Tabs.List)
+	closePanes(panes, func(closed int) {
+		InfoBar.Message("Closed ", before-len(Tabs.List), " tabs")
+	})
+}
+
+// OnlyTabCmd closes every tab except the current one
+func (h *BufPane) OnlyTabCmd(args []string) {
+	var tabs []*Tab
+	for _, t := range Tabs.List {
+		if t != h.tab {
+			tabs = append(tabs, t)
+		}
+	}
+	closeTabs(tabs)
+}
+
+// tabIndex returns the index of a tab in the tab list
+func tabIndex(t *Tab) int {
+	for i, tab := range Tabs.List {
+		if tab == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// CloseRightCmd closes the tabs after the current one
+func (h *BufPane) CloseRightCmd(args []string) {
+	i := tabIndex(h.tab)
+	if i < 0 {
+		return
+	}
+	closeTabs(append([]*Tab{}, Tabs.List[i+1:]...))
+}
+
+// CloseLeftCmd closes the tabs before the current one
+func (h *BufPane) CloseLeftCmd(args []string) {
+	i := tabIndex(h.tab)
+	if i < 0 {
+		return
+	}
+	closeTabs(append([]*Tab{}, Tabs.List[:i]...))
+}
+
+// CloseOthersCmd closes every pane of every tab except the current one. The
+// panes of the pinned tabs are kept
+func (h *BufPane) CloseOthersCmd(args []string) {
+	var panes []Pane
+	for _, t := range Tabs.List {
+		if t.pinned && t != h.tab {
+			continue
+		}
+		for _, p := range t.Panes {
+			if p != Pane(h) {
+				panes = append(panes, p)
+			}
+		}
+	}
+	if len(panes) == 0 {
+		InfoBar.Message("No other buffers")
+		return
+	}
+	closePanes(panes, func(closed int) {
+		InfoBar.Message("Closed ", closed, " buffers")
+	})
+}
+
+// PinTabCmd pins the current tab, or unpins it if it is pinned
+func (h *BufPane) PinTabCmd(args []string) {
+	h.tab.pinned = !h.tab.pinned
+	Tabs.sortPinned()
+	if h.tab.pinned {
+		InfoBar.Message("Pinned tab")
+	} else {
+		InfoBar.Message("Unpinned tab")
+	}
+}
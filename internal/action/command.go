@@ -31,37 +31,61 @@ var commands map[string]Command
 
 func InitCommands() {
 	commands = map[string]Command{
-		"set":        {(*BufPane).SetCmd, OptionValueComplete},
-		"reset":      {(*BufPane).ResetCmd, OptionValueComplete},
-		"setlocal":   {(*BufPane).SetLocalCmd, OptionValueComplete},
-		"show":       {(*BufPane).ShowCmd, OptionComplete},
-		"showkey":    {(*BufPane).ShowKeyCmd, nil},
-		"run":        {(*BufPane).RunCmd, nil},
-		"bind":       {(*BufPane).BindCmd, nil},
-		"unbind":     {(*BufPane).UnbindCmd, nil},
-		"quit":       {(*BufPane).QuitCmd, nil},
-		"goto":       {(*BufPane).GotoCmd, nil},
-		"save":       {(*BufPane).SaveCmd, nil},
-		"replace":    {(*BufPane).ReplaceCmd, nil},
-		"replaceall": {(*BufPane).ReplaceAllCmd, nil},
-		"vsplit":     {(*BufPane).VSplitCmd, buffer.FileComplete},
-		"hsplit":     {(*BufPane).HSplitCmd, buffer.FileComplete},
-		"tab":        {(*BufPane).NewTabCmd, buffer.FileComplete},
-		"help":       {(*BufPane).HelpCmd, HelpComplete},
-		"eval":       {(*BufPane).EvalCmd, nil},
-		"log":        {(*BufPane).ToggleLogCmd, nil},
-		"plugin":     {(*BufPane).PluginCmd, PluginComplete},
-		"reload":     {(*BufPane).ReloadCmd, nil},
-		"reopen":     {(*BufPane).ReopenCmd, nil},
-		"cd":         {(*BufPane).CdCmd, buffer.FileComplete},
-		"pwd":        {(*BufPane).PwdCmd, nil},
-		"open":       {(*BufPane).OpenCmd, buffer.FileComplete},
-		"tabswitch":  {(*BufPane).TabSwitchCmd, nil},
-		"term":       {(*BufPane).TermCmd, nil},
-		"memusage":   {(*BufPane).MemUsageCmd, nil},
-		"retab":      {(*BufPane).RetabCmd, nil},
-		"raw":        {(*BufPane).RawCmd, nil},
-		"textfilter": {(*BufPane).TextFilterCmd, nil},
+		"set":           {(*BufPane).SetCmd, OptionValueComplete},
+		"reset":         {(*BufPane).ResetCmd, OptionValueComplete},
+		"setlocal":      {(*BufPane).SetLocalCmd, OptionValueComplete},
+		"toggle":        {(*BufPane).ToggleCmd, OptionComplete},
+		"show":          {(*BufPane).ShowCmd, OptionComplete},
+		"showkey":       {(*BufPane).ShowKeyCmd, nil},
+		"run":           {(*BufPane).RunCmd, nil},
+		"bind":          {(*BufPane).BindCmd, BindComplete},
+		"unbind":        {(*BufPane).UnbindCmd, BindingComplete},
+		"quit":          {(*BufPane).QuitCmd, nil},
+		"goto":          {(*BufPane).GotoCmd, nil},
+		"save":          {(*BufPane).SaveCmd, buffer.FileComplete},
+		"replace":       {(*BufPane).ReplaceCmd, nil},
+		"replaceall":    {(*BufPane).ReplaceAllCmd, nil},
+		"vsplit":        {(*BufPane).VSplitCmd, buffer.FileComplete},
+		"hsplit":        {(*BufPane).HSplitCmd, buffer.FileComplete},
+		"tab":           {(*BufPane).NewTabCmd, buffer.FileComplete},
+		"scratch":       {(*BufPane).ScratchCmd, nil},
+		"help":          {(*BufPane).HelpCmd, HelpComplete},
+		"eval":          {(*BufPane).EvalCmd, nil},
+		"log":           {(*BufPane).ToggleLogCmd, nil},
+		"plugin":        {(*BufPane).PluginCmd, PluginComplete},
+		"reload":        {(*BufPane).ReloadCmd, nil},
+		"reopen":        {(*BufPane).ReopenCmd, nil},
+		"cd":            {(*BufPane).CdCmd, buffer.FileComplete},
+		"lcd":           {(*BufPane).LcdCmd, buffer.FileComplete},
+		"pwd":           {(*BufPane).PwdCmd, nil},
+		"open":          {(*BufPane).OpenCmd, buffer.FileComplete},
+		"tabswitch":     {(*BufPane).TabSwitchCmd, TabComplete},
+		"renametab":     {(*BufPane).RenameTabCmd, nil},
+		"term":          {(*BufPane).TermCmd, nil},
+		"memusage":      {(*BufPane).MemUsageCmd, nil},
+		"retab":         {(*BufPane).RetabCmd, nil},
+		"raw":           {(*BufPane).RawCmd, nil},
+		"textfilter":    {(*BufPane).TextFilterCmd, nil},
+		"filtercursors": {(*BufPane).FilterCursorsCmd, nil},
+		"wordcount":     {(*BufPane).WordCountCmd, nil},
+		"sort":          {(*BufPane).SortCmd, FlagComplete("-n")},
+		"uniq":          {(*BufPane).UniqCmd, FlagComplete("-a")},
+		"numberlines":   {(*BufPane).NumberLinesCmd, FlagComplete("-start", "-width", "-sep")},
+		"resetundo":     {(*BufPane).ResetUndoCmd, FlagComplete("-clean")},
+		"undolist":      {(*BufPane).UndoListCmd, nil},
+		"insertdate":    {(*BufPane).InsertDateCmd, nil},
+		"sum":           {(*BufPane).SumCmd, FlagComplete("-insert")},
+		"onlytab":       {(*BufPane).OnlyTabCmd, nil},
+		"closeothers":   {(*BufPane).CloseOthersCmd, nil},
+		"closeright":    {(*BufPane).CloseRightCmd, nil},
+		"closeleft":     {(*BufPane).CloseLeftCmd, nil},
+		"pintab":        {(*BufPane).PinTabCmd, nil},
+		"tree":          {(*BufPane).TreeCmd, buffer.FileComplete},
+		"keeplines":     {(*BufPane).KeepLinesCmd, nil},
+		"removelines":   {(*BufPane).RemoveLinesCmd, nil},
+		"runbuffer":     {(*BufPane).RunBufferCmd, nil},
+		"pager":         {(*BufPane).PagerCmd, nil},
+		"readonly":      {(*BufPane).ReadOnlyCmd, FlagComplete("on", "off")},
 	}
 }
 
@@ -136,6 +160,10 @@ func (h *BufPane) TextFilterCmd(args []string) {
 		InfoBar.Error("usage: textfilter arguments")
 		return
 	}
+	args, ok := h.expandFileNameArgs(args)
+	if !ok {
+		return
+	}
 	sel := h.Cursor.GetSelection()
 	if len(sel) == 0 {
 		h.Cursor.SelectWord()
@@ -143,6 +171,7 @@ func (h *BufPane) TextFilterCmd(args []string) {
 	}
 	var bout, berr bytes.Buffer
 	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = h.wd
 	cmd.Stdin = strings.NewReader(string(sel))
 	cmd.Stderr = &berr
 	cmd.Stdout = &bout
@@ -155,6 +184,119 @@ func (h *BufPane) TextFilterCmd(args []string) {
 	h.Buf.Insert(h.Cursor.Loc, bout.String())
 }
 
+// FilterCursorsCmd removes every cursor whose selection (or line, if the
+// cursor has no selection) does not match the given regex
+func (h *BufPane) FilterCursorsCmd(args []string) {
+	if len(args) == 0 {
+		InfoBar.Error("usage: filtercursors regex")
+		return
+	}
+
+	search := args[0]
+	if h.Buf.Settings["ignorecase"].(bool) {
+		search = "(?i)" + search
+	}
+	r, err := regexp.Compile(search)
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+
+	cursors := h.Buf.GetCursors()
+	active := h.Buf.GetActiveCursor()
+	removed := 0
+	for i := len(cursors) - 1; i >= 0; i-- {
+		c := cursors[i]
+		var text []byte
+		if c.HasSelection() {
+			text = c.GetSelection()
+		} else {
+			text = h.Buf.LineBytes(c.Y)
+		}
+		if r.Match(text) {
+			continue
+		}
+		if h.Buf.NumCursors() == 1 {
+			// always keep at least one cursor
+			break
+		}
+		if c == active {
+			active = nil
+		}
+		h.Buf.RemoveCursor(i)
+		removed++
+	}
+
+	// keep the previously active cursor active if it survived,
+	// otherwise fall back to the last remaining cursor
+	cur := h.Buf.NumCursors() - 1
+	for i, c := range h.Buf.GetCursors() {
+		if c == active {
+			cur = i
+			break
+		}
+	}
+	h.Buf.SetCurCursor(cur)
+	h.Buf.UpdateCursors()
+	h.Cursor = h.Buf.GetActiveCursor()
+	if h.Buf.NumCursors() == 1 {
+		h.multiWord = false
+	}
+	h.Relocate()
+
+	InfoBar.Message(fmt.Sprintf("Removed %d cursors, %d remaining", removed, h.Buf.NumCursors()))
+}
+
+// WordCountCmd displays the number of lines, words, characters and bytes
+// in the current selection, or in the whole buffer if nothing is selected
+func (h *BufPane) WordCountCmd(args []string) {
+	var text []byte
+	var lines int
+	what := "Buffer"
+	if h.Cursor.HasSelection() {
+		text = h.Cursor.GetSelection()
+		lines = bytes.Count(text, []byte{'\n'}) + 1
+		what = "Selection"
+	} else {
+		text = h.Buf.Bytes()
+		lines = h.Buf.LinesNum()
+	}
+
+	InfoBar.Message(fmt.Sprintf("%s: %d lines, %d words, %d characters, %d bytes",
+		what, lines, util.CountWords(text), utf8.RuneCount(text), len(text)))
+}
+
+// ResetUndoCmd empties the undo and redo history of the buffer. With -clean
+// the current text also becomes the unmodified state of the buffer
+func (h *BufPane) ResetUndoCmd(args []string) {
+	clean := false
+	for _, a := range args {
+		switch a {
+		case "-clean":
+			clean = true
+		default:
+			InfoBar.Error("usage: resetundo [-clean]")
+			return
+		}
+	}
+
+	h.Buf.ClearHistory()
+	if clean {
+		h.Buf.MarkClean()
+		InfoBar.Message("Cleared the undo history and marked the buffer as unmodified")
+		return
+	}
+	InfoBar.Message("Cleared the undo history")
+}
+
+// UndoListCmd opens a split showing the undo history of the buffer and the
+// branches of changes which were undone and replaced
+func (h *BufPane) UndoListCmd(args []string) {
+	listBuf := buffer.NewBufferFromString(h.Buf.UndoList(), "", buffer.BTHelp)
+	listBuf.SetName("Undo list " + h.Buf.GetName())
+	h.HSplitBuf(listBuf)
+}
+
 // TabSwitchCmd switches to a given tab either by name or by number
 func (h *BufPane) TabSwitchCmd(args []string) {
 	if len(args) > 0 {
@@ -164,7 +306,7 @@ func (h *BufPane) TabSwitchCmd(args []string) {
 
 			found := false
 			for i, t := range Tabs.List {
-				if t.Panes[t.active].Name() == args[0] {
+				if t.Name() == args[0] {
 					Tabs.SetActive(i)
 					found = true
 				}
@@ -183,7 +325,14 @@ func (h *BufPane) TabSwitchCmd(args []string) {
 	}
 }
 
-// CdCmd changes the current working directory
+// RenameTabCmd sets the name shown in the tab bar for the current tab, or
+// restores the name of its active pane if no name is given
+func (h *BufPane) RenameTabCmd(args []string) {
+	h.tab.SetName(strings.Join(args, " "))
+	Tabs.UpdateNames()
+}
+
+// CdCmd changes the current working directory and shows the new one
 func (h *BufPane) CdCmd(args []string) {
 	if len(args) > 0 {
 		path, err := util.ReplaceHome(args[0])
@@ -191,6 +340,13 @@ func (h *BufPane) CdCmd(args []string) {
 			InfoBar.Error(err)
 			return
 		}
+		if info, err := os.Stat(path); err != nil {
+			InfoBar.Error(err)
+			return
+		} else if !info.IsDir() {
+			InfoBar.Error(path, " is not a directory")
+			return
+		}
 		err = os.Chdir(path)
 		if err != nil {
 			InfoBar.Error(err)
@@ -206,6 +362,12 @@ func (h *BufPane) CdCmd(args []string) {
 			}
 		}
 	}
+	wd, err := os.Getwd()
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	InfoBar.Message(wd)
 }
 
 // MemUsageCmd prints micro's memory usage
@@ -219,8 +381,53 @@ func (h *BufPane) MemUsageCmd(args []string) {
 	InfoBar.Message(util.GetMemStats())
 }
 
-// PwdCmd prints the current working directory
+// LcdCmd sets the working directory of the current pane, used instead of
+// the working directory of micro to run shell commands and open files with
+// a relative path. Without argument the working directory of micro is used
+// again
+func (h *BufPane) LcdCmd(args []string) {
+	if len(args) == 0 {
+		h.wd = ""
+		InfoBar.Message("Local working directory cleared")
+		return
+	}
+	path, err := util.ReplaceHome(args[0])
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	path, err = filepath.Abs(h.resolvePath(path))
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	if info, err := os.Stat(path); err != nil {
+		InfoBar.Error(err)
+		return
+	} else if !info.IsDir() {
+		InfoBar.Error(path, " is not a directory")
+		return
+	}
+	h.wd = path
+	InfoBar.Message("Local working directory: ", path)
+}
+
+// resolvePath makes a relative path relative to the working directory of
+// the pane
+func (h *BufPane) resolvePath(path string) string {
+	if h.wd == "" || filepath.IsAbs(path) || strings.HasPrefix(path, "~") {
+		return path
+	}
+	return filepath.Join(h.wd, path)
+}
+
+// PwdCmd prints the working directory of the pane if it is set with lcd,
+// or the current working directory
 func (h *BufPane) PwdCmd(args []string) {
+	if h.wd != "" {
+		InfoBar.Message(h.wd, " (local)")
+		return
+	}
 	wd, err := os.Getwd()
 	if err != nil {
 		InfoBar.Message(err.Error())
@@ -245,7 +452,7 @@ func (h *BufPane) OpenCmd(args []string) {
 		filename = strings.Join(args, " ")
 
 		open := func() {
-			b, err := buffer.NewBufferFromFile(filename, buffer.BTDefault)
+			b, err := buffer.NewBufferFromFile(h.resolvePath(filename), buffer.BTDefault)
 			if err != nil {
 				InfoBar.Error(err)
 				return
@@ -300,6 +507,7 @@ func ReloadConfig() {
 
 	for _, b := range buffer.OpenBuffers {
 		b.UpdateRules()
+		b.UpdateFileSettings()
 	}
 }
 
@@ -361,7 +569,7 @@ func (h *BufPane) VSplitCmd(args []string) {
 		return
 	}
 
-	buf, err := buffer.NewBufferFromFile(args[0], buffer.BTDefault)
+	buf, err := buffer.NewBufferFromFile(h.resolvePath(args[0]), buffer.BTDefault)
 	if err != nil {
 		InfoBar.Error(err)
 		return
@@ -379,7 +587,7 @@ func (h *BufPane) HSplitCmd(args []string) {
 		return
 	}
 
-	buf, err := buffer.NewBufferFromFile(args[0], buffer.BTDefault)
+	buf, err := buffer.NewBufferFromFile(h.resolvePath(args[0]), buffer.BTDefault)
 	if err != nil {
 		InfoBar.Error(err)
 		return
@@ -399,7 +607,7 @@ func (h *BufPane) NewTabCmd(args []string) {
 	iOffset := config.GetInfoBarOffset()
 	if len(args) > 0 {
 		for _, a := range args {
-			b, err := buffer.NewBufferFromFile(a, buffer.BTDefault)
+			b, err := buffer.NewBufferFromFile(h.resolvePath(a), buffer.BTDefault)
 			if err != nil {
 				InfoBar.Error(err)
 				return
@@ -416,7 +624,45 @@ func (h *BufPane) NewTabCmd(args []string) {
 	}
 }
 
+// ReadOnlyCmd makes the buffer read-only, or editable again with off
+func (h *BufPane) ReadOnlyCmd(args []string) {
+	on := true
+	if len(args) > 0 {
+		switch args[0] {
+		case "on":
+		case "off":
+			on = false
+		default:
+			InfoBar.Error("usage: readonly [on|off]")
+			return
+		}
+	}
+	if h.Buf.Type.Kind != buffer.BTDefault.Kind {
+		InfoBar.Error("Only the buffers of files can be made read-only")
+		return
+	}
+	h.Buf.SetOptionNative("readonly", on)
+	if on {
+		InfoBar.Message("Enabled read-only")
+	} else {
+		InfoBar.Message("Disabled read-only")
+	}
+}
+
+// ScratchCmd opens a scratch buffer in a new tab. A scratch buffer has no
+// file and is discarded without asking to save it
+func (h *BufPane) ScratchCmd(args []string) {
+	width, height := screen.Screen.Size()
+	iOffset := config.GetInfoBarOffset()
+	b := buffer.NewBufferFromString("", "", buffer.BTScratch)
+	b.SetName("Scratch")
+	tp := NewTabFromBuffer(0, 0, width, height-iOffset, b)
+	Tabs.AddTab(tp)
+	Tabs.SetActive(len(Tabs.List) - 1)
+}
+
 func SetGlobalOptionNative(option string, nativeValue interface{}) error {
+	nativeValue = config.ConvertOldValue(option, nativeValue)
 	local := false
 	for _, s := range config.LocalSettings {
 		if s == option {
@@ -472,7 +718,7 @@ func SetGlobalOptionNative(option string, nativeValue interface{}) error {
 	}
 
 	for _, b := range buffer.OpenBuffers {
-		b.SetOptionNative(option, nativeValue)
+		b.DoSetOptionNative(option, nativeValue)
 	}
 
 	return config.WriteSettings(config.ConfigDir + "/settings.json")
@@ -551,6 +797,55 @@ func (h *BufPane) SetLocalCmd(args []string) {
 	}
 }
 
+// ToggleCmd flips a boolean option, or sets an option which can only take a
+// few values to the next one. The option is set locally unless it is global
+// only
+func (h *BufPane) ToggleCmd(args []string) {
+	if len(args) < 1 {
+		InfoBar.Error("Please provide an option to toggle")
+		return
+	}
+
+	option := args[0]
+	cur, local := h.Buf.Settings[option]
+	if !local {
+		cur = config.GlobalSettings[option]
+	}
+
+	var value string
+	switch v := cur.(type) {
+	case nil:
+		InfoBar.Error(config.ErrInvalidOption)
+		return
+	case bool:
+		value = strconv.FormatBool(!v)
+	default:
+		choices := config.OptionChoices(option)
+		if len(choices) == 0 {
+			InfoBar.Error(option, " cannot be toggled")
+			return
+		}
+		value = choices[0]
+		for i, c := range choices {
+			if c == v {
+				value = choices[(i+1)%len(choices)]
+			}
+		}
+	}
+
+	var err error
+	if local {
+		err = h.Buf.SetOption(option, value)
+	} else {
+		err = SetGlobalOption(option, value)
+	}
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	InfoBar.Message(option, " is now ", value)
+}
+
 // ShowCmd shows the value of the given option
 func (h *BufPane) ShowCmd(args []string) {
 	if len(args) < 1 {
@@ -615,7 +910,11 @@ func (h *BufPane) UnbindCmd(args []string) {
 
 // RunCmd runs a shell command in the background
 func (h *BufPane) RunCmd(args []string) {
-	runf, err := shell.RunBackgroundShell(shellquote.Join(args...))
+	args, ok := h.expandFileNameArgs(args)
+	if !ok {
+		return
+	}
+	runf, err := shell.RunBackgroundShellIn(h.wd, shellquote.Join(args...))
 	if err != nil {
 		InfoBar.Error(err)
 	} else {
@@ -814,6 +1113,11 @@ func (h *BufPane) TermCmd(args []string) {
 			return
 		}
 		args = []string{sh}
+	} else {
+		var ok bool
+		if args, ok = h.expandFileNameArgs(args); !ok {
+			return
+		}
 	}
 
 	term := func(i int, newtab bool) {
@@ -860,6 +1164,30 @@ func (h *BufPane) TermCmd(args []string) {
 	}
 }
 
+// expandFileNames replaces % and # in a command with the path of the file
+// of the buffer and the path of the alternate file, see
+// util.ExpandFileNames
+func (h *BufPane) expandFileNames(input string) (string, bool) {
+	expanded, err := util.ExpandFileNames(input, h.Buf.Path, h.altPath)
+	if err != nil {
+		InfoBar.Error(err)
+		return "", false
+	}
+	return expanded, true
+}
+
+// expandFileNameArgs replaces % and # in the arguments of a command with
+// the paths of the current and the alternate file, showing an error if one
+// of them has no path
+func (h *BufPane) expandFileNameArgs(args []string) ([]string, bool) {
+	expanded, err := util.ExpandFileNameArgs(args, h.Buf.Path, h.altPath)
+	if err != nil {
+		InfoBar.Error(err)
+		return nil, false
+	}
+	return expanded, true
+}
+
 // HandleCommand handles input from the user
 func (h *BufPane) HandleCommand(input string) {
 	args, err := shellquote.Split(input)
@@ -877,6 +1205,9 @@ func (h *BufPane) HandleCommand(input string) {
 	if _, ok := commands[inputCmd]; !ok {
 		InfoBar.Error("Unknown command ", inputCmd)
 	} else {
+		if recording_macro {
+			curmacro = append(curmacro, macroCommand(input))
+		}
 		WriteLog("> " + input + "\n")
 		commands[inputCmd].action(h, args[1:])
 		WriteLog("\n")
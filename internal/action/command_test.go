@@ -0,0 +1,24 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleCommandKeepsFileNameChars(t *testing.T) {
+	h := newTestPane("#include <a.h>\nx = 100%\n")
+	h.Buf.Path = "a.c"
+	h.altPath = "/tmp/b.c"
+
+	h.HandleCommand(`replace "#include" "import" -a`)
+	h.HandleCommand(`replace "100%" "all" -a`)
+	assert.Equal(t, "import <a.h>\nx = all\n", string(h.Buf.Bytes()))
+
+	h.HandleCommand(`setlocal lintcmd "grep -n # %f"`)
+	assert.Equal(t, "grep -n # %f", h.Buf.Settings["lintcmd"])
+
+	args, ok := h.expandFileNameArgs([]string{"diff", "%", "#:t", "##"})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"diff", "a.c", "b.c", "#"}, args)
+}
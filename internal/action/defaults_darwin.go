@@ -20,7 +20,7 @@ func DefaultBindings() map[string]string {
 		"CtrlLeft":       "StartOfText",
 		"CtrlRight":      "EndOfLine",
 		"CtrlShiftLeft":  "SelectToStartOfText",
-		"ShiftHome":      "SelectToStartOfText",
+		"ShiftHome":      "SelectToStartOfLine",
 		"CtrlShiftRight": "SelectToEndOfLine",
 		"ShiftEnd":       "SelectToEndOfLine",
 		"CtrlUp":         "CursorStart",
@@ -34,7 +34,7 @@ func DefaultBindings() map[string]string {
 		"Backspace":      "Backspace",
 		"Alt-CtrlH":      "DeleteWordLeft",
 		"Alt-Backspace":  "DeleteWordLeft",
-		"Tab":            "Autocomplete|IndentSelection|InsertTab",
+		"Tab":            "NextSnippetStop|Autocomplete|IndentSelection|InsertTab",
 		"Backtab":        "CycleAutocompleteBack|OutdentSelection|OutdentLine",
 		"CtrlO":          "OpenFile",
 		"CtrlS":          "Save",
@@ -52,7 +52,7 @@ func DefaultBindings() map[string]string {
 		"CtrlT":          "AddTab",
 		"Alt,":           "PreviousTab",
 		"Alt.":           "NextTab",
-		"Home":           "StartOfText",
+		"Home":           "StartOfLine",
 		"End":            "EndOfLine",
 		"CtrlHome":       "CursorStart",
 		"CtrlEnd":        "CursorEnd",
@@ -62,7 +62,7 @@ func DefaultBindings() map[string]string {
 		"CtrlPageDown":   "NextTab",
 		"CtrlG":          "ToggleHelp",
 		"Alt-g":          "ToggleKeyMenu",
-		"CtrlR":          "ToggleRuler",
+		"CtrlR":          "ToggleRuler|HistorySearch",
 		"CtrlL":          "command-edit:goto ",
 		"Delete":         "Delete",
 		"CtrlB":          "ShellMode",
@@ -90,11 +90,15 @@ func DefaultBindings() map[string]string {
 		"Esc": "Escape",
 
 		// Mouse bindings
-		"MouseWheelUp":   "ScrollUp",
-		"MouseWheelDown": "ScrollDown",
-		"MouseLeft":      "MousePress",
-		"MouseMiddle":    "PastePrimary",
-		"Ctrl-MouseLeft": "MouseMultiCursor",
+		"MouseWheelUp":         "ScrollUp",
+		"MouseWheelDown":       "ScrollDown",
+		"MouseWheelLeft":       "ScrollLeft",
+		"MouseWheelRight":      "ScrollRight",
+		"Shift-MouseWheelUp":   "ScrollLeft",
+		"Shift-MouseWheelDown": "ScrollRight",
+		"MouseLeft":            "MousePress",
+		"MouseMiddle":          "MousePastePrimary",
+		"Ctrl-MouseLeft":       "MouseMultiCursor",
 
 		"Alt-n":        "SpawnMultiCursor",
 		"AltShiftUp":   "SpawnMultiCursorUp",
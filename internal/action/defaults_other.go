@@ -22,7 +22,7 @@ func DefaultBindings() map[string]string {
 		"AltLeft":        "StartOfText",
 		"AltRight":       "EndOfLine",
 		"AltShiftLeft":   "SelectToStartOfText",
-		"ShiftHome":      "SelectToStartOfText",
+		"ShiftHome":      "SelectToStartOfLine",
 		"AltShiftRight":  "SelectToEndOfLine",
 		"ShiftEnd":       "SelectToEndOfLine",
 		"CtrlUp":         "CursorStart",
@@ -36,7 +36,7 @@ func DefaultBindings() map[string]string {
 		"Backspace":      "Backspace",
 		"Alt-CtrlH":      "DeleteWordLeft",
 		"Alt-Backspace":  "DeleteWordLeft",
-		"Tab":            "Autocomplete|IndentSelection|InsertTab",
+		"Tab":            "NextSnippetStop|Autocomplete|IndentSelection|InsertTab",
 		"Backtab":        "CycleAutocompleteBack|OutdentSelection|OutdentLine",
 		"CtrlO":          "OpenFile",
 		"CtrlS":          "Save",
@@ -54,7 +54,7 @@ func DefaultBindings() map[string]string {
 		"CtrlT":          "AddTab",
 		"Alt,":           "PreviousTab",
 		"Alt.":           "NextTab",
-		"Home":           "StartOfText",
+		"Home":           "StartOfLine",
 		"End":            "EndOfLine",
 		"CtrlHome":       "CursorStart",
 		"CtrlEnd":        "CursorEnd",
@@ -64,7 +64,7 @@ func DefaultBindings() map[string]string {
 		"CtrlPageDown":   "NextTab",
 		"CtrlG":          "ToggleHelp",
 		"Alt-g":          "ToggleKeyMenu",
-		"CtrlR":          "ToggleRuler",
+		"CtrlR":          "ToggleRuler|HistorySearch",
 		"CtrlL":          "command-edit:goto ",
 		"Delete":         "Delete",
 		"CtrlB":          "ShellMode",
@@ -92,11 +92,15 @@ func DefaultBindings() map[string]string {
 		"Esc": "Escape",
 
 		// Mouse bindings
-		"MouseWheelUp":   "ScrollUp",
-		"MouseWheelDown": "ScrollDown",
-		"MouseLeft":      "MousePress",
-		"MouseMiddle":    "PastePrimary",
-		"Ctrl-MouseLeft": "MouseMultiCursor",
+		"MouseWheelUp":         "ScrollUp",
+		"MouseWheelDown":       "ScrollDown",
+		"MouseWheelLeft":       "ScrollLeft",
+		"MouseWheelRight":      "ScrollRight",
+		"Shift-MouseWheelUp":   "ScrollLeft",
+		"Shift-MouseWheelDown": "ScrollRight",
+		"MouseLeft":            "MousePress",
+		"MouseMiddle":          "MousePastePrimary",
+		"Ctrl-MouseLeft":       "MouseMultiCursor",
 
 		"Alt-n":        "SpawnMultiCursor",
 		"Alt-m":        "SpawnMultiCursorSelect",
@@ -0,0 +1,217 @@
+package action
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/util"
+)
+
+// DiffWithDisk opens a read-only split with the unified diff between the
+// file on disk and the buffer, to review the unsaved changes
+func (h *BufPane) DiffWithDisk() bool {
+	if h.Buf.Path == "" || h.Buf.Type != buffer.BTDefault {
+		InfoBar.Error("Cannot diff a buffer without a file")
+		return false
+	}
+	base, err := h.Buf.DiskText()
+	if err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+
+	diff := buffer.UnifiedDiff(h.Buf.Path, base, string(h.Buf.Bytes()))
+	if diff == "" {
+		InfoBar.Message("No unsaved changes")
+		return false
+	}
+
+	diffBuf := buffer.NewBufferFromString(diff, "", buffer.BTHelp)
+	diffBuf.SetName("Diff " + h.Buf.GetName())
+	diffBuf.SetOptionNative("filetype", "patch")
+	h.VSplitBuf(diffBuf)
+	return true
+}
+
+// diffHunks returns the hunks of changes of the buffer: the changes shown
+// by the diff gutter if it is enabled, otherwise the changes from the file
+// on disk
+func (h *BufPane) diffHunks() []buffer.DiffHunk {
+	if h.Buf.Settings["diffgutter"].(bool) {
+		return h.Buf.DiffHunks()
+	}
+	if h.Buf.Path == "" {
+		return nil
+	}
+	base, err := h.Buf.DiskText()
+	if err != nil {
+		return nil
+	}
+	return buffer.DiffLines(base, string(h.Buf.Bytes()))
+}
+
+// diffHunkLines returns the first line of every hunk of changes of the
+// buffer. In a diff these are the hunk headers
+func (h *BufPane) diffHunkLines() []int {
+	var lines []int
+	if h.Buf.FileType() == "patch" {
+		for i := 0; i < h.Buf.LinesNum(); i++ {
+			if strings.HasPrefix(string(h.Buf.LineBytes(i)), "@@") {
+				lines = append(lines, i)
+			}
+		}
+		return lines
+	}
+
+	for _, hunk := range h.diffHunks() {
+		lines = append(lines, hunk.Start)
+	}
+	return lines
+}
+
+// gotoDiffLine moves the cursor to the start of line y, which starts a hunk
+func (h *BufPane) gotoDiffLine(y int) {
+	if y >= h.Buf.LinesNum() {
+		y = h.Buf.LinesNum() - 1
+	}
+	h.Cursor.ResetSelection()
+	h.Cursor.GotoLoc(buffer.Loc{X: 0, Y: y})
+	h.Relocate()
+}
+
+// DiffNext moves the cursor to the next hunk of changes
+func (h *BufPane) DiffNext() bool {
+	for _, y := range h.diffHunkLines() {
+		if y > h.Cursor.Y {
+			h.gotoDiffLine(y)
+			return true
+		}
+	}
+	InfoBar.Message("No next change")
+	return false
+}
+
+// DiffPrevious moves the cursor to the previous hunk of changes
+func (h *BufPane) DiffPrevious() bool {
+	lines := h.diffHunkLines()
+	for i := len(lines) - 1; i >= 0; i-- {
+		if lines[i] < h.Cursor.Y {
+			h.gotoDiffLine(lines[i])
+			return true
+		}
+	}
+	InfoBar.Message("No previous change")
+	return false
+}
+
+// hunkAt returns the hunk containing line y, or the hunk of lines deleted
+// at line y
+func (h *BufPane) hunkAt(hunks []buffer.DiffHunk, y int) (buffer.DiffHunk, bool) {
+	for _, hunk := range hunks {
+		if y >= hunk.Start && y < hunk.End {
+			return hunk, true
+		}
+		if hunk.Start == hunk.End && y == util.Min(hunk.Start, h.Buf.LinesNum()-1) {
+			return hunk, true
+		}
+	}
+	return buffer.DiffHunk{}, false
+}
+
+// RevertHunk restores the lines of the version of the file in the last git
+// commit in place of the hunk of changes under the cursor
+func (h *BufPane) RevertHunk() bool {
+	if h.Buf.Path == "" || h.Buf.Type != buffer.BTDefault {
+		InfoBar.Error("Cannot revert a buffer without a file")
+		return false
+	}
+	base, _, err := h.Buf.GitText("HEAD")
+	if err != nil {
+		InfoBar.Error("The file is not tracked by git")
+		return false
+	}
+	hunk, ok := h.hunkAt(buffer.DiffLines(base, string(h.Buf.Bytes())), h.Cursor.Y)
+	if !ok {
+		InfoBar.Message("No change under the cursor")
+		return false
+	}
+
+	text := strings.Join(hunk.BaseLines(base), "\n")
+	start := buffer.Loc{X: 0, Y: hunk.Start}
+	end := buffer.Loc{X: 0, Y: hunk.End}
+	switch {
+	case hunk.End < h.Buf.LinesNum():
+		if text != "" {
+			text += "\n"
+		}
+	case hunk.Start == hunk.End:
+		// the deleted lines were at the end of a file without final newline
+		start = h.Buf.End()
+		end = start
+		text = "\n" + text
+	default:
+		// the hunk contains the last line, which has no newline
+		end = h.Buf.End()
+		if text == "" && hunk.Start > 0 {
+			start = buffer.Loc{X: utf8.RuneCount(h.Buf.LineBytes(hunk.Start - 1)), Y: hunk.Start - 1}
+		}
+	}
+	h.Buf.Replace(start, end, text)
+
+	h.Cursor.ResetSelection()
+	h.Cursor.GotoLoc(buffer.Loc{X: 0, Y: util.Min(hunk.Start, h.Buf.LinesNum()-1)})
+	h.Relocate()
+	InfoBar.Message("Reverted the change")
+	return true
+}
+
+// StageHunk adds the hunk of changes under the cursor to the git index,
+// comparing the buffer with the version of the file in the index
+func (h *BufPane) StageHunk() bool {
+	if h.Buf.Path == "" || h.Buf.Type != buffer.BTDefault {
+		InfoBar.Error("Cannot stage a buffer without a file")
+		return false
+	}
+	root, _, err := util.GitRepo(filepath.Dir(h.Buf.AbsPath))
+	if err != nil {
+		InfoBar.Error("The file is not in a git repository")
+		return false
+	}
+	name, err := filepath.Rel(root, h.Buf.AbsPath)
+	if err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+
+	index, crlf, err := h.Buf.GitIndexText()
+	if err != nil {
+		InfoBar.Error("The file is not tracked by git")
+		return false
+	}
+	text := string(h.Buf.Bytes())
+	hunk, ok := h.hunkAt(buffer.DiffLines(index, text), h.Cursor.Y)
+	if !ok {
+		InfoBar.Message("No unstaged change under the cursor")
+		return false
+	}
+
+	cmd := exec.Command("git", "-C", root, "apply", "--cached", "-")
+	cmd.Stdin = strings.NewReader(buffer.HunkPatch(filepath.ToSlash(name), index, text, hunk, crlf))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// the index is left untouched when the patch does not apply
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		InfoBar.Error("Could not stage the change: ", msg)
+		return false
+	}
+	InfoBar.Message("Staged the change")
+	return true
+}
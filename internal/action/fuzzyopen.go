@@ -0,0 +1,183 @@
+package action
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	shellquote "github.com/kballard/go-shellquote"
+	"github.com/zyedidia/micro/internal/shell"
+)
+
+// maxIndexedFiles is the maximum number of files indexed by FuzzyOpen
+const maxIndexedFiles = 20000
+
+var (
+	errIndexFull    = errors.New("file index is full")
+	errIndexStopped = errors.New("file index is not needed anymore")
+)
+
+// A fileIndex is a list of the files under a directory which is
+// built in the background
+type fileIndex struct {
+	sync.Mutex
+
+	root    string
+	files   []string
+	ignores []string
+	// stopped is set once the picker is closed, to end the walk
+	stopped bool
+}
+
+// loadGitignore reads the patterns of the .gitignore file at the root of the
+// index. This is a best-effort implementation: negated patterns are not
+// supported and nested .gitignore files are not read
+func (idx *fileIndex) loadGitignore() {
+	f, err := os.Open(filepath.Join(idx.root, ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		idx.ignores = append(idx.ignores, line)
+	}
+}
+
+// ignored returns true if the given path (relative to the root) matches
+// one of the ignore patterns
+func (idx *fileIndex) ignored(rel string, isDir bool) bool {
+	base := filepath.Base(rel)
+	if isDir && base == ".git" {
+		return true
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pat := range idx.ignores {
+		if strings.HasSuffix(pat, "/") {
+			if !isDir {
+				continue
+			}
+			pat = strings.TrimSuffix(pat, "/")
+		}
+		if strings.Contains(pat, "/") {
+			// patterns with a slash are relative to the root
+			if ok, _ := filepath.Match(strings.TrimPrefix(pat, "/"), rel); ok {
+				return true
+			}
+		} else if ok, _ := filepath.Match(pat, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// build walks the root directory and adds every file to the index, calling
+// update regularly so that the list can be refreshed while it grows. The
+// walk ends early when the index is stopped
+func (idx *fileIndex) build(update func()) {
+	idx.loadGitignore()
+
+	count := 0
+	filepath.Walk(idx.root, func(path string, info os.FileInfo, err error) error {
+		idx.Lock()
+		stopped := idx.stopped
+		idx.Unlock()
+		if stopped {
+			return errIndexStopped
+		}
+		if err != nil {
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path == idx.root {
+			return nil
+		}
+		rel, err := filepath.Rel(idx.root, path)
+		if err != nil {
+			return nil
+		}
+		if idx.ignored(rel, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		idx.Lock()
+		idx.files = append(idx.files, rel)
+		idx.Unlock()
+
+		count++
+		if count >= maxIndexedFiles {
+			return errIndexFull
+		}
+		if count%1000 == 0 {
+			update()
+		}
+		return nil
+	})
+	update()
+}
+
+// stop ends the building of the index
+func (idx *fileIndex) stop() {
+	idx.Lock()
+	idx.stopped = true
+	idx.Unlock()
+}
+
+// list returns a copy of the files indexed so far
+func (idx *fileIndex) list() []string {
+	idx.Lock()
+	defer idx.Unlock()
+	files := make([]string, len(idx.files))
+	copy(files, idx.files)
+	return files
+}
+
+// FuzzyOpen lets the user fuzzy search the files under the working directory
+// and opens the chosen one in the current pane
+func (h *BufPane) FuzzyOpen() bool {
+	wd, err := os.Getwd()
+	if err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+	if h.wd != "" {
+		wd = h.wd
+	}
+
+	idx := &fileIndex{root: wd}
+	InfoBar.Pick("Open file: ", "FuzzyOpen", idx.list, func(choice string, canceled bool) {
+		idx.stop()
+		if !canceled {
+			h.OpenCmd([]string{shellquote.Join(choice)})
+		}
+	})
+	p := InfoBar.picker
+
+	go idx.build(func() {
+		// the picker must be refreshed in the main goroutine
+		shell.Jobs <- shell.JobFunction{
+			Function: func(string, []interface{}) {
+				if InfoBar.picker == p {
+					p.filter(InfoBar.Buf, p.query)
+				}
+			},
+		}
+	})
+	return true
+}
@@ -0,0 +1,29 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileIndexStop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "micro-index")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a", "b", "c"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	idx := &fileIndex{root: dir}
+	idx.build(func() {})
+	assert.Equal(t, []string{"a", "b", "c"}, idx.list())
+
+	// closing the picker ends the walk
+	idx = &fileIndex{root: dir}
+	idx.stop()
+	idx.build(func() {})
+	assert.Empty(t, idx.list())
+}
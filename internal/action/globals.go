@@ -1,6 +1,9 @@
 package action
 
-import "github.com/zyedidia/micro/internal/buffer"
+import (
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/display"
+)
 
 var InfoBar *InfoPane
 var LogBufPane *BufPane
@@ -8,6 +11,12 @@ var LogBufPane *BufPane
 func InitGlobals() {
 	InfoBar = NewInfoBar()
 	buffer.LogBuf = buffer.NewBufferFromString("", "Log", buffer.BTLog)
+	display.SetStatusInfoFn("rec", func(b *buffer.Buffer) string {
+		if recording_macro {
+			return "REC "
+		}
+		return ""
+	})
 }
 
 func GetInfoBar() *InfoPane {
@@ -172,19 +172,11 @@ func OptionValueComplete(b *buffer.Buffer) ([]string, []string) {
 		switch inputOpt {
 		case "colorscheme":
 			_, suggestions = colorschemeComplete(input)
-		case "fileformat":
-			if strings.HasPrefix("unix", input) {
-				suggestions = append(suggestions, "unix")
-			}
-			if strings.HasPrefix("dos", input) {
-				suggestions = append(suggestions, "dos")
-			}
-		case "sucmd":
-			if strings.HasPrefix("sudo", input) {
-				suggestions = append(suggestions, "sudo")
-			}
-			if strings.HasPrefix("doas", input) {
-				suggestions = append(suggestions, "doas")
+		default:
+			for _, choice := range config.OptionChoices(inputOpt) {
+				if strings.HasPrefix(choice, input) {
+					suggestions = append(suggestions, choice)
+				}
 			}
 		}
 	}
@@ -253,6 +245,76 @@ func PluginComplete(b *buffer.Buffer) ([]string, []string) {
 	return completions, suggestions
 }
 
+// completeWith completes the argument under the cursor with the candidates
+// which start with it
+func completeWith(b *buffer.Buffer, candidates []string) ([]string, []string) {
+	c := b.GetActiveCursor()
+	input, argstart := buffer.GetArg(b)
+
+	var suggestions []string
+	for _, cand := range candidates {
+		if strings.HasPrefix(cand, input) && !contains(suggestions, cand) {
+			suggestions = append(suggestions, cand)
+		}
+	}
+
+	sort.Strings(suggestions)
+	completions := make([]string, len(suggestions))
+	for i := range suggestions {
+		completions[i] = util.SliceEndStr(suggestions[i], c.X-argstart)
+	}
+	return completions, suggestions
+}
+
+// argIndex returns the index of the argument under the cursor, the command
+// itself not counted
+func argIndex(b *buffer.Buffer) int {
+	c := b.GetActiveCursor()
+	l := util.SliceStart(b.LineBytes(c.Y), c.X)
+	return len(bytes.Split(l, []byte{' '})) - 2
+}
+
+// FlagComplete returns a completer for a command which takes the given flags
+func FlagComplete(flags ...string) buffer.Completer {
+	return func(b *buffer.Buffer) ([]string, []string) {
+		return completeWith(b, flags)
+	}
+}
+
+// BindingComplete autocompletes the keys which are bound
+func BindingComplete(b *buffer.Buffer) ([]string, []string) {
+	var keys []string
+	for k := range config.Bindings {
+		keys = append(keys, k)
+	}
+	return completeWith(b, keys)
+}
+
+// BindComplete completes the key and then the action for the bind command
+func BindComplete(b *buffer.Buffer) ([]string, []string) {
+	if argIndex(b) == 0 {
+		return BindingComplete(b)
+	}
+
+	var actions []string
+	for a := range BufKeyActions {
+		actions = append(actions, a)
+	}
+	for a := range BufMouseActions {
+		actions = append(actions, a)
+	}
+	return completeWith(b, actions)
+}
+
+// TabComplete autocompletes the names of the tabs
+func TabComplete(b *buffer.Buffer) ([]string, []string) {
+	var names []string
+	for _, t := range Tabs.List {
+		names = append(names, t.Name())
+	}
+	return completeWith(b, names)
+}
+
 // PluginNameComplete completes with the names of loaded plugins
 // func PluginNameComplete(b *buffer.Buffer) ([]string, []string) {
 // 	c := b.GetActiveCursor()
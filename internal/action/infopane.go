@@ -15,6 +15,15 @@ type InfoKeyAction func(*InfoPane)
 type InfoPane struct {
 	*BufPane
 	*info.InfoBuf
+
+	// the active picker if the prompt was started with Pick
+	picker *picker
+
+	// historySearching is set when the last key searched the history, and
+	// searchQuery is the text searched for
+	historySearching bool
+	continueSearch   bool
+	searchQuery      string
 }
 
 func NewInfoPane(ib *info.InfoBuf, w display.BWindow, tab *Tab) *InfoPane {
@@ -45,6 +54,8 @@ func (h *InfoPane) HandleEvent(event tcell.Event) {
 			r:    e.Rune(),
 		}
 
+		// repeating the history search continues it with the same text
+		h.continueSearch, h.historySearching = h.historySearching, false
 		done := h.DoKeyEvent(ke)
 		hasYN := h.HasYN
 		if e.Key() == tcell.KeyRune && hasYN {
@@ -92,6 +103,12 @@ func (h *InfoPane) DoKeyEvent(e KeyEvent) bool {
 				break
 			}
 		}
+		// HistorySearch is chained after an action of the buffer, as in
+		// ToggleRuler|HistorySearch, so it is looked for in the whole binding
+		if !done && hasHistorySearch(estr) {
+			done = true
+			h.HistorySearch()
+		}
 		if !done {
 			done = action(h.BufPane)
 		}
@@ -99,20 +116,40 @@ func (h *InfoPane) DoKeyEvent(e KeyEvent) bool {
 	return done
 }
 
+func hasHistorySearch(estr string) bool {
+	names := strings.FieldsFunc(estr, func(r rune) bool {
+		return r == '&' || r == '|' || r == ','
+	})
+	for _, name := range names {
+		if name == "HistorySearch" {
+			return true
+		}
+	}
+	return false
+}
+
 // InfoNones is a list of actions that should have no effect when executed
 // by an infohandler
 var InfoNones = []string{
 	"Save",
 	"SaveAll",
 	"SaveAs",
+	"RevertBuffer",
+	"DiffWithDisk",
+	"DiffNext",
+	"DiffPrevious",
 	"Find",
 	"FindNext",
 	"FindPrevious",
 	"Center",
 	"DuplicateLine",
+	"DuplicateAndComment",
+	"JoinWithSeparator",
+	"SplitOnDelimiter",
 	"MoveLinesUp",
 	"MoveLinesDown",
 	"OpenFile",
+	"FuzzyOpen",
 	"Start",
 	"End",
 	"PageUp",
@@ -123,17 +160,28 @@ var InfoNones = []string{
 	"HalfPageDown",
 	"ToggleHelp",
 	"ToggleKeyMenu",
-	"ToggleRuler",
+	"ToggleShowWhitespace",
+	"ToggleIndentGuides",
+	"ToggleColorColumn",
+	"ToggleMinimap",
 	"JumpLine",
 	"ClearStatus",
 	"ShellMode",
 	"CommandMode",
+	"CommandPalette",
 	"AddTab",
 	"PreviousTab",
 	"NextTab",
+	"MoveTabLeft",
+	"MoveTabRight",
+	"SwitchBuffer",
+	"AlternateBuffer",
+	"SuggestSpelling",
 	"NextSplit",
 	"PreviousSplit",
 	"Unsplit",
+	"MovePaneToNewTab",
+	"MergeTab",
 	"VSplit",
 	"HSplit",
 	"ToggleMacro",
@@ -146,6 +194,8 @@ var InfoNones = []string{
 	"RemoveMultiCursor",
 	"RemoveAllMultiCursors",
 	"SkipMultiCursor",
+	"ToggleGitBlame",
+	"ShowBlameCommit",
 }
 
 // InfoOverrides is the list of actions which have been overridden
@@ -162,17 +212,42 @@ var InfoOverrides = map[string]InfoKeyAction{
 
 // CursorUp cycles history up
 func (h *InfoPane) CursorUp() {
+	if h.picker != nil {
+		h.picker.move(h.Buf, false)
+		return
+	}
 	h.UpHistory(h.History[h.PromptType])
 }
 
 // CursorDown cycles history down
 func (h *InfoPane) CursorDown() {
+	if h.picker != nil {
+		h.picker.move(h.Buf, true)
+		return
+	}
 	h.DownHistory(h.History[h.PromptType])
 }
 
+// HistorySearch fetches the previous item of the history which contains the
+// text of the prompt. Repeating it searches further back for the same text
+func (h *InfoPane) HistorySearch() {
+	if h.picker != nil {
+		return
+	}
+	if !h.continueSearch {
+		h.searchQuery = string(h.LineBytes(0))
+	}
+	h.historySearching = true
+	h.SearchUpHistory(h.History[h.PromptType], h.searchQuery)
+}
+
 // Autocomplete begins autocompletion
 func (h *InfoPane) Autocomplete() {
 	b := h.Buf
+	if h.picker != nil {
+		h.picker.move(b, true)
+		return
+	}
 	if b.HasSuggestions {
 		b.CycleAutocomplete(true)
 		return
@@ -0,0 +1,27 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zyedidia/tcell"
+)
+
+func TestInfoPaneHistorySearch(t *testing.T) {
+	h := newTestPane("")
+	InitBindings()
+	ruler := h.Buf.Settings["ruler"]
+
+	InfoBar.History = map[string][]string{"Command": {"set ruler off", "vsplit", "set tabsize 2"}}
+	InfoBar.Prompt("> ", "set", "Command", nil, nil)
+	ctrlR := tcell.NewEventKey(tcell.KeyCtrlR, rune(tcell.KeyCtrlR), tcell.ModCtrl, "")
+	InfoBar.HandleEvent(ctrlR)
+	assert.Equal(t, "set tabsize 2", string(InfoBar.LineBytes(0)))
+	InfoBar.HandleEvent(ctrlR)
+	assert.Equal(t, "set ruler off", string(InfoBar.LineBytes(0)))
+
+	// the ruler of the buffer is left alone
+	assert.Equal(t, ruler, h.Buf.Settings["ruler"])
+	h.HandleEvent(ctrlR)
+	assert.NotEqual(t, ruler, h.Buf.Settings["ruler"])
+}
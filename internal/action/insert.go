@@ -0,0 +1,248 @@
+package action
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/util"
+)
+
+// now returns the time inserted by InsertDateTime and insertdate, and is
+// replaced in tests
+var now = time.Now
+
+// insertText inserts text at the cursor, replacing the selection
+func (h *BufPane) insertText(text string) {
+	if h.Cursor.HasSelection() {
+		h.Cursor.DeleteSelection()
+		h.Cursor.ResetSelection()
+	}
+	h.Buf.Insert(h.Cursor.Loc, text)
+	h.Relocate()
+}
+
+// InsertDateTime inserts the current date and time, in the format of the
+// dateformat option
+func (h *BufPane) InsertDateTime() bool {
+	h.insertText(now().Format(h.Buf.Settings["dateformat"].(string)))
+	return true
+}
+
+// InsertDateCmd inserts the current date and time at every cursor, in the
+// given format or in the format of the dateformat option
+func (h *BufPane) InsertDateCmd(args []string) {
+	layout := h.Buf.Settings["dateformat"].(string)
+	if len(args) > 0 {
+		layout = strings.Join(args, " ")
+	}
+	date := now().Format(layout)
+	active := h.Buf.GetActiveCursor()
+	for _, c := range h.Buf.GetCursors() {
+		h.Buf.SetCurCursor(c.Num)
+		h.Cursor = c
+		h.insertText(date)
+	}
+	h.Buf.SetCurCursor(active.Num)
+	h.Cursor = active
+	h.editDenied()
+}
+
+// InsertUUID inserts a random UUID, in the format of the uuidformat option
+func (h *BufPane) InsertUUID() bool {
+	uuid, err := util.NewUUID(rand.Reader)
+	if err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+	format := h.Buf.Settings["uuidformat"].(string)
+	if strings.HasPrefix(format, "upper") {
+		uuid = strings.ToUpper(uuid)
+	}
+	if strings.HasSuffix(format, "compact") {
+		uuid = strings.Replace(uuid, "-", "", -1)
+	}
+	h.insertText(uuid)
+	return true
+}
+
+// EvalMath replaces the selected arithmetic expression by its value. The
+// buffer is left as is if the expression is not valid
+func (h *BufPane) EvalMath() bool {
+	if !h.Cursor.HasSelection() {
+		InfoBar.Error("No expression selected")
+		return false
+	}
+	v, err := util.EvalMath(string(h.Cursor.GetSelection()))
+	if err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+	h.insertText(util.FormatNumber(v))
+	return true
+}
+
+// selectedNumbers returns the numbers in the selections of all the cursors,
+// so that a column selected with multiple cursors can be summed
+func (h *BufPane) selectedNumbers() []float64 {
+	var nums []float64
+	for _, c := range h.Buf.GetCursors() {
+		if c.HasSelection() {
+			nums = append(nums, util.NumbersIn(c.GetSelection())...)
+		}
+	}
+	return nums
+}
+
+// sumNumbers returns the sum of the selected numbers and a message with
+// their sum, count, average, minimum and maximum
+func (h *BufPane) sumNumbers() (float64, string, bool) {
+	nums := h.selectedNumbers()
+	if len(nums) == 0 {
+		return 0, "No numbers selected", false
+	}
+	sum, min, max := 0.0, nums[0], nums[0]
+	for _, n := range nums {
+		sum += n
+		min = math.Min(min, n)
+		max = math.Max(max, n)
+	}
+	msg := fmt.Sprintf("Sum: %s (%d numbers, average %s, min %s, max %s)", util.FormatNumber(sum), len(nums),
+		util.FormatNumber(sum/float64(len(nums))), util.FormatNumber(min), util.FormatNumber(max))
+	return sum, msg, true
+}
+
+// SumColumn shows the sum of the numbers in the selection, or in the
+// selections of all the cursors, with their average, minimum and maximum
+func (h *BufPane) SumColumn() bool {
+	_, msg, ok := h.sumNumbers()
+	InfoBar.Message(msg)
+	return ok
+}
+
+// SumCmd shows the sum of the selected numbers like the SumColumn action.
+// With -insert the sum is also inserted after the selection of the cursor
+func (h *BufPane) SumCmd(args []string) {
+	insert := false
+	for _, a := range args {
+		switch a {
+		case "-insert":
+			insert = true
+		default:
+			InfoBar.Error("usage: sum [-insert]")
+			return
+		}
+	}
+
+	sum, msg, ok := h.sumNumbers()
+	InfoBar.Message(msg)
+	if ok && insert {
+		h.Buf.ClearCursors()
+		h.Cursor = h.Buf.GetActiveCursor()
+		h.Cursor.Deselect(false)
+		h.insertText(util.FormatNumber(sum))
+		h.editDenied()
+	}
+}
+
+// addCursorIndex adds the index of every cursor, multiplied by sign, to the
+// number under it or after it on its line, in a single undo step
+func (h *BufPane) addCursorIndex(sign int64) bool {
+	var deltas []buffer.Delta
+	for _, c := range h.Buf.GetCursors() {
+		line := h.Buf.LineBytes(c.Y)
+		start, end, ok := util.NumberAt(line, c.X)
+		if !ok {
+			continue
+		}
+		runes := []rune(string(line))
+		num, err := util.AddToNumber(string(runes[start:end]), sign*int64(c.Num))
+		if err != nil {
+			InfoBar.Error(err)
+			return false
+		}
+		deltas = append(deltas, buffer.Delta{
+			Text:  []byte(num),
+			Start: buffer.Loc{X: start, Y: c.Y},
+			End:   buffer.Loc{X: end, Y: c.Y},
+		})
+	}
+	if len(deltas) == 0 {
+		InfoBar.Message("No numbers under the cursors")
+		return false
+	}
+
+	// replace from the end so that the locations of the other numbers
+	// on the same line stay valid
+	sort.Slice(deltas, func(i, j int) bool {
+		return deltas[j].Start.LessThan(deltas[i].Start)
+	})
+	h.Buf.MultipleReplace(deltas)
+	h.Buf.RelocateCursors()
+	h.Relocate()
+	return true
+}
+
+// IncrementByCursorIndex adds the index of every cursor (0 for the first
+// cursor, 1 for the second...) to the number under it, which turns a column
+// of identical numbers into a sequence
+func (h *BufPane) IncrementByCursorIndex() bool {
+	return h.addCursorIndex(1)
+}
+
+// DecrementByCursorIndex subtracts the index of every cursor from the
+// number under it
+func (h *BufPane) DecrementByCursorIndex() bool {
+	return h.addCursorIndex(-1)
+}
+
+// filePath returns the absolute path of the file of the buffer, or its path
+// relative to the working directory of the pane. It shows an error if the
+// buffer has no file
+func (h *BufPane) filePath(relative bool) (string, bool) {
+	if h.Buf.AbsPath == "" {
+		InfoBar.Error("The buffer has no file")
+		return "", false
+	}
+	if !relative {
+		return h.Buf.AbsPath, true
+	}
+	wd := h.wd
+	if wd == "" {
+		var err error
+		if wd, err = os.Getwd(); err != nil {
+			InfoBar.Error(err)
+			return "", false
+		}
+	}
+	path, err := filepath.Rel(wd, h.Buf.AbsPath)
+	if err != nil {
+		return h.Buf.AbsPath, true
+	}
+	return path, true
+}
+
+// InsertFilePath inserts the absolute path of the file of the buffer
+func (h *BufPane) InsertFilePath() bool {
+	path, ok := h.filePath(false)
+	if ok {
+		h.insertText(path)
+	}
+	return ok
+}
+
+// InsertRelativeFilePath inserts the path of the file of the buffer relative
+// to the working directory
+func (h *BufPane) InsertRelativeFilePath() bool {
+	path, ok := h.filePath(true)
+	if ok {
+		h.insertText(path)
+	}
+	return ok
+}
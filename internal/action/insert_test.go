@@ -0,0 +1,40 @@
+package action
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zyedidia/micro/internal/buffer"
+)
+
+func fixedNow() time.Time {
+	return time.Date(2020, time.March, 4, 5, 6, 7, 0, time.UTC)
+}
+
+func TestInsertDateTime(t *testing.T) {
+	now = fixedNow
+	defer func() { now = time.Now }()
+
+	h := newTestPane("")
+	h.Buf.SetOptionNative("dateformat", "2006-01-02")
+	h.InsertDateTime()
+	assert.Equal(t, "2020-03-04", string(h.Buf.Bytes()))
+}
+
+func TestInsertDateCmd(t *testing.T) {
+	now = fixedNow
+	defer func() { now = time.Now }()
+
+	h := newTestPane("a\nb")
+	c := buffer.NewCursor(h.Buf, buffer.Loc{X: 1, Y: 1})
+	h.Buf.AddCursor(c)
+	h.Buf.SetCurCursor(0)
+	h.Cursor = h.Buf.GetActiveCursor()
+
+	h.InsertDateCmd([]string{"15:04"})
+	assert.Equal(t, "05:06a\nb05:06", string(h.Buf.Bytes()))
+	assert.Equal(t, 0, h.Cursor.Num)
+	assert.Equal(t, h.Buf.GetActiveCursor(), h.Cursor)
+	assert.Equal(t, buffer.Loc{X: 5, Y: 0}, h.Cursor.Loc)
+}
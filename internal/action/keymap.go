@@ -0,0 +1,96 @@
+package action
+
+import (
+	"github.com/zyedidia/micro/internal/screen"
+	"github.com/zyedidia/tcell"
+)
+
+// KeyLayers are the keymaps which the keymap option selects. The bindings of
+// the keymap of a buffer take precedence over the usual bindings, in the
+// same format as bindings.json
+var KeyLayers = map[string]map[string]string{
+	"pager": {
+		" ":         "PageDown",
+		"f":         "PageDown",
+		"b":         "PageUp",
+		"d":         "HalfPageDown",
+		"u":         "HalfPageUp",
+		"j":         "CursorDown",
+		"k":         "CursorUp",
+		"e":         "ScrollDown",
+		"y":         "ScrollUp",
+		"g":         "CursorStart",
+		"<":         "CursorStart",
+		"G":         "CursorEnd",
+		">":         "CursorEnd",
+		"/":         "Find",
+		"n":         "FindNext",
+		"N":         "FindPrevious",
+		"q":         "Quit",
+		"Backspace": "PageUp",
+	},
+}
+
+// keyLayerBindings caches the parsed bindings of the keymaps
+var keyLayerBindings = make(map[string]map[Event]BufKeyAction)
+
+// keyLayer returns the bindings of the keymap selected by the keymap option
+// of the buffer, or nil if there is none
+func (h *BufPane) keyLayer() map[Event]BufKeyAction {
+	name, _ := h.Buf.Settings["keymap"].(string)
+	if name == "" {
+		return nil
+	}
+	if bindings, ok := keyLayerBindings[name]; ok {
+		return bindings
+	}
+	layer, ok := KeyLayers[name]
+	if !ok {
+		return nil
+	}
+
+	bindings := make(map[Event]BufKeyAction)
+	for k, v := range layer {
+		event, ok := findEvent(k)
+		if !ok {
+			screen.TermMessage(k, "is not a bindable event")
+			continue
+		}
+		bindings[event] = bufKeyAction(v)
+	}
+	keyLayerBindings[name] = bindings
+	return bindings
+}
+
+// DoKeyLayerEvent executes a key event bound in the keymap of the buffer and
+// returns whether it was
+func (h *BufPane) DoKeyLayerEvent(e KeyEvent) bool {
+	bindings := h.keyLayer()
+	if bindings == nil {
+		return false
+	}
+	action, ok := bindings[e]
+	if !ok && e.code == tcell.KeyRune {
+		// some terminals report the shift of upper case letters
+		e.mod = tcell.ModNone
+		action, ok = bindings[e]
+	}
+	if !ok {
+		return false
+	}
+	return action(h)
+}
+
+// PagerCmd toggles the pager mode of the buffer, which makes it read-only
+// and selects the pager keymap with keys like less
+func (h *BufPane) PagerCmd(args []string) {
+	if h.Buf.Settings["keymap"] == "pager" {
+		h.Buf.SetOptionNative("keymap", "")
+		h.Buf.SetOptionNative("readonly", false)
+		InfoBar.Message("Disabled pager mode")
+		return
+	}
+	h.Buf.SetOptionNative("readonly", true)
+	h.Buf.SetOptionNative("keymap", "pager")
+	InfoBar.Message("Enabled pager mode, press q to quit")
+}
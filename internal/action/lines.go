@@ -0,0 +1,381 @@
+package action
+
+import (
+	"errors"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/config"
+	"github.com/zyedidia/micro/internal/util"
+)
+
+// selectLines returns the range of lines spanned by the selection, or all the
+// lines of the buffer if there is no selection. The end is excluded, and a
+// selection which ends at the start of a line does not include that line.
+// The empty line after the final newline of the buffer is not included either
+func (h *BufPane) selectLines() (int, int) {
+	if !h.Cursor.HasSelection() {
+		end := h.Buf.LinesNum()
+		if end > 1 && len(h.Buf.LineBytes(end-1)) == 0 {
+			end--
+		}
+		return 0, end
+	}
+	start, end := h.Cursor.CurSelection[0], h.Cursor.CurSelection[1]
+	if start.GreaterThan(end) {
+		start, end = end, start
+	}
+	if end.X == 0 && end.Y > start.Y {
+		return start.Y, end.Y
+	}
+	return start.Y, end.Y + 1
+}
+
+// getLines returns the lines from start to end, end excluded
+func (h *BufPane) getLines(start, end int) []string {
+	lines := make([]string, 0, end-start)
+	for y := start; y < end; y++ {
+		lines = append(lines, string(h.Buf.LineBytes(y)))
+	}
+	return lines
+}
+
+// replaceLines replaces the lines from start to end, end excluded, with the
+// given lines in a single edit, so that it is undone at once. The lines are
+// selected afterwards if there was a selection
+func (h *BufPane) replaceLines(start, end int, lines []string) {
+	hadSelection := h.Cursor.HasSelection()
+	loc := h.Cursor.Loc
+
+	last := end - 1
+	h.Buf.Replace(buffer.Loc{X: 0, Y: start}, buffer.Loc{X: utf8.RuneCount(h.Buf.LineBytes(last)), Y: last}, strings.Join(lines, "\n"))
+
+	h.Cursor.ResetSelection()
+	last = start + len(lines) - 1
+	if hadSelection {
+		h.Cursor.SetSelectionStart(buffer.Loc{X: 0, Y: start})
+		h.Cursor.SetSelectionEnd(buffer.Loc{X: utf8.RuneCount(h.Buf.LineBytes(last)), Y: last})
+		h.Cursor.GotoLoc(h.Cursor.CurSelection[1])
+	} else {
+		loc.Y = util.Clamp(loc.Y, 0, h.Buf.LinesNum()-1)
+		loc.X = util.Clamp(loc.X, 0, utf8.RuneCount(h.Buf.LineBytes(loc.Y)))
+		h.Cursor.GotoLoc(loc)
+	}
+	h.Relocate()
+}
+
+// SortLines sorts the selected lines, or all the lines of the buffer
+func (h *BufPane) SortLines() bool {
+	start, end := h.selectLines()
+	lines := h.getLines(start, end)
+	sort.Strings(lines)
+	h.replaceLines(start, end, lines)
+	InfoBar.Message("Sorted ", len(lines), " lines")
+	return true
+}
+
+// SortLinesNumeric sorts the selected lines, or all the lines of the buffer,
+// by the number they start with
+func (h *BufPane) SortLinesNumeric() bool {
+	start, end := h.selectLines()
+	lines := buffer.SortLinesNumeric(h.getLines(start, end))
+	h.replaceLines(start, end, lines)
+	InfoBar.Message("Sorted ", len(lines), " lines")
+	return true
+}
+
+// SortByKey asks for a regular expression and sorts the selected lines, or
+// all the lines of the buffer, by the text matched by its first group
+func (h *BufPane) SortByKey() bool {
+	InfoBar.Prompt("Sort key (regex): ", "", "SortByKey", nil, func(resp string, canceled bool) {
+		if canceled || resp == "" {
+			return
+		}
+		re, err := regexp.Compile(resp)
+		if err != nil {
+			InfoBar.Error(err)
+			return
+		}
+
+		start, end := h.selectLines()
+		lines := buffer.SortLinesByKey(h.getLines(start, end), re, h.Buf.Settings["sortunmatched"].(string) == "top")
+		h.replaceLines(start, end, lines)
+		InfoBar.Message("Sorted ", len(lines), " lines")
+	})
+	return true
+}
+
+// ShuffleLines puts the selected lines, or all the lines of the buffer, in a
+// random order. The undocumented shuffleseed option fixes the seed, to get
+// the same order every time
+func (h *BufPane) ShuffleLines() bool {
+	seed := int64(config.GetGlobalOption("shuffleseed").(float64))
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	start, end := h.selectLines()
+	h.replaceLines(start, end, buffer.ShuffleLines(h.getLines(start, end), rand.New(rand.NewSource(seed))))
+	return true
+}
+
+// ReverseLines puts the selected lines, or all the lines of the buffer, in
+// the reverse order
+func (h *BufPane) ReverseLines() bool {
+	start, end := h.selectLines()
+	h.replaceLines(start, end, buffer.ReverseLines(h.getLines(start, end)))
+	return true
+}
+
+// NumberLines prepends its number to every selected line, or to every line
+// of the buffer
+func (h *BufPane) NumberLines() bool {
+	start, end := h.selectLines()
+	h.replaceLines(start, end, buffer.NumberLines(h.getLines(start, end), 1, 0, ": "))
+	return true
+}
+
+// StripLineNumbers removes the line numbers added by NumberLines, or any
+// number followed by a colon or a whitespace at the start of the lines
+func (h *BufPane) StripLineNumbers() bool {
+	start, end := h.selectLines()
+	h.replaceLines(start, end, buffer.StripLineNumbers(h.getLines(start, end)))
+	return true
+}
+
+// uniqueLines removes the duplicate selected lines, or the duplicate lines
+// of the buffer
+func (h *BufPane) uniqueLines(adjacentOnly bool) bool {
+	start, end := h.selectLines()
+	lines := buffer.UniqueLines(h.getLines(start, end), adjacentOnly)
+	removed := end - start - len(lines)
+	if removed == 0 {
+		InfoBar.Message("No duplicate lines")
+		return false
+	}
+	h.replaceLines(start, end, lines)
+	InfoBar.Message("Removed ", removed, " duplicate lines")
+	return true
+}
+
+// UniqueLines removes the duplicate selected lines, or the duplicate lines
+// of the buffer, keeping the first occurrence of every line
+func (h *BufPane) UniqueLines() bool {
+	return h.uniqueLines(false)
+}
+
+// DuplicateAndComment duplicates the current line or the selected lines
+// below and comments out the original lines, with the comment type of the
+// commenttype option set by the comment plugin. The cursor moves to the copy.
+// Nothing is done if the buffer has no commenttype, since the comment syntax
+// of its filetype is not known
+func (h *BufPane) DuplicateAndComment() bool {
+	commentType, _ := h.Buf.Settings["commenttype"].(string)
+	if commentType == "" {
+		InfoBar.Error("No comment type for the filetype ", h.Buf.FileType())
+		return false
+	}
+	start, end := h.Cursor.Y, h.Cursor.Y+1
+	if h.Cursor.HasSelection() {
+		start, end = h.selectLines()
+	}
+
+	loc := h.Cursor.Loc
+	hadSelection := h.Cursor.HasSelection()
+	lines := h.getLines(start, end)
+	h.replaceLines(start, end, append(buffer.CommentLines(lines, commentType), lines...))
+
+	n := end - start
+	if hadSelection {
+		last := end + n - 1
+		h.Cursor.SetSelectionStart(buffer.Loc{X: 0, Y: start + n})
+		h.Cursor.SetSelectionEnd(buffer.Loc{X: utf8.RuneCount(h.Buf.LineBytes(last)), Y: last})
+		h.Cursor.GotoLoc(h.Cursor.CurSelection[1])
+	} else {
+		loc.Y += n
+		h.Cursor.GotoLoc(loc)
+	}
+	h.Relocate()
+	return true
+}
+
+// JoinWithSeparator asks for a separator and joins the selected lines, or
+// the current line and the next one, with it. \t in the separator stands for
+// a tab
+func (h *BufPane) JoinWithSeparator() bool {
+	start, end := h.Cursor.Y, h.Cursor.Y+2
+	if h.Cursor.HasSelection() {
+		start, end = h.selectLines()
+	}
+	if end > h.Buf.LinesNum() || end-start < 2 {
+		InfoBar.Message("Nothing to join")
+		return false
+	}
+	InfoBar.Prompt("Separator: ", "", "JoinSeparator", nil, func(resp string, canceled bool) {
+		if canceled {
+			return
+		}
+		joined := buffer.JoinLines(h.getLines(start, end), util.ParseSpecial(resp), h.Buf.Settings["jointrim"].(bool))
+		h.replaceLines(start, end, []string{joined})
+		h.Cursor.ResetSelection()
+		h.Cursor.GotoLoc(buffer.Loc{X: utf8.RuneCountInString(joined), Y: start})
+		h.Relocate()
+		InfoBar.Message("Joined ", end-start, " lines")
+	})
+	return true
+}
+
+// SplitOnDelimiter asks for a delimiter and splits the current line, or the
+// selected lines, at every occurrence of it. \t in the delimiter stands for
+// a tab
+func (h *BufPane) SplitOnDelimiter() bool {
+	start, end := h.Cursor.Y, h.Cursor.Y+1
+	if h.Cursor.HasSelection() {
+		start, end = h.selectLines()
+	}
+	InfoBar.Prompt("Delimiter: ", "", "SplitDelimiter", nil, func(resp string, canceled bool) {
+		if canceled || resp == "" {
+			return
+		}
+		delim := util.ParseSpecial(resp)
+		keepIndent := h.Buf.Settings["splitkeepindent"].(bool)
+		keepEmpty := h.Buf.Settings["splitkeepempty"].(bool)
+
+		var lines []string
+		for _, l := range h.getLines(start, end) {
+			lines = append(lines, buffer.SplitLine(l, delim, keepIndent, keepEmpty)...)
+		}
+		h.replaceLines(start, end, lines)
+		InfoBar.Message("Split into ", len(lines), " lines")
+	})
+	return true
+}
+
+// deleteLines deletes the lines from start to end, end excluded, with their
+// line breaks
+func (h *BufPane) deleteLines(start, end int) {
+	h.Cursor.ResetSelection()
+	switch {
+	case end < h.Buf.LinesNum():
+		h.Buf.Remove(buffer.Loc{X: 0, Y: start}, buffer.Loc{X: 0, Y: end})
+	case start > 0:
+		h.Buf.Remove(buffer.Loc{X: utf8.RuneCount(h.Buf.LineBytes(start - 1)), Y: start - 1}, h.Buf.End())
+	default:
+		h.Buf.Remove(h.Buf.Start(), h.Buf.End())
+	}
+	h.Cursor.GotoLoc(buffer.Loc{X: 0, Y: util.Clamp(start, 0, h.Buf.LinesNum()-1)})
+	h.Relocate()
+}
+
+// filterLines keeps the selected lines, or the lines of the buffer, which
+// match the regex of the command, or removes them if keep is false
+func (h *BufPane) filterLines(args []string, keep bool, usage string) {
+	if len(args) == 0 {
+		InfoBar.Error(usage)
+		return
+	}
+	re, err := regexp.Compile(strings.Join(args, " "))
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+
+	start, end := h.selectLines()
+	lines := buffer.FilterLines(h.getLines(start, end), re, keep)
+	removed := end - start - len(lines)
+	if removed == 0 {
+		InfoBar.Message("No lines removed")
+		return
+	}
+	if len(lines) == 0 {
+		h.deleteLines(start, end)
+	} else {
+		h.replaceLines(start, end, lines)
+	}
+	InfoBar.Message("Removed ", removed, " lines")
+}
+
+// KeepLinesCmd removes the selected lines, or the lines of the buffer,
+// which don't match the regex
+func (h *BufPane) KeepLinesCmd(args []string) {
+	h.filterLines(args, true, "usage: keeplines regex")
+}
+
+// RemoveLinesCmd removes the selected lines, or the lines of the buffer,
+// which match the regex
+func (h *BufPane) RemoveLinesCmd(args []string) {
+	h.filterLines(args, false, "usage: removelines regex")
+}
+
+// SortCmd sorts the selected lines, or all the lines of the buffer. With -n
+// the lines are sorted by the number they start with
+func (h *BufPane) SortCmd(args []string) {
+	numeric := false
+	for _, a := range args {
+		switch a {
+		case "-n":
+			numeric = true
+		default:
+			InfoBar.Error("usage: sort [-n]")
+			return
+		}
+	}
+	if numeric {
+		h.SortLinesNumeric()
+	} else {
+		h.SortLines()
+	}
+}
+
+// UniqCmd removes the duplicate selected lines, or the duplicate lines of
+// the buffer. With -a only the consecutive duplicates are removed
+func (h *BufPane) UniqCmd(args []string) {
+	adjacentOnly := false
+	for _, a := range args {
+		switch a {
+		case "-a":
+			adjacentOnly = true
+		default:
+			InfoBar.Error("usage: uniq [-a]")
+			return
+		}
+	}
+	h.uniqueLines(adjacentOnly)
+}
+
+// NumberLinesCmd prepends its number to every selected line, or to every
+// line of the buffer. The options set the first number, the width of the
+// numbers and the separator which follows them
+func (h *BufPane) NumberLinesCmd(args []string) {
+	first, width, sep := 1, 0, ": "
+	usage := "usage: numberlines [-start n] [-width n] [-sep separator]"
+	for i := 0; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			InfoBar.Error(usage)
+			return
+		}
+		var err error
+		switch args[i] {
+		case "-start":
+			first, err = strconv.Atoi(args[i+1])
+		case "-width":
+			width, err = strconv.Atoi(args[i+1])
+		case "-sep":
+			sep = args[i+1]
+		default:
+			err = errors.New(usage)
+		}
+		if err != nil {
+			InfoBar.Error(err)
+			return
+		}
+	}
+
+	start, end := h.selectLines()
+	h.replaceLines(start, end, buffer.NumberLines(h.getLines(start, end), first, width, sep))
+}
@@ -0,0 +1,46 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zyedidia/micro/internal/config"
+)
+
+func TestWholeBufferLineActions(t *testing.T) {
+	h := newTestPane("b\na\nc\na\n")
+	h.SortLines()
+	assert.Equal(t, "a\na\nb\nc\n", string(h.Buf.Bytes()))
+
+	h.ReverseLines()
+	assert.Equal(t, "c\nb\na\na\n", string(h.Buf.Bytes()))
+
+	h.UniqueLines()
+	assert.Equal(t, "c\nb\na\n", string(h.Buf.Bytes()))
+
+	h.NumberLines()
+	assert.Equal(t, "1: c\n2: b\n3: a\n", string(h.Buf.Bytes()))
+
+	config.GlobalSettings["shuffleseed"] = float64(1)
+	h.ShuffleLines()
+	assert.Len(t, h.Buf.Bytes(), len("1: c\n2: b\n3: a\n"))
+	assert.Equal(t, byte('\n'), h.Buf.Bytes()[len(h.Buf.Bytes())-1])
+	assert.Equal(t, 4, h.Buf.LinesNum())
+}
+
+func TestLineActionsWithoutFinalNewline(t *testing.T) {
+	h := newTestPane("b\na")
+	h.SortLines()
+	assert.Equal(t, "a\nb", string(h.Buf.Bytes()))
+}
+
+func TestDuplicateAndComment(t *testing.T) {
+	h := newTestPane("a\nb")
+	assert.False(t, h.DuplicateAndComment())
+	assert.Equal(t, "a\nb", string(h.Buf.Bytes()))
+
+	h.Buf.Settings["commenttype"] = "// %s"
+	assert.True(t, h.DuplicateAndComment())
+	assert.Equal(t, "// a\na\nb", string(h.Buf.Bytes()))
+	assert.Equal(t, 1, h.Cursor.Y)
+}
@@ -0,0 +1,118 @@
+package action
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	shellquote "github.com/kballard/go-shellquote"
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/shell"
+	"github.com/zyedidia/micro/internal/util"
+)
+
+// lintOwner is the owner of the gutter messages created by the linter
+const lintOwner = "lintcmd"
+
+// lintRegex matches the lines of the linter output in the usual
+// file:line:col: message format, the column being optional
+var lintRegex = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)?\s*(.*)$`)
+
+// runLinter runs the command of the lintcmd option in the background and shows
+// the problems it reports for this buffer in the gutter. A %f in the command
+// is replaced by the path of the buffer
+func (h *BufPane) runLinter() {
+	b := h.Buf
+	cmd := b.Settings["lintcmd"].(string)
+	if cmd == "" || b.Path == "" {
+		return
+	}
+	cmd = strings.Replace(cmd, "%f", shellquote.Join(b.Path), -1)
+
+	shell.JobStart(cmd, nil, nil, func(out string, args []interface{}) {
+		b.ClearMessages(lintOwner)
+		for _, l := range strings.Split(out, "\n") {
+			if m := parseLintLine(l, b.AbsPath); m != nil {
+				b.AddMessage(m)
+			}
+		}
+	})
+}
+
+// parseLintLine returns the gutter message for a line of the linter output,
+// or nil if the line does not refer to the given file
+func parseLintLine(line, abspath string) *buffer.Message {
+	match := lintRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if match == nil {
+		return nil
+	}
+	if file, err := filepath.Abs(match[1]); err != nil || file != abspath {
+		return nil
+	}
+
+	y, _ := strconv.Atoi(match[2])
+	msg := match[4]
+	var kind buffer.MsgType = buffer.MTError
+	if strings.Contains(strings.ToLower(msg), "warning") {
+		kind = buffer.MTWarning
+	}
+
+	if match[3] == "" {
+		return buffer.NewMessageAtLine(lintOwner, msg, y, kind)
+	}
+	x, _ := strconv.Atoi(match[3])
+	start := buffer.Loc{X: util.Max(x-1, 0), Y: y - 1}
+	end := buffer.Loc{X: start.X + 1, Y: start.Y}
+	return buffer.NewMessage(lintOwner, msg, start, end, kind)
+}
+
+// gotoLintError moves the cursor to the next or previous line with a linter
+// message, wrapping around at the ends of the buffer
+func (h *BufPane) gotoLintError(forward bool) bool {
+	var target *buffer.Message
+	for _, m := range h.Buf.Messages {
+		if m.Owner != lintOwner {
+			continue
+		}
+		if target == nil {
+			target = m
+			continue
+		}
+
+		// the distance in lines from the cursor in the search direction
+		dist := func(m *buffer.Message) int {
+			d := m.Start.Y - h.Cursor.Y
+			if !forward {
+				d = -d
+			}
+			if d <= 0 {
+				d += h.Buf.LinesNum()
+			}
+			return d
+		}
+		if dist(m) < dist(target) {
+			target = m
+		}
+	}
+
+	if target == nil {
+		InfoBar.Message("No lint errors")
+		return false
+	}
+
+	h.Cursor.ResetSelection()
+	h.Cursor.GotoLoc(buffer.Loc{X: util.Max(target.Start.X, 0), Y: target.Start.Y})
+	h.Relocate()
+	return true
+}
+
+// NextLintError moves the cursor to the next line flagged by the linter
+func (h *BufPane) NextLintError() bool {
+	return h.gotoLintError(true)
+}
+
+// PrevLintError moves the cursor to the previous line flagged by the linter
+func (h *BufPane) PrevLintError() bool {
+	return h.gotoLintError(false)
+}
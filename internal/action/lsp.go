@@ -0,0 +1,258 @@
+package action
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/lsp"
+	"github.com/zyedidia/micro/internal/shell"
+	"github.com/zyedidia/micro/internal/util"
+)
+
+// lspOwner is the owner of the gutter messages created from the
+// diagnostics of the language server
+const lspOwner = "lsp"
+
+// lspClients holds the running language servers by command
+var lspClients = make(map[string]*lsp.Client)
+
+// lspRun runs request in the background with the language server of the
+// buffer of this pane, starting the server if needed, after sending it the
+// current text of the buffer. The function returned by request is then run
+// in the main goroutine to use the result. lspRun returns false if the
+// lspcmd option is not set
+func (h *BufPane) lspRun(request func(c *lsp.Client) func()) bool {
+	b := h.Buf
+	cmd := b.Settings["lspcmd"].(string)
+	if cmd == "" || b.Path == "" {
+		return false
+	}
+
+	c := lspClients[cmd]
+	if c != nil && !c.Alive() {
+		delete(lspClients, cmd)
+		c = nil
+	}
+	path, filetype, text := b.AbsPath, b.Settings["filetype"].(string), b.Bytes()
+	go func() {
+		started := c == nil
+		if started {
+			wd, _ := os.Getwd()
+			var err error
+			if c, err = lsp.Start(cmd, wd, onDiagnostics); err != nil {
+				lspDone(func() {
+					InfoBar.Error("Language server: ", err)
+				})
+				return
+			}
+		}
+
+		var done func()
+		if err := c.Sync(path, filetype, text); err != nil {
+			done = func() {
+				InfoBar.Error("Language server: ", err)
+			}
+		} else {
+			done = request(c)
+		}
+		lspDone(func() {
+			if started {
+				// another request may have started the server meanwhile
+				if running := lspClients[cmd]; running != nil && running.Alive() {
+					go c.Close()
+				} else {
+					lspClients[cmd] = c
+				}
+			}
+			done()
+		})
+	}()
+	return true
+}
+
+// lspDone runs f in the main goroutine
+func lspDone(f func()) {
+	shell.Jobs <- shell.JobFunction{
+		Function: func(string, []interface{}) {
+			f()
+		},
+	}
+}
+
+// lspPosition converts a location of the buffer to a position of the protocol
+func lspPosition(b *buffer.Buffer, loc buffer.Loc) lsp.Position {
+	return lsp.Position{Line: loc.Y, Character: lsp.UTF16Offset(b.LineBytes(loc.Y), loc.X)}
+}
+
+// bufferLoc converts a position of the protocol to a location of the buffer
+func bufferLoc(b *buffer.Buffer, pos lsp.Position) buffer.Loc {
+	y := util.Clamp(pos.Line, 0, b.LinesNum()-1)
+	return buffer.Loc{X: lsp.CharOffset(b.LineBytes(y), pos.Character), Y: y}
+}
+
+// onDiagnostics shows the diagnostics published by a language server as
+// gutter messages of the buffers of the file
+func onDiagnostics(path string, diags []lsp.Diagnostic) {
+	shell.Jobs <- shell.JobFunction{
+		Function: func(string, []interface{}) {
+			for _, b := range buffer.OpenBuffers {
+				if b.AbsPath != path {
+					continue
+				}
+				b.ClearMessages(lspOwner)
+				for _, d := range diags {
+					var kind buffer.MsgType = buffer.MTInfo
+					switch d.Severity {
+					case lsp.SeverityError:
+						kind = buffer.MTError
+					case lsp.SeverityWarning:
+						kind = buffer.MTWarning
+					}
+					start := bufferLoc(b, d.Range.Start)
+					end := bufferLoc(b, d.Range.End)
+					b.AddMessage(buffer.NewMessage(lspOwner, d.Message, start, end, kind))
+				}
+			}
+		},
+	}
+}
+
+// lspAutocomplete asks the language server for the completions at the
+// cursor and shows them once they arrive, if the cursor has not moved and
+// its line has not changed meanwhile. The words of the buffer are completed
+// if the server has no completion, and a tab is inserted if there is no
+// word either. If the server is not running yet, it is started for the next
+// completions and lspAutocomplete returns false
+func (h *BufPane) lspAutocomplete() bool {
+	b := h.Buf
+	if c := lspClients[b.Settings["lspcmd"].(string)]; c == nil || !c.Alive() {
+		h.lspRun(func(c *lsp.Client) func() {
+			return func() {}
+		})
+		return false
+	}
+
+	loc := h.Cursor.Loc
+	line := string(b.LineBytes(loc.Y))
+	pos := lspPosition(b, loc)
+	return h.lspRun(func(c *lsp.Client) func() {
+		items, _ := c.Completion(b.AbsPath, pos)
+		return func() {
+			if h.Buf != b || h.Cursor.Loc != loc || h.Cursor.HasSelection() ||
+				b.HasSuggestions || loc.Y >= b.LinesNum() || string(b.LineBytes(loc.Y)) != line {
+				return
+			}
+			if !b.Autocomplete(lspComplete(items)) && !b.Autocomplete(buffer.BufferComplete) {
+				h.InsertTab()
+			}
+		}
+	})
+}
+
+// lspComplete returns a completer which completes the word at the cursor
+// with the completion items of the language server
+func lspComplete(items []lsp.CompletionItem) buffer.Completer {
+	return func(b *buffer.Buffer) ([]string, []string) {
+		input, argstart := buffer.GetWord(b)
+		if argstart == -1 {
+			input = nil
+		}
+		prefix := string(input)
+
+		var completions, suggestions []string
+		seen := make(map[string]bool)
+		for _, item := range items {
+			text := item.Text()
+			if seen[text] || len(text) <= len(prefix) || !strings.HasPrefix(text, prefix) {
+				continue
+			}
+			seen[text] = true
+			completions = append(completions, util.SliceEndStr(text, utf8.RuneCountInString(prefix)))
+			suggestions = append(suggestions, item.Label)
+		}
+		return completions, suggestions
+	}
+}
+
+// lspDidSave notifies the language server that the buffer was saved so that
+// it can update the diagnostics
+func (h *BufPane) lspDidSave() {
+	path := h.Buf.AbsPath
+	h.lspRun(func(c *lsp.Client) func() {
+		c.DidSave(path)
+		return func() {}
+	})
+}
+
+// GotoDefinition asks the language server for the definition of the symbol
+// under the cursor and moves there, opening its file in a new tab if needed
+func (h *BufPane) GotoDefinition() bool {
+	path, pos := h.Buf.AbsPath, lspPosition(h.Buf, h.Cursor.Loc)
+	ok := h.lspRun(func(c *lsp.Client) func() {
+		loc, err := c.Definition(path, pos)
+		return func() {
+			if err != nil {
+				InfoBar.Error(err)
+				return
+			}
+			h.gotoLocation(loc)
+		}
+	})
+	if !ok {
+		InfoBar.Error("No language server, set the lspcmd option")
+	}
+	return ok
+}
+
+// gotoLocation moves to a location given by the language server, opening
+// its file in a new tab if needed
+func (h *BufPane) gotoLocation(loc lsp.Location) {
+	path := lsp.Path(loc.URI)
+
+	var pane *BufPane
+	for i, t := range Tabs.List {
+		for j, p := range t.Panes {
+			if bp, ok := p.(*BufPane); ok && bp.Buf.AbsPath == path {
+				Tabs.SetActive(i)
+				t.SetActive(j)
+				pane = bp
+				break
+			}
+		}
+		if pane != nil {
+			break
+		}
+	}
+	if pane == nil {
+		h.NewTabCmd([]string{path})
+		pane = MainTab().CurPane()
+	}
+
+	pane.Cursor.ResetSelection()
+	pane.Cursor.GotoLoc(bufferLoc(pane.Buf, loc.Range.Start))
+	pane.Relocate()
+}
+
+// Hover shows the documentation the language server has for the symbol
+// under the cursor
+func (h *BufPane) Hover() bool {
+	path, pos := h.Buf.AbsPath, lspPosition(h.Buf, h.Cursor.Loc)
+	ok := h.lspRun(func(c *lsp.Client) func() {
+		text, err := c.Hover(path, pos)
+		return func() {
+			if err != nil {
+				InfoBar.Error(err)
+			} else if text == "" {
+				InfoBar.Message("No information available")
+			} else {
+				InfoBar.Message(strings.Join(strings.Fields(text), " "))
+			}
+		}
+	})
+	if !ok {
+		InfoBar.Error("No language server, set the lspcmd option")
+	}
+	return ok
+}
@@ -0,0 +1,110 @@
+package action
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/util"
+)
+
+// maxPickerResults is the maximum number of matches shown by a picker
+const maxPickerResults = 100
+
+// A picker lets the user choose one entry of a list by typing a fuzzy
+// query in the infobar. The best matches are shown in the suggestion
+// line above the infobar
+type picker struct {
+	// entries returns the list to choose from, it is called again every
+	// time the query changes so the list may grow in the background
+	entries func() []string
+
+	query   string
+	list    []string
+	matches []int
+}
+
+// filter updates the matches for the given query and shows them as the
+// suggestions of the given buffer
+func (p *picker) filter(b *buffer.Buffer, query string) {
+	p.query = query
+	p.list = p.entries()
+
+	type match struct {
+		index, score int
+	}
+	var matches []match
+	for i, e := range p.list {
+		if score, ok := util.FuzzyMatch(query, e); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return utf8.RuneCountInString(p.list[matches[i].index]) < utf8.RuneCountInString(p.list[matches[j].index])
+	})
+	if len(matches) > maxPickerResults {
+		matches = matches[:maxPickerResults]
+	}
+
+	p.matches = p.matches[:0]
+	b.Suggestions = b.Suggestions[:0]
+	for _, m := range matches {
+		p.matches = append(p.matches, m.index)
+		b.Suggestions = append(b.Suggestions, p.list[m.index])
+	}
+	b.Completions = b.Suggestions
+	b.CurSuggestion = 0
+	b.HasSuggestions = len(matches) > 0
+}
+
+// move moves the selected match forward or backward
+func (p *picker) move(b *buffer.Buffer, forward bool) {
+	if len(p.matches) == 0 {
+		return
+	}
+	if forward {
+		b.CurSuggestion = (b.CurSuggestion + 1) % len(p.matches)
+	} else {
+		b.CurSuggestion = (b.CurSuggestion + len(p.matches) - 1) % len(p.matches)
+	}
+	b.HasSuggestions = true
+}
+
+// selected returns the selected entry or false if nothing matched
+func (p *picker) selected(b *buffer.Buffer) (string, bool) {
+	if len(p.matches) == 0 || b.CurSuggestion < 0 || b.CurSuggestion >= len(p.matches) {
+		return "", false
+	}
+	return p.list[p.matches[b.CurSuggestion]], true
+}
+
+// Pick prompts the user to choose one of a list of entries. The entries are
+// filtered with a fuzzy search as the user types, and the selected match can
+// be changed with the up and down arrows or tab. The callback receives the
+// chosen entry, or is canceled if the prompt was canceled or nothing matched
+func (h *InfoPane) Pick(prompt, ptype string, entries func() []string, donecb func(string, bool)) {
+	p := &picker{entries: entries}
+
+	h.Prompt(prompt, "", ptype, func(resp string) {
+		if resp != p.query {
+			p.filter(h.Buf, resp)
+		}
+	}, func(resp string, canceled bool) {
+		h.picker = nil
+		h.Buf.HasSuggestions = false
+		if canceled {
+			donecb("", true)
+			return
+		}
+		if choice, ok := p.selected(h.Buf); ok {
+			donecb(choice, false)
+		} else {
+			donecb("", true)
+		}
+	})
+	h.picker = p
+	p.filter(h.Buf, "")
+}
@@ -0,0 +1,107 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	shellquote "github.com/kballard/go-shellquote"
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/shell"
+)
+
+// runInterpreters are the interpreters used by runbuffer for the filetypes
+// when the runcmd option is not set
+var runInterpreters = map[string]string{
+	"javascript": "node",
+	"lua":        "lua",
+	"perl":       "perl",
+	"php":        "php",
+	"python":     "python3",
+	"python2":    "python2",
+	"ruby":       "ruby",
+	"shell":      "bash",
+	"tcl":        "tclsh",
+}
+
+// runCommand returns the shell command which runs the script at path with the
+// interpreter of its shebang line, of the runcmd option or of its filetype.
+// A %f in the runcmd option is replaced by the path, otherwise the path is
+// appended to it
+func runCommand(b *buffer.Buffer, path string) string {
+	quoted := shellquote.Join(path)
+	if line := b.Line(0); strings.HasPrefix(line, "#!") {
+		if args := strings.Fields(line[2:]); len(args) > 0 {
+			return shellquote.Join(args...) + " " + quoted
+		}
+	}
+	cmd := b.Settings["runcmd"].(string)
+	if cmd == "" {
+		cmd = runInterpreters[b.Settings["filetype"].(string)]
+	}
+	if cmd == "" {
+		return ""
+	}
+	if strings.Contains(cmd, "%f") {
+		return strings.Replace(cmd, "%f", quoted, -1)
+	}
+	return cmd + " " + quoted
+}
+
+// RunBufferCmd executes the buffer as a script and streams its output into a
+// split. The file of the buffer is run if it is saved, otherwise the text is
+// written to a temporary file
+func (h *BufPane) RunBufferCmd(args []string) {
+	b := h.Buf
+	path, temp := b.AbsPath, false
+	if b.Path == "" || b.Modified() {
+		f, err := ioutil.TempFile("", "micro-run-*"+filepath.Ext(b.GetName()))
+		if err == nil {
+			_, err = f.Write(b.Bytes())
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			InfoBar.Error("runbuffer: ", err)
+			return
+		}
+		path, temp = f.Name(), true
+	}
+
+	cmd := runCommand(b, path)
+	if cmd == "" {
+		if temp {
+			os.Remove(path)
+		}
+		InfoBar.Error("No interpreter for filetype ", b.Settings["filetype"], ", set the runcmd option")
+		return
+	}
+
+	outBuf := buffer.NewBufferFromString("", "", buffer.BTScratch)
+	outBuf.SetName("Run " + b.GetName())
+	out := h.HSplitBuf(outBuf)
+
+	write := func(output string, userargs []interface{}) {
+		outBuf.Insert(outBuf.End(), output)
+		out.Cursor.GotoLoc(outBuf.End())
+		out.Relocate()
+	}
+	var proc *exec.Cmd
+	proc = shell.JobSpawnIn(h.wd, "sh", []string{"-c", cmd}, write, write, func(output string, userargs []interface{}) {
+		if temp {
+			os.Remove(path)
+		}
+		status := "[Process exited]"
+		if state := proc.ProcessState; state == nil || !state.Success() {
+			status = "[Process failed]"
+		}
+		if outBuf.End().X > 0 {
+			status = "\n" + status
+		}
+		write(status+"\n", userargs)
+	})
+	InfoBar.Message("Running ", cmd)
+}
@@ -0,0 +1,156 @@
+package action
+
+import (
+	"strings"
+
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/config"
+	"github.com/zyedidia/micro/internal/util"
+)
+
+// An activeSnippet is a snippet which was expanded in a buffer and whose
+// tab stops are being filled in
+type activeSnippet struct {
+	buf *buffer.Buffer
+	// start is the location of the first char of the snippet, the ranges
+	// of the stops are rune offsets from it
+	start buffer.Loc
+	stops []config.SnippetStop
+	// cur is the index of the current stop
+	cur int
+}
+
+// loc returns the buffer location of an offset of the snippet
+func (s *activeSnippet) loc(offset int) buffer.Loc {
+	return s.start.Move(offset, s.buf)
+}
+
+// update accounts for the text typed in the placeholders of the current
+// stop, which the cursor c was editing, by moving all the ranges after them.
+// It returns false if the cursor left the placeholder
+func (s *activeSnippet) update(c *buffer.Cursor) bool {
+	ranges := s.stops[s.cur].Ranges
+	if len(ranges) == 0 || c.HasSelection() {
+		return true
+	}
+
+	newLen := s.start.Diff(s.start, c.Loc, s.buf) - ranges[0][0]
+	if c.Loc.LessThan(s.start) || newLen < 0 {
+		return false
+	}
+	delta := newLen - (ranges[0][1] - ranges[0][0])
+	if delta == 0 {
+		return true
+	}
+
+	// every placeholder of the stop got the same text, so shift the offsets
+	// after each of them starting from the last one
+	for i := len(ranges) - 1; i >= 0; i-- {
+		end := ranges[i][1]
+		for j := range s.stops {
+			for k := range s.stops[j].Ranges {
+				r := &s.stops[j].Ranges[k]
+				if r[0] >= end && !(j == s.cur && k == i) {
+					r[0] += delta
+				}
+				if r[1] >= end {
+					r[1] += delta
+				}
+			}
+		}
+	}
+	return true
+}
+
+// selectStop puts a cursor on every placeholder of the current stop,
+// selecting its text
+func (h *BufPane) selectStop() {
+	s := h.snippet
+	h.Buf.ClearCursors()
+	for i, r := range s.stops[s.cur].Ranges {
+		start, end := s.loc(r[0]), s.loc(r[1])
+
+		c := h.Buf.GetActiveCursor()
+		if i > 0 {
+			c = buffer.NewCursor(h.Buf, end)
+			h.Buf.AddCursor(c)
+		}
+		c.GotoLoc(end)
+		if start != end {
+			c.SetSelectionStart(start)
+			c.SetSelectionEnd(end)
+			c.OrigSelection[0] = c.CurSelection[0]
+			c.OrigSelection[1] = c.CurSelection[1]
+		}
+	}
+	h.Cursor = h.Buf.GetActiveCursor()
+	h.Relocate()
+}
+
+// ExpandSnippet replaces the word before the cursor with the snippet it
+// triggers and selects the first placeholder of the snippet
+func (h *BufPane) ExpandSnippet() bool {
+	if h.Cursor.HasSelection() {
+		return false
+	}
+	word, start := buffer.GetWord(h.Buf)
+	if start == -1 || len(word) == 0 {
+		return false
+	}
+	body, ok := config.GetSnippets(h.Buf.Settings["filetype"].(string))[string(word)]
+	if !ok {
+		return false
+	}
+
+	// the lines of the body are indented like the line of the trigger
+	indent := string(util.GetLeadingWhitespace(h.Buf.LineBytes(h.Cursor.Y)))
+	tab := h.Buf.IndentString(util.IntOpt(h.Buf.Settings["tabsize"]))
+	lines := strings.Split(body, "\n")
+	for i := range lines {
+		ws := util.GetLeadingWhitespace([]byte(lines[i]))
+		lines[i] = strings.Replace(string(ws), "\t", tab, -1) + lines[i][len(ws):]
+		if i > 0 {
+			lines[i] = indent + lines[i]
+		}
+	}
+	text, stops := config.ExpandSnippet(strings.Join(lines, "\n"))
+
+	loc := buffer.Loc{X: start, Y: h.Cursor.Y}
+	h.Buf.Replace(loc, h.Cursor.Loc, text)
+	h.snippet = &activeSnippet{buf: h.Buf, start: loc, stops: stops}
+	h.selectStop()
+	if len(stops) == 1 {
+		h.endSnippet()
+	}
+	return true
+}
+
+// NextSnippetStop moves to the next tab stop of the snippet being filled in
+func (h *BufPane) NextSnippetStop() bool {
+	s := h.snippet
+	if s == nil || s.buf != h.Buf {
+		h.snippet = nil
+		return false
+	}
+	if !s.update(h.Cursor) {
+		h.snippet = nil
+		return false
+	}
+
+	s.cur++
+	h.selectStop()
+	if s.cur == len(s.stops)-1 {
+		h.endSnippet()
+	}
+	return true
+}
+
+// endSnippet finishes the snippet at its final stop
+func (h *BufPane) endSnippet() {
+	h.Buf.ClearCursors()
+	h.Cursor = h.Buf.GetActiveCursor()
+	s := h.snippet
+	h.Cursor.GotoLoc(s.loc(s.stops[len(s.stops)-1].Ranges[0][1]))
+	h.snippet = nil
+	h.Relocate()
+}
@@ -1,10 +1,13 @@
 package action
 
 import (
+	"sort"
+
 	"github.com/zyedidia/micro/internal/buffer"
 	"github.com/zyedidia/micro/internal/config"
 	"github.com/zyedidia/micro/internal/display"
 	"github.com/zyedidia/micro/internal/screen"
+	"github.com/zyedidia/micro/internal/util"
 	"github.com/zyedidia/micro/internal/views"
 	"github.com/zyedidia/tcell"
 )
@@ -41,7 +44,11 @@ func NewTabList(bufs []*buffer.Buffer) *TabList {
 func (t *TabList) UpdateNames() {
 	t.Names = t.Names[:0]
 	for _, p := range t.List {
-		t.Names = append(t.Names, p.Panes[p.active].Name())
+		if p.pinned {
+			t.Names = append(t.Names, "^"+p.Name())
+		} else {
+			t.Names = append(t.Names, p.Name())
+		}
 	}
 }
 
@@ -72,6 +79,15 @@ func (t *TabList) RemoveTab(id uint64) {
 	}
 }
 
+// SetActive switches to the tab with the given index. The tab which was
+// shown is un-zoomed
+func (t *TabList) SetActive(a int) {
+	if cur := t.Active(); cur != a && cur < len(t.List) {
+		t.List[cur].SetZoomed(false)
+	}
+	t.TabWindow.SetActive(a)
+}
+
 // Resize resizes all elements within the tab list
 // One thing to note is that when there is only 1 tab
 // the tab bar should not be drawn so resizing must take
@@ -129,6 +145,59 @@ func (t *TabList) HandleEvent(event tcell.Event) {
 	t.List[t.Active()].HandleEvent(event)
 }
 
+// MoveTab moves the active tab by the given number of places in the list,
+// keeping it active
+func (t *TabList) MoveTab(n int) bool {
+	i := t.Active()
+	// pinned tabs stay left of the other tabs
+	if t.List[i].pinned {
+		n = util.Min(n, t.numPinned()-1-i)
+	} else {
+		n = util.Max(n, t.numPinned()-i)
+	}
+	j := util.Clamp(i+n, 0, len(t.List)-1)
+	if i == j {
+		return false
+	}
+	tab := t.List[i]
+	if j < i {
+		copy(t.List[j+1:i+1], t.List[j:i])
+	} else {
+		copy(t.List[i:j], t.List[i+1:j+1])
+	}
+	t.List[j] = tab
+	t.UpdateNames()
+	t.SetActive(j)
+	return true
+}
+
+// numPinned returns the number of pinned tabs, which are the first tabs of
+// the list
+func (t *TabList) numPinned() int {
+	n := 0
+	for _, tab := range t.List {
+		if tab.pinned {
+			n++
+		}
+	}
+	return n
+}
+
+// sortPinned moves the pinned tabs before the other tabs, keeping their
+// order and the active tab
+func (t *TabList) sortPinned() {
+	active := t.List[t.Active()]
+	sort.SliceStable(t.List, func(i, j int) bool {
+		return t.List[i].pinned && !t.List[j].pinned
+	})
+	t.UpdateNames()
+	for i, tab := range t.List {
+		if tab == active {
+			t.SetActive(i)
+		}
+	}
+}
+
 // Display updates the names and then displays the tab bar
 func (t *TabList) Display() {
 	t.UpdateNames()
@@ -158,6 +227,18 @@ type Tab struct {
 	Panes  []Pane
 	active int
 
+	// name is shown in the tab bar instead of the name of the active pane
+	// if it is set
+	name string
+
+	// pinned tabs are kept left of the other tabs and are not closed by
+	// the commands closing several tabs
+	pinned bool
+
+	// zoomed is set when the active pane fills the whole tab and the other
+	// panes are hidden. The split tree is kept to restore the layout
+	zoomed bool
+
 	resizing *views.Node // node currently being resized
 }
 
@@ -191,6 +272,10 @@ func NewTabFromPane(x, y, width, height int, pane Pane) *Tab {
 // If the event is a mouse event in a pane, that pane will become active and get
 // the event
 func (t *Tab) HandleEvent(event tcell.Event) {
+	if t.zoomed {
+		t.Panes[t.active].HandleEvent(event)
+		return
+	}
 	switch e := event.(type) {
 	case *tcell.EventMouse:
 		mx, my := e.Position()
@@ -241,6 +326,9 @@ func (t *Tab) HandleEvent(event tcell.Event) {
 
 // SetActive changes the currently active pane to the specified index
 func (t *Tab) SetActive(i int) {
+	if i != t.active {
+		t.SetZoomed(false)
+	}
 	t.active = i
 	for j, p := range t.Panes {
 		if j == i {
@@ -263,6 +351,7 @@ func (t *Tab) GetPane(splitid uint64) int {
 
 // Remove pane removes the pane with the given index
 func (t *Tab) RemovePane(i int) {
+	t.zoomed = false
 	copy(t.Panes[i:], t.Panes[i+1:])
 	t.Panes[len(t.Panes)-1] = nil
 	t.Panes = t.Panes[:len(t.Panes)-1]
@@ -281,13 +370,74 @@ func (t *Tab) Resize() {
 		p.SetView(pv)
 		p.Resize(n.W-offset, n.H)
 	}
+
+	if t.zoomed {
+		p := t.Panes[t.active]
+		pv := p.GetView()
+		pv.X, pv.Y = t.X, t.Y
+		p.SetView(pv)
+		p.Resize(t.W, t.H)
+	}
+}
+
+// Zoomed returns whether the active pane fills the whole tab
+func (t *Tab) Zoomed() bool {
+	return t.zoomed
+}
+
+// SetZoomed makes the active pane fill the whole tab, hiding the other panes,
+// or restores the layout of the splits
+func (t *Tab) SetZoomed(zoomed bool) {
+	if zoomed == t.zoomed {
+		return
+	}
+	t.zoomed = zoomed
+	t.Resize()
+}
+
+// VisiblePanes returns the panes which are shown, which is only the active
+// pane when the tab is zoomed
+func (t *Tab) VisiblePanes() []Pane {
+	if t.zoomed {
+		return t.Panes[t.active : t.active+1]
+	}
+	return t.Panes
+}
+
+// Display displays the borders between the splits, which are hidden when
+// the tab is zoomed
+func (t *Tab) Display() {
+	if !t.zoomed {
+		t.UIWindow.Display()
+	}
+}
+
+// Name returns the name of the tab shown in the tab bar
+func (t *Tab) Name() string {
+	if t.name != "" {
+		return t.name
+	}
+	return t.Panes[t.active].Name()
+}
+
+// SetName sets the name of the tab, an empty name restores the name of the
+// active pane
+func (t *Tab) SetName(name string) {
+	t.name = name
+}
+
+// Pinned returns whether the tab is pinned
+func (t *Tab) Pinned() bool {
+	return t.pinned
 }
 
 // CurPane returns the currently active pane
 func (t *Tab) CurPane() *BufPane {
-	p, ok := t.Panes[t.active].(*BufPane)
-	if !ok {
-		return nil
+	switch p := t.Panes[t.active].(type) {
+	case *BufPane:
+		return p
+	case *TreePane:
+		return p.BufPane
 	}
-	return p
+	return nil
 }
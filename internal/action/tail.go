@@ -0,0 +1,55 @@
+package action
+
+import (
+	"github.com/zyedidia/micro/internal/buffer"
+)
+
+// ToggleTail turns the tail mode of the buffer on and off. Like tail -f,
+// the lines added to the file are appended to the buffer, which is
+// read-only, and the view follows them while the end of the buffer is shown
+func (h *BufPane) ToggleTail() bool {
+	if h.Buf.Tailing() {
+		h.Buf.StopTail()
+		h.Buf.SetOptionNative("readonly", h.tailReadonly)
+		InfoBar.Message("Stopped following ", h.Buf.GetName())
+		return true
+	}
+	if h.Buf.Path == "" || h.Buf.Type.Kind != buffer.BTDefault.Kind {
+		InfoBar.Error("Cannot follow a buffer without a file")
+		return false
+	}
+	if h.Buf.Modified() {
+		InfoBar.Error("Save the buffer before following its file")
+		return false
+	}
+	if err := h.Buf.StartTail(); err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+	h.tailReadonly = h.Buf.Settings["readonly"].(bool)
+	h.Buf.SetOptionNative("readonly", true)
+	h.CursorEnd()
+	InfoBar.Message("Following ", h.Buf.GetName())
+	return true
+}
+
+// followTail appends the lines added to the followed file, and moves the
+// cursor to the end when it was on the last line and the view showed it.
+// Moving the cursor up or scrolling up pauses the following, which resumes
+// once the end is shown again
+func (h *BufPane) followTail() {
+	last := h.Buf.LinesNum() - 1
+	v := h.GetView()
+	follow := h.Cursor.Y == last && !h.Cursor.HasSelection() && last < v.StartLine+v.Height
+
+	changed, err := h.Buf.ReadTail()
+	if err != nil {
+		h.Buf.StopTail()
+		h.Buf.SetOptionNative("readonly", h.tailReadonly)
+		InfoBar.Error("Stopped following ", h.Buf.GetName(), ": ", err)
+		return
+	}
+	if changed && follow {
+		h.CursorEnd()
+	}
+}
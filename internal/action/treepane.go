@@ -0,0 +1,373 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	shellquote "github.com/kballard/go-shellquote"
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/util"
+	"github.com/zyedidia/tcell"
+)
+
+// treeWidth is the width of the split showing a file tree
+const treeWidth = 30
+
+// A treeEntry is the file or directory shown on a line of a file tree
+type treeEntry struct {
+	path  string
+	dir   bool
+	depth int
+}
+
+// A TreePane shows the files under a directory in a side split. Enter opens
+// the file of the line in the pane the tree was opened from, or expands or
+// collapses the directory of the line
+type TreePane struct {
+	*BufPane
+
+	root string
+	// the directories which are expanded
+	expanded map[string]bool
+	// the entries of the lines after the first one, which shows the root
+	entries []treeEntry
+	// the pane in which the files are opened
+	target *BufPane
+}
+
+// NewTreePane creates a pane showing the file tree of the root directory,
+// which opens the files in the target pane
+func NewTreePane(root string, target *BufPane, tab *Tab) *TreePane {
+	b := buffer.NewBufferFromString("", "", buffer.BTTree)
+	b.SetName(filepath.Base(root))
+	b.SetOptionNative("ruler", false)
+	b.SetOptionNative("softwrap", false)
+
+	t := &TreePane{
+		BufPane:  NewBufPaneFromBuf(b, tab),
+		root:     root,
+		expanded: map[string]bool{root: true},
+		target:   target,
+	}
+	t.refresh()
+	return t
+}
+
+// refresh lists the files of the tree again, keeping the line of the cursor
+func (t *TreePane) refresh() {
+	t.entries = t.entries[:0]
+	lines := []string{t.root + string(filepath.Separator)}
+	lines = t.list(t.root, 0, lines)
+
+	// the buffer is read-only for the user only
+	t.Buf.Type.Readonly = false
+	t.Buf.Replace(t.Buf.Start(), t.Buf.End(), strings.Join(lines, "\n"))
+	t.Buf.Type.Readonly = true
+	t.Buf.ClearHistory()
+	y := t.Cursor.Y
+	if y >= len(lines) {
+		y = len(lines) - 1
+	}
+	t.Cursor.GotoLoc(buffer.Loc{X: 0, Y: y})
+	t.Relocate()
+}
+
+// list appends the lines of the files of a directory, and of the expanded
+// directories under it, to lines
+func (t *TreePane) list(dir string, depth int, lines []string) []string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return lines
+	}
+	// directories first, ReadDir sorts by name
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].IsDir() && !files[j].IsDir()
+	})
+
+	indent := strings.Repeat("  ", depth)
+	for _, f := range files {
+		path := filepath.Join(dir, f.Name())
+		t.entries = append(t.entries, treeEntry{path, f.IsDir(), depth})
+		switch {
+		case !f.IsDir():
+			lines = append(lines, indent+"  "+f.Name())
+		case t.expanded[path]:
+			lines = append(lines, indent+"- "+f.Name()+"/")
+			lines = t.list(path, depth+1, lines)
+		default:
+			lines = append(lines, indent+"+ "+f.Name()+"/")
+		}
+	}
+	return lines
+}
+
+// entry returns the entry of the line of the cursor, or nil on the line of
+// the root
+func (t *TreePane) entry() *treeEntry {
+	i := t.Cursor.Y - 1
+	if i < 0 || i >= len(t.entries) {
+		return nil
+	}
+	return &t.entries[i]
+}
+
+// entryDir returns the directory of the entry of the cursor, or the entry
+// itself if it is a directory
+func (t *TreePane) entryDir() string {
+	e := t.entry()
+	if e == nil {
+		return t.root
+	}
+	if e.dir {
+		return e.path
+	}
+	return filepath.Dir(e.path)
+}
+
+// gotoEntry moves the cursor to the line of the given path
+func (t *TreePane) gotoEntry(path string) {
+	for i, e := range t.entries {
+		if e.path == path {
+			t.Cursor.GotoLoc(buffer.Loc{X: 0, Y: i + 1})
+			t.Relocate()
+			return
+		}
+	}
+}
+
+// HandleEvent handles the keys of the file tree and passes the other
+// events, like the movements of the cursor, to the buffer pane
+func (t *TreePane) HandleEvent(event tcell.Event) {
+	if e, ok := event.(*tcell.EventKey); ok {
+		switch e.Key() {
+		case tcell.KeyEnter:
+			t.activate()
+			return
+		case tcell.KeyRight:
+			if en := t.entry(); en != nil && en.dir && !t.expanded[en.path] {
+				t.expanded[en.path] = true
+				t.refresh()
+			} else {
+				t.CursorDown()
+			}
+			return
+		case tcell.KeyLeft:
+			if en := t.entry(); en != nil && en.dir && t.expanded[en.path] {
+				delete(t.expanded, en.path)
+				t.refresh()
+			} else if en != nil && en.depth > 0 {
+				t.gotoEntry(filepath.Dir(en.path))
+			}
+			return
+		case tcell.KeyEscape:
+			t.Quit()
+			return
+		case tcell.KeyRune:
+			if e.Modifiers()&tcell.ModAlt != 0 {
+				break
+			}
+			switch e.Rune() {
+			case 'a':
+				t.createEntry()
+			case 'r':
+				t.renameEntry()
+			case 'd':
+				t.deleteEntry()
+			case 'R':
+				t.refresh()
+			case 'q':
+				t.Quit()
+			}
+			return
+		}
+	}
+	t.BufPane.HandleEvent(event)
+}
+
+// activate opens the file of the cursor, or expands or collapses the
+// directory of the cursor
+func (t *TreePane) activate() {
+	e := t.entry()
+	if e == nil {
+		return
+	}
+	if e.dir {
+		t.expanded[e.path] = !t.expanded[e.path]
+		t.refresh()
+		return
+	}
+	t.openFile(e.path)
+}
+
+// openFile opens a file in the target pane, or in a new split if the target
+// pane was closed
+func (t *TreePane) openFile(path string) {
+	target := -1
+	for i, p := range t.tab.Panes {
+		if p == Pane(t.target) {
+			target = i
+		}
+	}
+	if target < 0 {
+		// use another pane of the tab instead
+		for i, p := range t.tab.Panes {
+			if bp, ok := p.(*BufPane); ok {
+				t.target, target = bp, i
+				break
+			}
+		}
+	}
+	if target < 0 {
+		b, err := buffer.NewBufferFromFile(path, buffer.BTDefault)
+		if err != nil {
+			InfoBar.Error(err)
+			return
+		}
+		t.target = t.VSplitIndex(b, true)
+		return
+	}
+
+	t.tab.SetActive(target)
+	if t.target.Buf.AbsPath != path {
+		t.target.OpenCmd([]string{shellquote.Join(path)})
+	}
+}
+
+// createEntry asks for the name of a new file, or of a new directory if the
+// name ends with a slash, and creates it in the directory of the cursor
+func (t *TreePane) createEntry() {
+	dir := t.entryDir()
+	InfoBar.Prompt("New file (end with / for a directory): ", "", "TreeNew", nil, func(resp string, canceled bool) {
+		if canceled || resp == "" {
+			return
+		}
+		path := filepath.Join(dir, resp)
+		var err error
+		if strings.HasSuffix(resp, "/") {
+			err = os.MkdirAll(path, os.ModePerm)
+		} else if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err == nil {
+			var f *os.File
+			if f, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666); err == nil {
+				f.Close()
+			}
+		}
+		if err != nil {
+			InfoBar.Error(err)
+			return
+		}
+		// expand the directories down to the new entry
+		for d := filepath.Dir(path); ; d = filepath.Dir(d) {
+			t.expanded[d] = true
+			if d == dir || d == filepath.Dir(d) {
+				break
+			}
+		}
+		t.refresh()
+		t.gotoEntry(path)
+	})
+}
+
+// renameEntry asks for a new name for the file or directory of the cursor
+func (t *TreePane) renameEntry() {
+	e := t.entry()
+	if e == nil {
+		return
+	}
+	old := e.path
+	InfoBar.Prompt("Rename to: ", filepath.Base(old), "TreeRename", nil, func(resp string, canceled bool) {
+		if canceled || resp == "" {
+			return
+		}
+		path := filepath.Join(filepath.Dir(old), resp)
+		if _, err := os.Stat(path); err == nil {
+			InfoBar.Error(path, " already exists")
+			return
+		}
+		if err := os.Rename(old, path); err != nil {
+			InfoBar.Error(err)
+			return
+		}
+		if t.expanded[old] {
+			delete(t.expanded, old)
+			t.expanded[path] = true
+		}
+		t.refresh()
+		t.gotoEntry(path)
+	})
+}
+
+// deleteEntry deletes the file or directory of the cursor after asking for
+// confirmation
+func (t *TreePane) deleteEntry() {
+	e := t.entry()
+	if e == nil {
+		return
+	}
+	path := e.path
+	msg := "Delete " + filepath.Base(path) + "? (y,n)"
+	if e.dir {
+		msg = "Delete the directory " + filepath.Base(path) + " and its contents? (y,n)"
+	}
+	InfoBar.YNPrompt(msg, func(yes, canceled bool) {
+		if !yes || canceled {
+			return
+		}
+		if err := os.RemoveAll(path); err != nil {
+			InfoBar.Error(err)
+		}
+		delete(t.expanded, path)
+		t.refresh()
+	})
+}
+
+// TreeCmd opens a file tree of the given directory, or of the working
+// directory, in a split on the left. The file tree already open in the tab
+// is reused
+func (h *BufPane) TreeCmd(args []string) {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	dir, err := util.ReplaceHome(dir)
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	root, err := filepath.Abs(h.resolvePath(dir))
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	if info, err := os.Stat(root); err != nil {
+		InfoBar.Error(err)
+		return
+	} else if !info.IsDir() {
+		InfoBar.Error(root, " is not a directory")
+		return
+	}
+
+	for i, p := range h.tab.Panes {
+		if t, ok := p.(*TreePane); ok {
+			t.root, t.target = root, h
+			t.expanded = map[string]bool{root: true}
+			t.Buf.SetName(filepath.Base(root))
+			t.refresh()
+			h.tab.SetActive(i)
+			return
+		}
+	}
+
+	t := NewTreePane(root, h, h.tab)
+	n := h.tab.GetNode(h.splitID)
+	t.splitID = n.VSplit(false)
+	h.tab.Panes = append(h.tab.Panes, t)
+	h.tab.Resize()
+	if tn := h.tab.GetNode(t.splitID); tn != nil && tn.W > treeWidth {
+		tn.ResizeSplitBy(treeWidth - tn.W)
+		h.tab.Resize()
+	}
+	h.tab.SetActive(len(h.tab.Panes) - 1)
+}
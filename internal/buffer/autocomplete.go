@@ -153,6 +153,74 @@ func FileComplete(b *Buffer) ([]string, []string) {
 	return completions, suggestions
 }
 
+// GetPath gets the path-like token before the cursor, which ends at
+// whitespace, quotes or brackets, and the char position where it starts
+func GetPath(b *Buffer) (string, int) {
+	c := b.GetActiveCursor()
+	l := []rune(string(b.LineBytes(c.Y)))
+	l = l[:util.Min(c.X, len(l))]
+
+	start := len(l)
+	for start > 0 && !util.IsWhitespace(l[start-1]) && !strings.ContainsRune("\"'`()[]{}<>,;=|", l[start-1]) {
+		start--
+	}
+	return string(l[start:]), start
+}
+
+// PathComplete autocompletes filesystem paths. It only suggests something
+// when the token before the cursor looks like a path: it contains a slash
+// or starts with '.' or '~'
+func PathComplete(b *Buffer) ([]string, []string) {
+	input, _ := GetPath(b)
+	sep := string(os.PathSeparator)
+	if !strings.Contains(input, "/") && !strings.Contains(input, sep) &&
+		!strings.HasPrefix(input, ".") && !strings.HasPrefix(input, "~") {
+		return nil, nil
+	}
+
+	dir, base := ".", input
+	if i := strings.LastIndexAny(input, "/"+sep); i != -1 {
+		dir, base = input[:i+1], input[i+1:]
+	} else if strings.HasPrefix(input, "~") {
+		return nil, nil
+	}
+	dir, err := util.ReplaceHome(dir)
+	if err != nil {
+		return nil, nil
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+
+	var suggestions []string
+	for _, f := range files {
+		name := f.Name()
+		if !strings.HasPrefix(name, base) || name == base {
+			continue
+		}
+		// hidden files are only suggested if the name starts with a dot
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		if f.IsDir() {
+			name += sep
+		}
+		suggestions = append(suggestions, name)
+	}
+	sort.Strings(suggestions)
+	if len(suggestions) > 1 {
+		suggestions = append(suggestions, base)
+	}
+
+	completions := make([]string, len(suggestions))
+	for i := range suggestions {
+		completions[i] = util.SliceEndStr(suggestions[i], utf8.RuneCountInString(base))
+	}
+	return completions, suggestions
+}
+
 // BufferComplete autocompletes based on previous words in the buffer
 func BufferComplete(b *Buffer) ([]string, []string) {
 	c := b.GetActiveCursor()
@@ -193,6 +261,23 @@ func BufferComplete(b *Buffer) ([]string, []string) {
 			}
 		}
 	}
+	if b.Settings["completeacrossbuffers"].(bool) {
+		// words of the other buffers come after the local ones
+		for _, ob := range OpenBuffers {
+			if ob.SharedBuffer == b.SharedBuffer || ob.Type == BTInfo {
+				continue
+			}
+			for _, w := range ob.words() {
+				if strings.HasPrefix(w, string(input)) && utf8.RuneCountInString(w) > inputLen {
+					if _, ok := suggestionsSet[w]; !ok {
+						suggestionsSet[w] = struct{}{}
+						suggestions = append(suggestions, w)
+					}
+				}
+			}
+		}
+	}
+
 	if len(suggestions) > 1 {
 		suggestions = append(suggestions, string(input))
 	}
@@ -204,3 +289,23 @@ func BufferComplete(b *Buffer) ([]string, []string) {
 
 	return completions, suggestions
 }
+
+// words returns the distinct words of the buffer. The list is cached until
+// the buffer is edited
+func (b *SharedBuffer) words() []string {
+	if b.wordCache != nil {
+		return b.wordCache
+	}
+
+	set := make(map[string]struct{})
+	b.wordCache = []string{}
+	for i := 0; i < b.LinesNum(); i++ {
+		for _, w := range bytes.FieldsFunc(b.LineBytes(i), util.IsNonAlphaNumeric) {
+			if _, ok := set[string(w)]; !ok {
+				set[string(w)] = struct{}{}
+				b.wordCache = append(b.wordCache, string(w))
+			}
+		}
+	}
+	return b.wordCache
+}
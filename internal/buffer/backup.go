@@ -27,6 +27,31 @@ The backup was created on %s, and the file is
 
 Options: [r]ecover, [i]gnore: `
 
+// BackupTimer receives a value every backupTime milliseconds, the main loop
+// then backs up the buffers edited since their last backup
+var BackupTimer = make(chan bool)
+
+// StartBackupTimer starts sending the ticks of BackupTimer
+func StartBackupTimer() {
+	go func() {
+		for {
+			time.Sleep(backupTime * time.Millisecond)
+			BackupTimer <- true
+		}
+	}()
+}
+
+// BackupRequested backs up all the open buffers which were edited since
+// their last backup
+func BackupRequested() {
+	for _, b := range OpenBuffers {
+		if b.backupRequested {
+			b.backupRequested = false
+			b.Backup(false)
+		}
+	}
+}
+
 // Backup saves the current buffer to ConfigDir/backups
 func (b *Buffer) Backup(checkTime bool) error {
 	if !b.Settings["backup"].(bool) || b.Path == "" || b.Type != BTDefault {
@@ -91,6 +116,10 @@ func (b *Buffer) ApplyBackup(fsize int64) bool {
 	if b.Settings["backup"].(bool) && len(b.Path) > 0 && b.Type == BTDefault {
 		backupfile := config.ConfigDir + "/backups/" + util.EscapePath(b.AbsPath)
 		if info, err := os.Stat(backupfile); err == nil {
+			// a backup older than the file holds no changes which weren't saved
+			if modTime, err := util.GetModTime(b.Path); err == nil && !info.ModTime().After(modTime) {
+				return false
+			}
 			backup, err := os.Open(backupfile)
 			if err == nil {
 				defer backup.Close()
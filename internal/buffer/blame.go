@@ -0,0 +1,140 @@
+package buffer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A BlameLine is the commit which last changed a line of a file
+type BlameLine struct {
+	Hash    string
+	Author  string
+	Summary string
+}
+
+// ParseBlame parses the output of git blame --porcelain and returns the
+// commit of every line of the file. Lines which are not committed yet have
+// no commit
+func ParseBlame(data []byte) []*BlameLine {
+	var lines []*BlameLine
+	commits := make(map[string]*BlameLine)
+	var cur *BlameLine
+	line := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case cur == nil && !isBlameHeader(text):
+			// every entry starts with a header line
+		case strings.HasPrefix(text, "\t"):
+			// the content of the line ends the entry
+			for len(lines) <= line {
+				lines = append(lines, nil)
+			}
+			if strings.Trim(cur.Hash, "0") != "" {
+				lines[line] = cur
+			}
+		case strings.HasPrefix(text, "author "):
+			cur.Author = text[len("author "):]
+		case strings.HasPrefix(text, "summary "):
+			cur.Summary = text[len("summary "):]
+		case isBlameHeader(text):
+			fields := strings.Fields(text)
+			n, _ := strconv.Atoi(fields[2])
+			line = n - 1
+			cur = commits[fields[0]]
+			if cur == nil {
+				cur = &BlameLine{Hash: fields[0]}
+				commits[fields[0]] = cur
+			}
+		}
+	}
+	return lines
+}
+
+// isBlameHeader returns whether a line of the porcelain format is the header
+// of an entry: the hash of the commit, the line number in the original file
+// and the line number in the final file
+func isBlameHeader(text string) bool {
+	fields := strings.Fields(text)
+	if len(fields) < 3 || len(fields[0]) != 40 {
+		return false
+	}
+	n, err := strconv.Atoi(fields[2])
+	return err == nil && n > 0
+}
+
+// ShowingBlame returns whether the git blame of the buffer is shown
+func (b *Buffer) ShowingBlame() bool {
+	return b.blame != nil
+}
+
+// LoadBlame runs git blame on the file of the buffer to show the commit of
+// every line
+func (b *Buffer) LoadBlame() error {
+	text, err := b.DiskText()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("git", "-C", filepath.Dir(b.AbsPath), "blame", "--porcelain", "--", filepath.Base(b.AbsPath))
+	out, err := cmd.Output()
+	if err != nil {
+		if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) > 0 {
+			return errors.New(strings.TrimSpace(string(e.Stderr)))
+		}
+		return err
+	}
+
+	b.blame = ParseBlame(out)
+	if b.blame == nil {
+		b.blame = []*BlameLine{}
+	}
+	b.blameText = text
+	b.blameUpToDate = false
+	return nil
+}
+
+// HideBlame stops showing the git blame of the buffer
+func (b *Buffer) HideBlame() {
+	b.blame = nil
+	b.blameLines = nil
+}
+
+// BlameAt returns the commit which last changed line y, or nil if the line
+// has uncommitted changes, including the unsaved edits of the buffer
+func (b *Buffer) BlameAt(y int) *BlameLine {
+	if b.blame == nil {
+		return nil
+	}
+	if !b.blameUpToDate {
+		// map the lines of the buffer to the lines of the file which
+		// was blamed, the lines of the hunks have no commit
+		b.blameUpToDate = true
+		b.blameLines = make([]*BlameLine, b.LinesNum())
+		y, base := 0, 0
+		for _, h := range DiffLines(b.blameText, string(b.Bytes())) {
+			for ; y < h.Start; y, base = y+1, base+1 {
+				if base < len(b.blame) {
+					b.blameLines[y] = b.blame[base]
+				}
+			}
+			y, base = h.End, h.BaseEnd
+		}
+		for ; y < len(b.blameLines); y, base = y+1, base+1 {
+			if base < len(b.blame) {
+				b.blameLines[y] = b.blame[base]
+			}
+		}
+	}
+	if y < 0 || y >= len(b.blameLines) {
+		return nil
+	}
+	return b.blameLines[y]
+}
@@ -0,0 +1,33 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBlame(t *testing.T) {
+	hash := "3a21aca0c0ffee00000000000000000000000001"
+	zero := "0000000000000000000000000000000000000000"
+	data := hash + ` 1 1 2
+author Jane Doe
+author-mail <jane@example.com>
+summary Add the first lines
+filename main.go
+	package main
+` + hash + ` 2 2
+	
+` + zero + ` 3 3 1
+author Not Committed Yet
+summary Version of main.go from main.go
+filename main.go
+	func main() {}
+`
+
+	lines := ParseBlame([]byte(data))
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "Jane Doe", lines[0].Author)
+	assert.Equal(t, "Add the first lines", lines[0].Summary)
+	assert.True(t, lines[0] == lines[1])
+	assert.Nil(t, lines[2])
+}
@@ -58,6 +58,8 @@ var (
 	BTRaw = BufType{4, false, true, false}
 	// BTInfo is a buffer for inputting information
 	BTInfo = BufType{5, false, true, false}
+	// BTTree is a buffer showing a file tree
+	BTTree = BufType{6, true, true, false}
 
 	// ErrFileTooLarge is returned when the file is too large to hash
 	// (fastdirty is automatically enabled)
@@ -70,6 +72,13 @@ type SharedBuffer struct {
 	*LineArray
 	// Stores the last modification time of the file the buffer is pointing to
 	ModTime time.Time
+	// watchModTime is the modification time seen by the previous check of
+	// the file watcher
+	watchModTime time.Time
+	// tailInfo is the file being followed by the tail mode and tailSize the
+	// part of it which was read
+	tailInfo os.FileInfo
+	tailSize int64
 	// Type of the buffer (e.g. help, raw, scratch etc..)
 	Type BufType
 
@@ -80,13 +89,54 @@ type SharedBuffer struct {
 
 	// Modifications is the list of modified regions for syntax highlighting
 	Modifications []Loc
+
+	// Messages are the gutter messages, they are shared so that they
+	// follow the lines they refer to when any view edits the text
+	Messages []*Message
+
+	// wordCache holds the words of the buffer for autocompletion, it is
+	// reset by every edit
+	wordCache []string
+
+	// diffBase is the text the diff gutter compares the buffer with, or nil
+	// if the diff gutter is disabled. The diff is computed again after an
+	// edit, when diffUpToDate is false
+	diffBase     *string
+	diffHunks    []DiffHunk
+	diffStatus   map[int]DiffStatus
+	diffUpToDate bool
+	// diffGitDir is the git directory the diff base was read from and
+	// diffGitStamp its state at that time
+	diffGitDir   string
+	diffGitStamp time.Time
+
+	// blame holds the commit of every line of the file when its git blame
+	// is shown, blameText the text which was blamed. blameLines maps the
+	// lines of the buffer to these commits, it is computed again after an
+	// edit
+	blame         []*BlameLine
+	blameText     string
+	blameLines    []*BlameLine
+	blameUpToDate bool
 }
 
 func (b *SharedBuffer) insert(pos Loc, value []byte) {
 	b.isModified = true
 	b.HasSuggestions = false
+	b.wordCache = nil
+	b.diffUpToDate = false
+	b.blameUpToDate = false
 	b.LineArray.insert(pos, value)
 
+	// a newline inserted at the start of a line pushes that line down too
+	if n := bytes.Count(value, []byte{'\n'}); n > 0 {
+		if pos.X == 0 {
+			b.shiftMessages(pos.Y, n)
+		} else {
+			b.shiftMessages(pos.Y+1, n)
+		}
+	}
+
 	// b.Modifications is cleared every screen redraw so it's
 	// ok to append duplicates
 	b.Modifications = append(b.Modifications, Loc{pos.Y, pos.Y + bytes.Count(value, []byte{'\n'})})
@@ -94,7 +144,13 @@ func (b *SharedBuffer) insert(pos Loc, value []byte) {
 func (b *SharedBuffer) remove(start, end Loc) []byte {
 	b.isModified = true
 	b.HasSuggestions = false
+	b.wordCache = nil
+	b.diffUpToDate = false
+	b.blameUpToDate = false
 	b.Modifications = append(b.Modifications, Loc{start.Y, start.Y})
+	if end.Y > start.Y {
+		b.shiftMessages(end.Y+1, start.Y-end.Y)
+	}
 	return b.LineArray.remove(start, end)
 }
 
@@ -137,11 +193,18 @@ type Buffer struct {
 	Completions   []string
 	CurSuggestion int
 
-	Messages []*Message
-
 	// counts the number of edits
 	// resets every backupTime edits
 	lastbackup time.Time
+	// whether the buffer was edited since its last backup
+	backupRequested bool
+	// whether an edit was refused because the buffer is read-only
+	editDenied bool
+	// the options which were set locally with setlocal, the ft and glob
+	// sections of settings.json do not override them
+	localOptions map[string]bool
+	// the options given by the ft and glob sections of settings.json
+	fileOptions map[string]bool
 }
 
 // NewBufferFromFile opens a new buffer using the given path
@@ -202,6 +265,13 @@ func NewBuffer(r io.Reader, size int64, path string, startcursor Loc, btype BufT
 			b.Settings[k] = v
 		}
 	}
+	var editorConfig map[string]interface{}
+	if len(path) > 0 {
+		editorConfig = config.EditorConfigSettings(absPath)
+		for k, v := range editorConfig {
+			b.Settings[k] = v
+		}
+	}
 	config.InitLocalSettings(b.Settings, path)
 
 	enc, err := htmlindex.Get(b.Settings["encoding"].(string))
@@ -251,9 +321,28 @@ func NewBuffer(r io.Reader, size int64, path string, startcursor Loc, btype BufT
 	case FFDos:
 		b.Settings["fileformat"] = "dos"
 	}
+	// the line endings required by .editorconfig are used when saving
+	if ff, ok := editorConfig["fileformat"]; ok {
+		b.Settings["fileformat"] = ff
+	}
 
 	b.UpdateRules()
 	config.InitLocalSettings(b.Settings, b.Path)
+	local, _ := config.FileSettings(b.Settings["filetype"].(string), b.Path)
+	b.fileOptions = make(map[string]bool)
+	for k := range local {
+		if k != "filetype" {
+			b.fileOptions[k] = true
+		}
+	}
+	if b.Settings["detectindent"].(bool) {
+		// the options given for the file take precedence
+		b.detectIndent(editorConfig, local)
+	}
+
+	if !found {
+		b.UpdateDiffBase()
+	}
 
 	if _, err := os.Stat(config.ConfigDir + "/buffers/"); os.IsNotExist(err) {
 		os.Mkdir(config.ConfigDir+"/buffers/", os.ModePerm)
@@ -262,7 +351,7 @@ func NewBuffer(r io.Reader, size int64, path string, startcursor Loc, btype BufT
 	if startcursor.X != -1 && startcursor.Y != -1 {
 		b.StartCursor = startcursor
 	} else {
-		if b.Settings["savecursor"].(bool) || b.Settings["saveundo"].(bool) {
+		if b.Settings["savecursor"].(bool) || b.Settings["persistentundo"].(bool) {
 			err := b.Unserialize()
 			if err != nil {
 				screen.TermMessage(err)
@@ -340,7 +429,9 @@ func (b *Buffer) Insert(start Loc, text string) {
 		b.EventHandler.active = b.curCursor
 		b.EventHandler.Insert(start, text)
 
-		go b.Backup(true)
+		b.backupRequested = true
+	} else {
+		b.editDenied = true
 	}
 }
 
@@ -351,10 +442,47 @@ func (b *Buffer) Remove(start, end Loc) {
 		b.EventHandler.active = b.curCursor
 		b.EventHandler.Remove(start, end)
 
-		go b.Backup(true)
+		b.backupRequested = true
+	} else {
+		b.editDenied = true
 	}
 }
 
+// Replace replaces the text between the start and end locations with the
+// given string
+func (b *Buffer) Replace(start, end Loc, text string) {
+	if !b.Type.Readonly {
+		b.EventHandler.cursors = b.cursors
+		b.EventHandler.active = b.curCursor
+		b.EventHandler.Replace(start, end, text)
+
+		b.backupRequested = true
+	} else {
+		b.editDenied = true
+	}
+}
+
+// MultipleReplace applies all the given replacements as a single event
+func (b *Buffer) MultipleReplace(deltas []Delta) {
+	if !b.Type.Readonly {
+		b.EventHandler.cursors = b.cursors
+		b.EventHandler.active = b.curCursor
+		b.EventHandler.MultipleReplace(deltas)
+
+		b.backupRequested = true
+	} else {
+		b.editDenied = true
+	}
+}
+
+// EditDenied returns whether an edit was refused because the buffer is
+// read-only since the last call
+func (b *Buffer) EditDenied() bool {
+	denied := b.editDenied
+	b.editDenied = false
+	return denied
+}
+
 // ClearModifications clears the list of modified lines in this buffer
 // The list of modified lines is used for syntax highlighting so that
 // we can selectively highlight only the necessary lines
@@ -406,6 +534,7 @@ func (b *Buffer) ReOpen() error {
 		return err
 	}
 	b.EventHandler.ApplyDiff(txt)
+	b.UpdateDiffBase()
 
 	err = b.UpdateModTime()
 	b.isModified = false
@@ -455,6 +584,15 @@ func (b *Buffer) Modified() bool {
 	return buff != b.origHash
 }
 
+// MarkClean makes the current text of the buffer the unmodified state, as
+// if it had just been saved
+func (b *Buffer) MarkClean() {
+	if !b.Settings["fastdirty"].(bool) {
+		calcHash(b, &b.origHash)
+	}
+	b.isModified = false
+}
+
 // calcHash calculates md5 hash of all lines in the buffer
 func calcHash(b *Buffer, out *[md5.Size]byte) error {
 	h := md5.New()
@@ -641,6 +779,15 @@ func (b *Buffer) ClearMatches() {
 	}
 }
 
+// TabWidth returns the number of columns a tab takes on the screen, which is
+// the tabdisplaywidth option, or tabsize if it is 0
+func (b *Buffer) TabWidth() int {
+	if w := util.IntOpt(b.Settings["tabdisplaywidth"]); w > 0 {
+		return w
+	}
+	return util.IntOpt(b.Settings["tabsize"])
+}
+
 // IndentString returns this buffer's indent method (a tabstop or n spaces
 // depending on the settings)
 func (b *Buffer) IndentString(tabsize int) string {
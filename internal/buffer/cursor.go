@@ -1,6 +1,7 @@
 package buffer
 
 import (
+	"strings"
 	"unicode/utf8"
 
 	"github.com/zyedidia/clipboard"
@@ -25,6 +26,10 @@ type Cursor struct {
 	// Last cursor x position
 	LastVisualX int
 
+	// Number of columns the cursor is past the end of its line, which
+	// is only possible with the virtualedit option
+	Virtual int
+
 	// The current selection as a range of character numbers (inclusive)
 	CurSelection [2]Loc
 	// The original selection as a range of character numbers
@@ -56,7 +61,7 @@ func (c *Cursor) Buf() *Buffer {
 // Goto puts the cursor at the given cursor's location and gives
 // the current cursor its selection too
 func (c *Cursor) Goto(b Cursor) {
-	c.X, c.Y, c.LastVisualX = b.X, b.Y, b.LastVisualX
+	c.X, c.Y, c.LastVisualX, c.Virtual = b.X, b.Y, b.LastVisualX, b.Virtual
 	c.OrigSelection, c.CurSelection = b.OrigSelection, b.CurSelection
 }
 
@@ -64,36 +69,43 @@ func (c *Cursor) Goto(b Cursor) {
 // the current cursor its selection too
 func (c *Cursor) GotoLoc(l Loc) {
 	c.X, c.Y = l.X, l.Y
+	c.Virtual = 0
 	c.StoreVisualX()
 }
 
-// GetVisualX returns the x value of the cursor in visual spaces
+// GetVisualX returns the x value of the cursor in visual spaces,
+// including the virtual columns past the end of the line
 func (c *Cursor) GetVisualX() int {
+	bytes := c.buf.LineBytes(c.Y)
+	virtual := 0
+	if c.X >= utf8.RuneCount(bytes) {
+		virtual = c.Virtual
+	}
 	if c.X <= 0 {
 		c.X = 0
-		return 0
+		return virtual
 	}
 
-	bytes := c.buf.LineBytes(c.Y)
-	tabsize := int(c.buf.Settings["tabsize"].(float64))
+	tabsize := c.buf.TabWidth()
 	if c.X > utf8.RuneCount(bytes) {
 		c.X = utf8.RuneCount(bytes) - 1
 	}
 
-	return util.StringWidth(bytes, c.X, tabsize)
+	return util.StringWidth(bytes, c.X, tabsize) + virtual
 }
 
 // GetCharPosInLine gets the char position of a visual x y
 // coordinate (this is necessary because tabs are 1 char but
 // 4 visual spaces)
 func (c *Cursor) GetCharPosInLine(b []byte, visualPos int) int {
-	tabsize := int(c.buf.Settings["tabsize"].(float64))
+	tabsize := c.buf.TabWidth()
 	return util.GetCharPosInLine(b, visualPos, tabsize)
 }
 
 // Start moves the cursor to the start of the line it is on
 func (c *Cursor) Start() {
 	c.X = 0
+	c.Virtual = 0
 	c.LastVisualX = c.GetVisualX()
 }
 
@@ -109,9 +121,20 @@ func (c *Cursor) StartOfText() {
 	}
 }
 
+// StartOfTextToggle moves the cursor to the first non-whitespace rune of
+// the line it is on, or to the start of the line if it is already there
+func (c *Cursor) StartOfTextToggle() {
+	x := c.X
+	c.StartOfText()
+	if c.X == x {
+		c.Start()
+	}
+}
+
 // End moves the cursor to the end of the line it is on
 func (c *Cursor) End() {
 	c.X = utf8.RuneCount(c.buf.LineBytes(c.Y))
+	c.Virtual = 0
 	c.LastVisualX = c.GetVisualX()
 }
 
@@ -217,20 +240,49 @@ func (c *Cursor) AddLineToSelection() {
 	}
 }
 
+// SelectParagraph selects the lines around the cursor up to the empty
+// lines before and after them, or the line of the cursor if it is empty
+func (c *Cursor) SelectParagraph() {
+	if util.IsBytesWhitespace(c.buf.LineBytes(c.Y)) {
+		c.SelectLine()
+		return
+	}
+
+	start, end := c.Y, c.Y
+	for start > 0 && !util.IsBytesWhitespace(c.buf.LineBytes(start-1)) {
+		start--
+	}
+	for end < len(c.buf.lines)-1 && !util.IsBytesWhitespace(c.buf.LineBytes(end+1)) {
+		end++
+	}
+
+	c.Y = start
+	c.Start()
+	c.SetSelectionStart(c.Loc)
+	c.Y = end
+	c.End()
+	if len(c.buf.lines)-1 > c.Y {
+		c.SetSelectionEnd(c.Loc.Move(1, c.buf))
+	} else {
+		c.SetSelectionEnd(c.Loc)
+	}
+
+	c.OrigSelection = c.CurSelection
+}
+
 // UpN moves the cursor up N lines (if possible)
 func (c *Cursor) UpN(amount int) {
-	proposedY := c.Y - amount
-	if proposedY < 0 {
-		proposedY = 0
-	} else if proposedY >= len(c.buf.lines) {
-		proposedY = len(c.buf.lines) - 1
-	}
+	proposedY := c.buf.MoveLines(c.Y, -amount)
 
 	bytes := c.buf.LineBytes(proposedY)
 	c.X = c.GetCharPosInLine(bytes, c.LastVisualX)
+	c.Virtual = 0
 
 	if c.X > utf8.RuneCount(bytes) || (amount < 0 && proposedY == c.Y) {
 		c.X = utf8.RuneCount(bytes)
+	} else if c.X == utf8.RuneCount(bytes) && c.buf.Settings["virtualedit"].(bool) {
+		// keep the column past the end of a shorter line
+		c.Virtual = util.Max(c.LastVisualX-util.StringWidth(bytes, c.X, c.buf.TabWidth()), 0)
 	}
 
 	c.Y = proposedY
@@ -254,6 +306,7 @@ func (c *Cursor) Down() {
 // Left moves the cursor left one cell (if possible) or to
 // the previous line if it is at the beginning
 func (c *Cursor) Left() {
+	c.Virtual = 0
 	if c.Loc == c.buf.Start() {
 		return
 	}
@@ -269,6 +322,7 @@ func (c *Cursor) Left() {
 // Right moves the cursor right one cell (if possible) or
 // to the next line if it is at the end
 func (c *Cursor) Right() {
+	c.Virtual = 0
 	if c.Loc == c.buf.End() {
 		return
 	}
@@ -296,6 +350,16 @@ func (c *Cursor) Relocate() {
 	} else if c.X > utf8.RuneCount(c.buf.LineBytes(c.Y)) {
 		c.X = utf8.RuneCount(c.buf.LineBytes(c.Y))
 	}
+	if c.X < utf8.RuneCount(c.buf.LineBytes(c.Y)) {
+		c.Virtual = 0
+	}
+}
+
+// isWordChar returns whether r is a word character for selecting words
+// and moving by words, which includes the runes of the selectwordchars
+// option
+func (c *Cursor) isWordChar(r rune) bool {
+	return util.IsWordChar(r) || strings.ContainsRune(c.buf.Settings["selectwordchars"].(string), r)
 }
 
 // SelectWord selects the word the cursor is currently on
@@ -304,7 +368,7 @@ func (c *Cursor) SelectWord() {
 		return
 	}
 
-	if !util.IsWordChar(c.RuneUnder(c.X)) {
+	if !c.isWordChar(c.RuneUnder(c.X)) {
 		c.SetSelectionStart(c.Loc)
 		c.SetSelectionEnd(c.Loc.Move(1, c.buf))
 		c.OrigSelection = c.CurSelection
@@ -313,7 +377,7 @@ func (c *Cursor) SelectWord() {
 
 	forward, backward := c.X, c.X
 
-	for backward > 0 && util.IsWordChar(c.RuneUnder(backward-1)) {
+	for backward > 0 && c.isWordChar(c.RuneUnder(backward-1)) {
 		backward--
 	}
 
@@ -321,7 +385,7 @@ func (c *Cursor) SelectWord() {
 	c.OrigSelection[0] = c.CurSelection[0]
 
 	lineLen := utf8.RuneCount(c.buf.LineBytes(c.Y)) - 1
-	for forward < lineLen && util.IsWordChar(c.RuneUnder(forward+1)) {
+	for forward < lineLen && c.isWordChar(c.RuneUnder(forward+1)) {
 		forward++
 	}
 
@@ -341,7 +405,7 @@ func (c *Cursor) AddWordToSelection() {
 	if c.Loc.LessThan(c.OrigSelection[0]) {
 		backward := c.X
 
-		for backward > 0 && util.IsWordChar(c.RuneUnder(backward-1)) {
+		for backward > 0 && c.isWordChar(c.RuneUnder(backward-1)) {
 			backward--
 		}
 
@@ -353,7 +417,7 @@ func (c *Cursor) AddWordToSelection() {
 		forward := c.X
 
 		lineLen := utf8.RuneCount(c.buf.LineBytes(c.Y)) - 1
-		for forward < lineLen && util.IsWordChar(c.RuneUnder(forward+1)) {
+		for forward < lineLen && c.isWordChar(c.RuneUnder(forward+1)) {
 			forward++
 		}
 
@@ -364,6 +428,32 @@ func (c *Cursor) AddWordToSelection() {
 	c.Loc = c.CurSelection[1]
 }
 
+// TrimSelection shrinks the selection so that it does not start or end
+// with whitespace. It returns false if the selection is only whitespace
+func (c *Cursor) TrimSelection() bool {
+	sel := []rune(string(c.GetSelection()))
+	start, end := 0, len(sel)
+	for start < end && util.IsWhitespace(sel[start]) {
+		start++
+	}
+	for end > start && util.IsWhitespace(sel[end-1]) {
+		end--
+	}
+	if start == end {
+		return false
+	}
+
+	a, b := c.CurSelection[0], c.CurSelection[1]
+	if a.GreaterThan(b) {
+		a, b = b, a
+	}
+	c.SetSelectionStart(a.Move(start, c.buf))
+	c.SetSelectionEnd(b.Move(end-len(sel), c.buf))
+	c.OrigSelection = c.CurSelection
+	c.Loc = c.CurSelection[1]
+	return true
+}
+
 // SelectTo selects from the current cursor location to the given
 // location
 func (c *Cursor) SelectTo(loc Loc) {
@@ -386,7 +476,7 @@ func (c *Cursor) WordRight() {
 		c.Right()
 	}
 	c.Right()
-	for util.IsWordChar(c.RuneUnder(c.X)) {
+	for c.isWordChar(c.RuneUnder(c.X)) {
 		if c.X == utf8.RuneCount(c.buf.LineBytes(c.Y)) {
 			return
 		}
@@ -404,7 +494,7 @@ func (c *Cursor) WordLeft() {
 		c.Left()
 	}
 	c.Left()
-	for util.IsWordChar(c.RuneUnder(c.X)) {
+	for c.isWordChar(c.RuneUnder(c.X)) {
 		if c.X == 0 {
 			return
 		}
@@ -435,6 +525,11 @@ func (c *Cursor) RuneUnder(x int) rune {
 	return '\n'
 }
 
+// StoreVisualX stores the current visual x value in the cursor. The virtual
+// columns are dropped if the cursor was moved inside its line
 func (c *Cursor) StoreVisualX() {
+	if c.X < utf8.RuneCount(c.buf.LineBytes(c.Y)) {
+		c.Virtual = 0
+	}
 	c.LastVisualX = c.GetVisualX()
 }
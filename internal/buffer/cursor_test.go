@@ -0,0 +1,31 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/zyedidia/micro/internal/config"
+	ulua "github.com/zyedidia/micro/internal/lua"
+)
+
+func TestVirtualColumns(t *testing.T) {
+	ulua.L = lua.NewState()
+	config.InitGlobalSettings()
+	b := NewBufferFromString("abc\nabcdef", "", BTDefault)
+	b.SetOptionNative("virtualedit", true)
+
+	c := b.GetActiveCursor()
+	c.GotoLoc(Loc{X: 6, Y: 1})
+	c.Up()
+	assert.Equal(t, Loc{X: 3, Y: 0}, c.Loc)
+	assert.Equal(t, 3, c.Virtual)
+	assert.Equal(t, 6, c.GetVisualX())
+
+	// reading the visual x of a cursor moved inside its line keeps it
+	c.X = 1
+	assert.Equal(t, 1, c.GetVisualX())
+	assert.Equal(t, 3, c.Virtual)
+	c.StoreVisualX()
+	assert.Equal(t, 0, c.Virtual)
+}
@@ -0,0 +1,388 @@
+package buffer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/zyedidia/micro/internal/util"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// diffContext is the number of unchanged lines shown around the changes of
+// a unified diff
+const diffContext = 3
+
+// A DiffHunk is a run of consecutive lines which differ between a base text
+// and a new text. Start and End delimit the lines of the new text, BaseStart
+// and BaseEnd the lines of the base text they replace. The lines are counted
+// from 0 and the ends are exclusive, so a hunk which only deletes lines has
+// Start == End
+type DiffHunk struct {
+	Start, End         int
+	BaseStart, BaseEnd int
+}
+
+// DiffStatus is the state of a line of the buffer compared with the diff base
+type DiffStatus int
+
+const (
+	DSUnchanged DiffStatus = iota
+	DSAdded
+	DSModified
+	// lines of the base were deleted above or below the line
+	DSDeletedAbove
+	DSDeletedBelow
+)
+
+// splitLines splits a text into lines, ignoring the empty line after a
+// final newline
+func splitLines(text string) []string {
+	lines := strings.Split(text, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// DiffLines compares the lines of base and text and returns the hunks of
+// changed lines
+func DiffLines(base, text string) []DiffHunk {
+	return diffLines(splitLines(base), splitLines(text))
+}
+
+func diffLines(base, text []string) []DiffHunk {
+	// each distinct line is replaced by a rune so that the lines can be
+	// diffed like characters
+	ids := make(map[string]rune)
+	runes := func(lines []string) []rune {
+		r := make([]rune, len(lines))
+		for i, l := range lines {
+			id, ok := ids[l]
+			if !ok {
+				id = rune(len(ids) + 1)
+				ids[l] = id
+			}
+			r[i] = id
+		}
+		return r
+	}
+	differ := dmp.New()
+	diffs := differ.DiffMainRunes(runes(base), runes(text), false)
+
+	var hunks []DiffHunk
+	var cur *DiffHunk
+	a, b := 0, 0
+	for _, d := range diffs {
+		n := len([]rune(d.Text))
+		if d.Type == dmp.DiffEqual {
+			cur = nil
+			a += n
+			b += n
+			continue
+		}
+		if cur == nil {
+			hunks = append(hunks, DiffHunk{b, b, a, a})
+			cur = &hunks[len(hunks)-1]
+		}
+		if d.Type == dmp.DiffDelete {
+			a += n
+			cur.BaseEnd = a
+		} else {
+			b += n
+			cur.End = b
+		}
+	}
+	return hunks
+}
+
+// UnifiedDiff returns the unified diff between base and text, labelling
+// the files with the given name. It returns an empty string if the texts
+// have the same lines
+func UnifiedDiff(name, base, text string) string {
+	baseLines, lines := splitLines(base), splitLines(text)
+	hunks := diffLines(baseLines, lines)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	writeDiffHeader(&out, name)
+	for i := 0; i < len(hunks); {
+		// group the hunks whose context lines overlap
+		j := i
+		for j+1 < len(hunks) && hunks[j+1].Start-hunks[j].End <= 2*diffContext {
+			j++
+		}
+		first, last := hunks[i], hunks[j]
+		baseStart := util.Max(first.BaseStart-diffContext, 0)
+		baseEnd := util.Min(last.BaseEnd+diffContext, len(baseLines))
+		start := util.Max(first.Start-diffContext, 0)
+		end := util.Min(last.End+diffContext, len(lines))
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(baseStart, baseEnd), hunkRange(start, end))
+		b := start
+		for k := i; k <= j; k++ {
+			h := hunks[k]
+			for ; b < h.Start; b++ {
+				out.WriteString(" " + lines[b] + "\n")
+			}
+			for _, l := range baseLines[h.BaseStart:h.BaseEnd] {
+				out.WriteString("-" + l + "\n")
+			}
+			for ; b < h.End; b++ {
+				out.WriteString("+" + lines[b] + "\n")
+			}
+		}
+		for ; b < end; b++ {
+			out.WriteString(" " + lines[b] + "\n")
+		}
+		i = j + 1
+	}
+	return out.String()
+}
+
+// HunkPatch returns a patch which applies only the given hunk of the diff
+// between base and text to base. Its context lines are taken from base, so
+// that the other changes of text don't prevent it from applying. With crlf
+// the lines of the patch end with CRLF, like the lines of the file it applies
+// to, and a missing newline at the end of either text is marked as git does
+func HunkPatch(name, base, text string, hunk DiffHunk, crlf bool) string {
+	baseLines, lines := splitLines(base), splitLines(text)
+	start := util.Max(hunk.BaseStart-diffContext, 0)
+	end := util.Min(hunk.BaseEnd+diffContext, len(baseLines))
+	newEnd := end - (hunk.BaseEnd - hunk.BaseStart) + (hunk.End - hunk.Start)
+
+	before := baseLines[start:hunk.BaseStart]
+	deleted := baseLines[hunk.BaseStart:hunk.BaseEnd]
+	added := lines[hunk.Start:hunk.End]
+	after := baseLines[hunk.BaseEnd:end]
+
+	// the last line of the patch is the last line of the file on the side
+	// where it is missing a newline
+	baseNoEOL := end == len(baseLines) && noFinalNewline(base)
+	newNoEOL := baseNoEOL
+	if hunk.BaseEnd == len(baseLines) {
+		newNoEOL = noFinalNewline(text)
+	}
+	if len(after) == 0 && len(before) > 0 &&
+		(len(deleted) == 0 && baseNoEOL || len(added) == 0 && newNoEOL) {
+		// the last context line ends only one of the sides, so it is
+		// deleted and added again to mark the missing newline on one side
+		last := before[len(before)-1]
+		before = before[:len(before)-1]
+		deleted = append([]string{last}, deleted...)
+		added = append([]string{last}, added...)
+	}
+
+	eol := "\n"
+	if crlf {
+		eol = "\r\n"
+	}
+	var out strings.Builder
+	writeLines := func(prefix string, ls []string, noEOL bool) {
+		for _, l := range ls {
+			out.WriteString(prefix + l + eol)
+		}
+		if noEOL && len(ls) > 0 {
+			out.WriteString("\\ No newline at end of file\n")
+		}
+	}
+	writeDiffHeader(&out, name)
+	fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(start, end), hunkRange(start, newEnd))
+	writeLines(" ", before, false)
+	writeLines("-", deleted, len(after) == 0 && baseNoEOL)
+	writeLines("+", added, len(after) == 0 && newNoEOL)
+	writeLines(" ", after, baseNoEOL)
+	return out.String()
+}
+
+// noFinalNewline returns whether the last line of a non-empty text has no
+// newline
+func noFinalNewline(text string) bool {
+	return text != "" && !strings.HasSuffix(text, "\n")
+}
+
+// BaseLines returns the lines of base which the hunk replaces
+func (h DiffHunk) BaseLines(base string) []string {
+	return splitLines(base)[h.BaseStart:h.BaseEnd]
+}
+
+func writeDiffHeader(out *strings.Builder, name string) {
+	fmt.Fprintf(out, "--- a/%s\n+++ b/%s\n", name, name)
+}
+
+// hunkRange formats the range of lines [start, end) for the header of a
+// hunk of a unified diff
+func hunkRange(start, end int) string {
+	if end == start {
+		// an empty range refers to the line before it
+		return fmt.Sprintf("%d,0", start)
+	}
+	if end-start == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, end-start)
+}
+
+// decodeText decodes the content of the file of the buffer with its
+// encoding and converts the line endings to unix ones
+func (b *Buffer) decodeText(data []byte) (string, error) {
+	enc, err := htmlindex.Get(b.Settings["encoding"].(string))
+	if err != nil {
+		return "", err
+	}
+	data, err = enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", err
+	}
+	return strings.Replace(string(data), "\r\n", "\n", -1), nil
+}
+
+// DiskText returns the text of the file of the buffer on disk, or an empty
+// string if the file doesn't exist
+func (b *Buffer) DiskText() (string, error) {
+	data, err := ioutil.ReadFile(b.AbsPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return b.decodeText(data)
+}
+
+// GitText returns the text of the file of the buffer in the given revision
+// of its git repository, or in the index if rev is empty, along with the git
+// directory of the repository
+func (b *Buffer) GitText(rev string) (string, string, error) {
+	data, gitDir, err := b.gitShow(rev)
+	if err != nil {
+		return "", "", err
+	}
+	text, err := b.decodeText(data)
+	return text, gitDir, err
+}
+
+// GitIndexText returns the text of the file of the buffer in the index of its
+// git repository, and whether its lines end with CRLF there
+func (b *Buffer) GitIndexText() (string, bool, error) {
+	data, _, err := b.gitShow("")
+	if err != nil {
+		return "", false, err
+	}
+	text, err := b.decodeText(data)
+	return text, bytes.Contains(data, []byte("\r\n")), err
+}
+
+// gitShow returns the content of the file of the buffer in the given revision
+// of its git repository and the git directory of the repository
+func (b *Buffer) gitShow(rev string) ([]byte, string, error) {
+	dir := filepath.Dir(b.AbsPath)
+	_, gitDir, err := util.GitRepo(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := exec.Command("git", "-C", dir, "show", rev+":./"+filepath.Base(b.AbsPath)).Output()
+	if err != nil {
+		return nil, "", err
+	}
+	return data, gitDir, nil
+}
+
+// gitStamp returns the last modification time of the files of a git
+// directory which change on commits, checkouts and resets
+func gitStamp(gitDir string) time.Time {
+	var stamp time.Time
+	for _, name := range []string{"HEAD", "index"} {
+		if t, err := util.GetModTime(filepath.Join(gitDir, name)); err == nil && t.After(stamp) {
+			stamp = t
+		}
+	}
+	return stamp
+}
+
+// UpdateDiffBase reads the text the diff gutter compares the buffer with.
+// Depending on the diffgutterbase option, it is the file on disk or its
+// version in the HEAD commit of its git repository, falling back to the
+// file on disk if the file isn't tracked
+func (b *Buffer) UpdateDiffBase() {
+	b.diffBase = nil
+	b.diffGitDir = ""
+	b.diffUpToDate = false
+	if !b.Settings["diffgutter"].(bool) || b.Path == "" || b.Type != BTDefault {
+		return
+	}
+
+	if b.Settings["diffgutterbase"].(string) == "git" {
+		if text, gitDir, err := b.GitText("HEAD"); err == nil {
+			b.diffBase = &text
+			b.diffGitDir = gitDir
+			b.diffGitStamp = gitStamp(gitDir)
+			return
+		}
+	}
+	if text, err := b.DiskText(); err == nil {
+		b.diffBase = &text
+	}
+}
+
+// RefreshDiffBases reads again the diff bases of the open buffers whose git
+// repository changed, after a commit or a checkout for example
+func RefreshDiffBases() {
+	for _, b := range OpenBuffers {
+		if b.diffGitDir != "" && !gitStamp(b.diffGitDir).Equal(b.diffGitStamp) {
+			b.UpdateDiffBase()
+		}
+	}
+}
+
+// updateDiff computes the diff between the diff base and the buffer if it
+// was edited since the last time
+func (b *Buffer) updateDiff() {
+	if b.diffUpToDate {
+		return
+	}
+	b.diffUpToDate = true
+	b.diffHunks, b.diffStatus = nil, nil
+	if b.diffBase == nil {
+		return
+	}
+
+	b.diffHunks = DiffLines(*b.diffBase, string(b.Bytes()))
+	b.diffStatus = make(map[int]DiffStatus)
+	for _, h := range b.diffHunks {
+		switch {
+		case h.Start == h.End && h.Start >= b.LinesNum():
+			b.diffStatus[b.LinesNum()-1] = DSDeletedBelow
+		case h.Start == h.End:
+			b.diffStatus[h.Start] = DSDeletedAbove
+		case h.BaseStart == h.BaseEnd:
+			for y := h.Start; y < h.End; y++ {
+				b.diffStatus[y] = DSAdded
+			}
+		default:
+			for y := h.Start; y < h.End; y++ {
+				b.diffStatus[y] = DSModified
+			}
+		}
+	}
+}
+
+// DiffHunks returns the hunks of changes between the diff base and the
+// buffer, or nil if the diff gutter is disabled
+func (b *Buffer) DiffHunks() []DiffHunk {
+	b.updateDiff()
+	return b.diffHunks
+}
+
+// DiffStatus returns the state of line y compared with the diff base
+func (b *Buffer) DiffStatus(y int) DiffStatus {
+	b.updateDiff()
+	return b.diffStatus[y]
+}
@@ -0,0 +1,85 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+	text := "a\nB\nc\ne\nf\n"
+
+	assert.Equal(t, []DiffHunk{
+		{Start: 1, End: 2, BaseStart: 1, BaseEnd: 2},
+		{Start: 3, End: 3, BaseStart: 3, BaseEnd: 4},
+		{Start: 4, End: 5, BaseStart: 5, BaseEnd: 5},
+	}, DiffLines(base, text))
+	assert.Empty(t, DiffLines(base, base))
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	base := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	text := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+
+	assert.Equal(t, `--- a/f
++++ b/f
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`, UnifiedDiff("f", base, text))
+	assert.Equal(t, "", UnifiedDiff("f", base, base))
+}
+
+func TestHunkPatch(t *testing.T) {
+	base := "1\n2\n3\n4\n5\n6\n7\n8\n"
+	text := "1\ntwo\n3\n4\n5\nsix\n7\n8\n"
+	hunks := DiffLines(base, text)
+	assert.Equal(t, 2, len(hunks))
+
+	// the context is taken from base, without the other change
+	assert.Equal(t, `--- a/f
++++ b/f
+@@ -3,6 +3,6 @@
+ 3
+ 4
+ 5
+-6
++six
+ 7
+ 8
+`, HunkPatch("f", base, text, hunks[1], false))
+	assert.Equal(t, []string{"2"}, hunks[0].BaseLines(base))
+}
+
+func TestHunkPatchNoFinalNewline(t *testing.T) {
+	tests := []struct {
+		base, text, patch string
+	}{
+		{"1\n2\n3", "1\n2\nthree", "@@ -1,3 +1,3 @@\n 1\n 2\n-3\n\\ No newline at end of file\n+three\n\\ No newline at end of file\n"},
+		{"1\n2", "1\n2\n3\n", "@@ -1,2 +1,3 @@\n 1\n-2\n\\ No newline at end of file\n+2\n+3\n"},
+		{"a\nb\n", "a", "@@ -1,2 +1 @@\n-a\n-b\n+a\n\\ No newline at end of file\n"},
+		{"1\n2\n3", "one\n2\n3", "@@ -1,3 +1,3 @@\n-1\n+one\n 2\n 3\n\\ No newline at end of file\n"},
+	}
+	for _, test := range tests {
+		hunks := DiffLines(test.base, test.text)
+		assert.Equal(t, 1, len(hunks))
+		assert.Equal(t, "--- a/f\n+++ b/f\n"+test.patch, HunkPatch("f", test.base, test.text, hunks[0], false))
+	}
+}
+
+func TestHunkPatchCRLF(t *testing.T) {
+	base, text := "1\n2\n", "1\ntwo\n"
+	hunks := DiffLines(base, text)
+	assert.Equal(t, "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n 1\r\n-2\r\n+two\r\n", HunkPatch("f", base, text, hunks[0], true))
+}
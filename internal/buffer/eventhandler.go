@@ -76,6 +76,8 @@ type EventHandler struct {
 	active    int
 	UndoStack *TEStack
 	RedoStack *TEStack
+	// Changes which were undone and then replaced by other changes
+	Branches []*UndoBranch
 }
 
 // NewEventHandler returns a new EventHandler
@@ -140,7 +142,7 @@ func (eh *EventHandler) InsertBytes(start Loc, text []byte) {
 		c.CurSelection[1] = move(c.CurSelection[1])
 		c.OrigSelection[0] = move(c.OrigSelection[0])
 		c.OrigSelection[1] = move(c.OrigSelection[1])
-		c.LastVisualX = c.GetVisualX()
+		c.StoreVisualX()
 	}
 }
 
@@ -168,7 +170,7 @@ func (eh *EventHandler) Remove(start, end Loc) {
 		c.CurSelection[1] = move(c.CurSelection[1])
 		c.OrigSelection[0] = move(c.OrigSelection[0])
 		c.OrigSelection[1] = move(c.OrigSelection[1])
-		c.LastVisualX = c.GetVisualX()
+		c.StoreVisualX()
 	}
 }
 
@@ -192,6 +194,7 @@ func (eh *EventHandler) Replace(start, end Loc, replace string) {
 // Execute a textevent and add it to the undo stack
 func (eh *EventHandler) Execute(t *TextEvent) {
 	if eh.RedoStack.Len() > 0 {
+		eh.forkBranch()
 		eh.RedoStack = new(TEStack)
 	}
 	eh.UndoStack.Push(t)
@@ -208,6 +211,13 @@ func (eh *EventHandler) Execute(t *TextEvent) {
 	ExecuteTextEvent(t, eh.buf)
 }
 
+// ClearHistory empties the undo and redo stacks and drops the undo branches
+func (eh *EventHandler) ClearHistory() {
+	eh.UndoStack = new(TEStack)
+	eh.RedoStack = new(TEStack)
+	eh.Branches = nil
+}
+
 // Undo the first event in the undo stack
 func (eh *EventHandler) Undo() {
 	t := eh.UndoStack.Peek()
@@ -0,0 +1,29 @@
+package buffer
+
+// LineHidden returns whether line y is hidden inside a folded region. The
+// cursor and the view move over the hidden lines as if they were a single
+// line. Folding does not exist yet, so no line is hidden
+func (b *Buffer) LineHidden(y int) bool {
+	return false
+}
+
+// MoveLines returns the line which is n visible lines after line y, or
+// before it if n is negative, stopping at the first or the last visible
+// line of the buffer
+func (b *Buffer) MoveLines(y, n int) int {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for ; n > 0; n-- {
+		next := y + step
+		for next >= 0 && next < b.LinesNum() && b.LineHidden(next) {
+			next += step
+		}
+		if next < 0 || next >= b.LinesNum() {
+			break
+		}
+		y = next
+	}
+	return y
+}
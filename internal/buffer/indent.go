@@ -0,0 +1,171 @@
+package buffer
+
+import (
+	"unicode/utf8"
+
+	"github.com/zyedidia/micro/internal/util"
+)
+
+// The number of indented lines DetectIndent looks at
+const detectIndentLines = 100
+
+// DetectIndent looks at the first indented lines and returns whether most
+// of them are indented with spaces, and in that case the most common
+// indentation step. ok is false if there are no indented lines
+func DetectIndent(lines [][]byte) (spaces bool, size int, ok bool) {
+	tabLines, spaceLines := 0, 0
+	steps := make(map[int]int)
+	prev := 0
+	for _, l := range lines {
+		if tabLines+spaceLines >= detectIndentLines {
+			break
+		}
+		if util.IsBytesWhitespace(l) {
+			continue
+		}
+
+		ws := util.GetLeadingWhitespace(l)
+		switch {
+		case len(ws) == 0:
+			prev = 0
+		case ws[0] == '\t':
+			tabLines++
+		default:
+			n := 0
+			for n < len(ws) && ws[n] == ' ' {
+				n++
+			}
+			// a single space is usually the alignment of a comment
+			if n-prev > 1 {
+				steps[n-prev]++
+			}
+			if n > 1 {
+				spaceLines++
+			}
+			prev = n
+		}
+	}
+
+	if tabLines == 0 && spaceLines == 0 {
+		return false, 0, false
+	}
+	if tabLines >= spaceLines {
+		return false, 0, true
+	}
+	for step, count := range steps {
+		if step <= 8 && (count > steps[size] || count == steps[size] && step < size) {
+			size = step
+		}
+	}
+	return true, size, size > 0
+}
+
+// detectIndent sets tabstospaces and tabsize from the indentation of the
+// buffer. The options which are set in one of the given maps are kept
+func (b *Buffer) detectIndent(keep ...map[string]interface{}) {
+	var lines [][]byte
+	for i := 0; i < b.LinesNum() && i < 10*detectIndentLines; i++ {
+		lines = append(lines, b.LineBytes(i))
+	}
+	spaces, size, ok := DetectIndent(lines)
+	if !ok {
+		return
+	}
+
+	set := func(option string, value interface{}) {
+		for _, m := range keep {
+			if _, ok := m[option]; ok {
+				return
+			}
+		}
+		b.Settings[option] = value
+	}
+	set("tabstospaces", spaces)
+	if spaces {
+		set("tabsize", float64(size))
+	}
+}
+
+// SiblingLine returns the first line after line y, or before it if dir is
+// negative, which is indented as much as line y or less, skipping the blank
+// lines and the more indented lines. It returns -1 if there is none
+func SiblingLine(lines [][]byte, y, dir, tabsize int) int {
+	ws := util.GetLeadingWhitespace(lines[y])
+	indent := util.StringWidth(ws, utf8.RuneCount(ws), tabsize)
+	if dir < 0 {
+		dir = -1
+	} else {
+		dir = 1
+	}
+	for i := y + dir; i >= 0 && i < len(lines); i += dir {
+		if util.IsBytesWhitespace(lines[i]) {
+			continue
+		}
+		ws := util.GetLeadingWhitespace(lines[i])
+		if util.StringWidth(ws, utf8.RuneCount(ws), tabsize) <= indent {
+			return i
+		}
+	}
+	return -1
+}
+
+// IndentBlock returns the first and the last line of the block of line y
+// delimited by indentation. If the next line is more indented, line y heads
+// the block and it extends over the following more indented lines,
+// otherwise the block is the run of lines around y which are indented as
+// much as line y or more. Blank lines are part of the block when they are
+// inside it, and a blank line y belongs to the block of the nearest
+// non-blank line, the one below it if they are as near
+func IndentBlock(lines [][]byte, y, tabsize int) (int, int) {
+	blank := func(i int) bool {
+		return util.IsBytesWhitespace(lines[i])
+	}
+	indent := func(i int) int {
+		ws := util.GetLeadingWhitespace(lines[i])
+		return util.StringWidth(ws, utf8.RuneCount(ws), tabsize)
+	}
+
+	if blank(y) {
+		found := false
+		for d := 1; !found && (y-d >= 0 || y+d < len(lines)); d++ {
+			if y+d < len(lines) && !blank(y+d) {
+				y, found = y+d, true
+			} else if y-d >= 0 && !blank(y-d) {
+				y, found = y-d, true
+			}
+		}
+		if !found {
+			return y, y
+		}
+	}
+
+	level := indent(y)
+	next := y + 1
+	for next < len(lines) && blank(next) {
+		next++
+	}
+	heads := next < len(lines) && indent(next) > level
+
+	start, end := y, y
+	if !heads {
+		for i := y - 1; i >= 0; i-- {
+			if blank(i) {
+				continue
+			}
+			if indent(i) < level {
+				break
+			}
+			start = i
+		}
+	}
+	for i := y + 1; i < len(lines); i++ {
+		if blank(i) {
+			continue
+		}
+		if indent(i) < level || (heads && indent(i) == level) {
+			break
+		}
+		end = i
+	}
+	return start, end
+}
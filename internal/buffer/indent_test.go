@@ -0,0 +1,66 @@
+package buffer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func detect(text string) (bool, int, bool) {
+	var lines [][]byte
+	for _, l := range strings.Split(text, "\n") {
+		lines = append(lines, []byte(l))
+	}
+	return DetectIndent(lines)
+}
+
+func TestDetectIndent(t *testing.T) {
+	spaces, size, ok := detect("func f() {\n\tif x {\n\t\ty()\n\t}\n}\n")
+	assert.True(t, ok)
+	assert.False(t, spaces)
+
+	spaces, size, ok = detect("def f():\n  if x:\n    y()\n  /* a\n   * b */\n  return\n")
+	assert.True(t, ok)
+	assert.True(t, spaces)
+	assert.Equal(t, 2, size)
+
+	spaces, size, ok = detect("a:\n    b:\n        c\n    d\n")
+	assert.True(t, spaces)
+	assert.Equal(t, 4, size)
+
+	_, _, ok = detect("a\nb\n\nc\n")
+	assert.False(t, ok)
+}
+
+func TestSiblingLine(t *testing.T) {
+	var lines [][]byte
+	for _, l := range strings.Split("a:\n  b: 1\n  c:\n    d: 2\n\n    e: 3\n  f: 4\ng: 5", "\n") {
+		lines = append(lines, []byte(l))
+	}
+	assert.Equal(t, 2, SiblingLine(lines, 1, 1, 4))
+	assert.Equal(t, 6, SiblingLine(lines, 2, 1, 4))
+	assert.Equal(t, 5, SiblingLine(lines, 3, 1, 4))
+	assert.Equal(t, 2, SiblingLine(lines, 6, -1, 4))
+	assert.Equal(t, 0, SiblingLine(lines, 1, -1, 4))
+	assert.Equal(t, -1, SiblingLine(lines, 7, 1, 4))
+	assert.Equal(t, -1, SiblingLine(lines, 0, -1, 4))
+}
+
+func TestIndentBlock(t *testing.T) {
+	var lines [][]byte
+	for _, l := range strings.Split("def f():\n    a = 1\n\n    if a:\n        b()\n    c()\n\ndef g():\n    pass", "\n") {
+		lines = append(lines, []byte(l))
+	}
+	block := func(y int) []int {
+		start, end := IndentBlock(lines, y, 4)
+		return []int{start, end}
+	}
+	assert.Equal(t, []int{0, 5}, block(0))
+	assert.Equal(t, []int{1, 5}, block(1))
+	assert.Equal(t, []int{3, 4}, block(3))
+	assert.Equal(t, []int{4, 4}, block(4))
+	assert.Equal(t, []int{3, 4}, block(2))
+	assert.Equal(t, []int{7, 8}, block(6))
+	assert.Equal(t, []int{7, 8}, block(7))
+}
@@ -0,0 +1,237 @@
+package buffer
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	leadingNumber = regexp.MustCompile(`^\s*[-+]?(\d+(\.\d*)?|\.\d+)`)
+	lineNumber    = regexp.MustCompile(`^\s*\d+(:\s?|\s)`)
+)
+
+// compareKeys compares two sort keys, numerically if both are integers
+func compareKeys(a, b string) int {
+	if na, err := strconv.ParseInt(a, 10, 64); err == nil {
+		if nb, err := strconv.ParseInt(b, 10, 64); err == nil {
+			switch {
+			case na < nb:
+				return -1
+			case na > nb:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// SortLinesByKey sorts the lines by the text matched by the first group of
+// the regular expression, or by the whole match if it has no group. Keys
+// which are integers are compared numerically. The lines without a match
+// keep their order, at the top if unmatchedFirst is set, otherwise at the
+// bottom
+func SortLinesByKey(lines []string, re *regexp.Regexp, unmatchedFirst bool) []string {
+	type keyed struct {
+		line string
+		key  string
+	}
+	var matched []keyed
+	var unmatched []string
+	for _, l := range lines {
+		m := re.FindStringSubmatch(l)
+		if m == nil {
+			unmatched = append(unmatched, l)
+			continue
+		}
+		key := m[0]
+		if len(m) > 1 {
+			key = m[1]
+		}
+		matched = append(matched, keyed{l, key})
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return compareKeys(matched[i].key, matched[j].key) < 0
+	})
+
+	sorted := make([]string, 0, len(lines))
+	if unmatchedFirst {
+		sorted = append(sorted, unmatched...)
+	}
+	for _, k := range matched {
+		sorted = append(sorted, k.line)
+	}
+	if !unmatchedFirst {
+		sorted = append(sorted, unmatched...)
+	}
+	return sorted
+}
+
+// SortLinesNumeric sorts the lines by the number they start with, which may
+// follow some whitespace and be negative. The lines which do not start with
+// a number come after the others and are sorted as strings
+func SortLinesNumeric(lines []string) []string {
+	number := func(l string) (float64, bool) {
+		m := leadingNumber.FindString(l)
+		if m == "" {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(m), 64)
+		return n, err == nil
+	}
+
+	sorted := append([]string(nil), lines...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, aok := number(sorted[i])
+		b, bok := number(sorted[j])
+		switch {
+		case aok && bok && a != b:
+			return a < b
+		case aok != bok:
+			return aok
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+// UniqueLines removes the duplicate lines and keeps the first occurrence of
+// every line. If adjacentOnly is set, only the consecutive duplicates are
+// removed, like uniq does
+func UniqueLines(lines []string, adjacentOnly bool) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for i, l := range lines {
+		if adjacentOnly {
+			if i > 0 && l == lines[i-1] {
+				continue
+			}
+		} else {
+			if seen[l] {
+				continue
+			}
+			seen[l] = true
+		}
+		unique = append(unique, l)
+	}
+	return unique
+}
+
+// ShuffleLines returns the lines in a random order, drawn from the given
+// source with the Fisher-Yates shuffle
+func ShuffleLines(lines []string, r *rand.Rand) []string {
+	shuffled := append([]string(nil), lines...)
+	for i := len(shuffled) - 1; i > 0; i-- {
+		j := r.Intn(i + 1)
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	return shuffled
+}
+
+// NumberLines prepends its number to every line, starting from start. The
+// numbers are right-aligned to width columns, or to the width of the largest
+// number if width is 0, and followed by sep
+func NumberLines(lines []string, start, width int, sep string) []string {
+	if width == 0 {
+		width = len(strconv.Itoa(start + len(lines) - 1))
+	}
+	numbered := make([]string, len(lines))
+	for i, l := range lines {
+		numbered[i] = fmt.Sprintf("%*d%s%s", width, start+i, sep, l)
+	}
+	return numbered
+}
+
+// StripLineNumbers removes the line number at the start of every line,
+// followed by a colon or by a whitespace
+func StripLineNumbers(lines []string) []string {
+	stripped := make([]string, len(lines))
+	for i, l := range lines {
+		stripped[i] = lineNumber.ReplaceAllString(l, "")
+	}
+	return stripped
+}
+
+// ReverseLines returns the lines in the reverse order
+func ReverseLines(lines []string) []string {
+	reversed := make([]string, len(lines))
+	for i, l := range lines {
+		reversed[len(lines)-1-i] = l
+	}
+	return reversed
+}
+
+// CommentLines comments out every line which is not blank with the given
+// comment type, where %s stands for the text of the line after its
+// indentation, like "// %s" or "<!-- %s -->"
+func CommentLines(lines []string, commentType string) []string {
+	if !strings.Contains(commentType, "%s") {
+		commentType += " %s"
+	}
+	commented := make([]string, len(lines))
+	for i, l := range lines {
+		text := strings.TrimLeft(l, " \t")
+		if text == "" {
+			commented[i] = l
+			continue
+		}
+		commented[i] = l[:len(l)-len(text)] + strings.Replace(commentType, "%s", text, 1)
+	}
+	return commented
+}
+
+// JoinLines joins the lines with sep. With trim, the trailing whitespace of
+// the lines and the indentation of the lines after the first one are removed
+func JoinLines(lines []string, sep string, trim bool) string {
+	if !trim {
+		return strings.Join(lines, sep)
+	}
+	trimmed := make([]string, len(lines))
+	for i, l := range lines {
+		l = strings.TrimRight(l, " \t")
+		if i > 0 {
+			l = strings.TrimLeft(l, " \t")
+		}
+		trimmed[i] = l
+	}
+	return strings.Join(trimmed, sep)
+}
+
+// SplitLine splits a line at every occurrence of delim. With keepIndent,
+// every line produced gets the indentation of the line, and the empty
+// fields are dropped unless keepEmpty is set
+func SplitLine(line, delim string, keepIndent, keepEmpty bool) []string {
+	text := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(text)]
+
+	var lines []string
+	for _, f := range strings.Split(text, delim) {
+		if f == "" && !keepEmpty {
+			continue
+		}
+		if keepIndent || len(lines) == 0 {
+			f = indent + f
+		}
+		lines = append(lines, f)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, indent)
+	}
+	return lines
+}
+
+// FilterLines returns the lines which match re, or the lines which don't
+// match it if keep is false
+func FilterLines(lines []string, re *regexp.Regexp, keep bool) []string {
+	var filtered []string
+	for _, l := range lines {
+		if re.MatchString(l) == keep {
+			filtered = append(filtered, l)
+		}
+	}
+	return filtered
+}
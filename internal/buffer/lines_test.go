@@ -0,0 +1,111 @@
+package buffer
+
+import (
+	"math/rand"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortLinesByKey(t *testing.T) {
+	lines := []string{
+		"b took 10ms",
+		"no timing",
+		"a took 9ms",
+		"c took 100ms",
+		"also no timing",
+	}
+	re := regexp.MustCompile(`took (\d+)ms`)
+
+	assert.Equal(t, []string{
+		"a took 9ms",
+		"b took 10ms",
+		"c took 100ms",
+		"no timing",
+		"also no timing",
+	}, SortLinesByKey(lines, re, false))
+	assert.Equal(t, []string{
+		"no timing",
+		"also no timing",
+		"a took 9ms",
+		"b took 10ms",
+		"c took 100ms",
+	}, SortLinesByKey(lines, re, true))
+
+	// without a group the whole match is the key
+	assert.Equal(t, []string{"x b", "y c", "z a"}, SortLinesByKey([]string{"z a", "x b", "y c"}, regexp.MustCompile(`^\w`), false))
+}
+
+func TestSortLinesNumeric(t *testing.T) {
+	assert.Equal(t, []string{
+		"-3 below",
+		"  2 two",
+		"2.5",
+		"10 ten",
+		"apple",
+		"banana",
+	}, SortLinesNumeric([]string{"10 ten", "banana", "  2 two", "-3 below", "apple", "2.5"}))
+}
+
+func TestUniqueLines(t *testing.T) {
+	lines := []string{"a", "a", "b", "a", "c", "c"}
+	assert.Equal(t, []string{"a", "b", "c"}, UniqueLines(lines, false))
+	assert.Equal(t, []string{"a", "b", "a", "c"}, UniqueLines(lines, true))
+}
+
+func TestShuffleLines(t *testing.T) {
+	lines := []string{"a", "b", "c", "d", "e", "f"}
+	shuffled := ShuffleLines(lines, rand.New(rand.NewSource(1)))
+	assert.Equal(t, shuffled, ShuffleLines(lines, rand.New(rand.NewSource(1))))
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, lines)
+
+	sort.Strings(shuffled)
+	assert.Equal(t, lines, shuffled)
+}
+
+func TestNumberLines(t *testing.T) {
+	lines := []string{"a", "", "c"}
+	assert.Equal(t, []string{"1: a", "2: ", "3: c"}, NumberLines(lines, 1, 0, ": "))
+	assert.Equal(t, []string{" 9\ta", "10\t", "11\tc"}, NumberLines(lines, 9, 0, "\t"))
+	assert.Equal(t, []string{"   0 a", "   1 ", "   2 c"}, NumberLines(lines, 0, 4, " "))
+
+	assert.Equal(t, lines, StripLineNumbers(NumberLines(lines, 9, 0, ": ")))
+	assert.Equal(t, lines, StripLineNumbers(NumberLines(lines, 0, 4, " ")))
+	assert.Equal(t, []string{"x1 a"}, StripLineNumbers([]string{"x1 a"}))
+}
+
+func TestReverseLines(t *testing.T) {
+	assert.Equal(t, []string{"c", "b", "a"}, ReverseLines([]string{"a", "b", "c"}))
+	assert.Equal(t, []string{"a"}, ReverseLines([]string{"a"}))
+}
+
+func TestCommentLines(t *testing.T) {
+	lines := []string{"\tx := 1", "", "  <b>"}
+	assert.Equal(t, []string{"\t// x := 1", "", "  // <b>"}, CommentLines(lines, "// %s"))
+	assert.Equal(t, []string{"\t<!-- x := 1 -->", "", "  <!-- <b> -->"}, CommentLines(lines, "<!-- %s -->"))
+	assert.Equal(t, []string{"\t# x := 1", "", "  # <b>"}, CommentLines(lines, "#"))
+}
+
+func TestJoinLines(t *testing.T) {
+	lines := []string{"  a ", "\tb", "c  "}
+	assert.Equal(t, "  a, b, c", JoinLines(lines, ", ", true))
+	assert.Equal(t, "  a ,\tb,c  ", JoinLines(lines, ",", false))
+	assert.Equal(t, "a", JoinLines([]string{"a"}, ",", true))
+}
+
+func TestSplitLine(t *testing.T) {
+	assert.Equal(t, []string{"\ta", "\tb", "\t", "\tc"}, SplitLine("\ta,b,,c", ",", true, true))
+	assert.Equal(t, []string{"\ta", "b", "c"}, SplitLine("\ta,b,,c", ",", false, false))
+	assert.Equal(t, []string{"a", "b"}, SplitLine("a, b", ", ", true, true))
+	assert.Equal(t, []string{"  "}, SplitLine("  ,", ",", true, false))
+}
+
+func TestFilterLines(t *testing.T) {
+	lines := []string{"INFO start", "ERROR disk", "INFO stop", "WARN cpu"}
+	re := regexp.MustCompile(`^(ERROR|WARN)`)
+	assert.Equal(t, []string{"ERROR disk", "WARN cpu"}, FilterLines(lines, re, true))
+	assert.Equal(t, []string{"INFO start", "INFO stop"}, FilterLines(lines, re, false))
+	assert.Empty(t, FilterLines(lines, regexp.MustCompile("DEBUG"), true))
+}
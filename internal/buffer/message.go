@@ -79,6 +79,26 @@ func (b *Buffer) ClearMessages(owner string) {
 	}
 }
 
+// shiftMessages moves the messages starting at line y or below by n lines
+// after an edit added or removed lines. When lines are removed (n < 0) the
+// messages on the removed lines, just above y, are deleted
+func (b *SharedBuffer) shiftMessages(y, n int) {
+	msgs := b.Messages[:0]
+	for _, m := range b.Messages {
+		if m.Start.Y >= y {
+			m.Start.Y += n
+			m.End.Y += n
+		} else if n < 0 && m.Start.Y >= y+n {
+			continue
+		}
+		msgs = append(msgs, m)
+	}
+	for i := len(msgs); i < len(b.Messages); i++ {
+		b.Messages[i] = nil
+	}
+	b.Messages = msgs
+}
+
 func (b *Buffer) ClearAllMessages() {
 	b.Messages = make([]*Message, 0)
 }
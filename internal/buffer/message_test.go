@@ -0,0 +1,26 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShiftMessages(t *testing.T) {
+	b := new(SharedBuffer)
+	for _, y := range []int{1, 3, 5} {
+		b.Messages = append(b.Messages, NewMessageAtLine("test", "", y+1, MTError))
+	}
+
+	b.shiftMessages(2, 2)
+	assert.Equal(t, 3, len(b.Messages))
+	assert.Equal(t, 1, b.Messages[0].Start.Y)
+	assert.Equal(t, 5, b.Messages[1].Start.Y)
+	assert.Equal(t, 7, b.Messages[2].End.Y)
+
+	// removing lines 4 and 5 deletes the message on line 5
+	b.shiftMessages(6, -2)
+	assert.Equal(t, 2, len(b.Messages))
+	assert.Equal(t, 1, b.Messages[0].Start.Y)
+	assert.Equal(t, 5, b.Messages[1].Start.Y)
+}
@@ -160,19 +160,42 @@ func (b *Buffer) saveToFile(filename string, withSudo bool) error {
 			eol = []byte{'\n'}
 		}
 
+		lines := b.lines
+		finalEOL := false
+		if b.Settings["trimfinalnewlines"].(bool) {
+			// drop the blank lines at the end, even if they hold whitespace,
+			// and leave a single newline at the end of the file
+			n := len(lines)
+			for n > 1 && util.IsBytesWhitespace(lines[n-1].data) {
+				n--
+			}
+			if n < len(lines) {
+				lines = lines[:n]
+				finalEOL = true
+			}
+		}
+
 		// write lines
-		if fileSize, e = file.Write(b.lines[0].data); e != nil {
+		if fileSize, e = file.Write(lines[0].data); e != nil {
 			return
 		}
 
-		for _, l := range b.lines[1:] {
+		for i := 1; i < len(lines); i++ {
 			if _, e = file.Write(eol); e != nil {
 				return
 			}
-			if _, e = file.Write(l.data); e != nil {
+			if _, e = file.Write(lines[i].data); e != nil {
 				return
 			}
-			fileSize += len(eol) + len(l.data)
+			fileSize += len(eol) + len(lines[i].data)
+		}
+
+		// the final newline is only written to the file, the buffer is left as is
+		if finalEOL || b.Settings["insertfinalnewline"].(bool) && len(lines[len(lines)-1].data) > 0 {
+			if _, e = file.Write(eol); e != nil {
+				return
+			}
+			fileSize += len(eol)
 		}
 		return
 	}
@@ -190,9 +213,17 @@ func (b *Buffer) saveToFile(filename string, withSudo bool) error {
 		}
 	}
 
+	// the saved file holds all the changes, the backup isn't needed anymore
+	b.backupRequested = false
+	b.RemoveBackup()
+
 	b.Path = filename
 	absPath, _ := filepath.Abs(filename)
 	b.AbsPath = absPath
 	b.isModified = false
+	b.UpdateDiffBase()
+	if b.ShowingBlame() {
+		b.LoadBlame()
+	}
 	return err
 }
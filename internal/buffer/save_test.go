@@ -0,0 +1,37 @@
+package buffer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/zyedidia/micro/internal/config"
+	ulua "github.com/zyedidia/micro/internal/lua"
+)
+
+func TestTrimFinalNewlines(t *testing.T) {
+	ulua.L = lua.NewState()
+	config.InitGlobalSettings()
+	dir, err := ioutil.TempDir("", "micro")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	save := func(text string) string {
+		path := filepath.Join(dir, "file.txt")
+		b := NewBufferFromString(text, path, BTDefault)
+		defer b.Close()
+		b.Settings["trimfinalnewlines"] = true
+		assert.NoError(t, b.Save())
+		data, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "a\n", save("a\n\n\n"))
+	assert.Equal(t, "a\n", save("a\n  \n\t\n"))
+	assert.Equal(t, "a\n", save("a\n  "))
+	assert.Equal(t, "a\n  b\n", save("a\n  b\n"))
+	assert.Equal(t, "a", save("a"))
+}
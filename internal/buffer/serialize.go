@@ -1,6 +1,7 @@
 package buffer
 
 import (
+	"crypto/md5"
 	"encoding/gob"
 	"errors"
 	"io"
@@ -15,16 +16,19 @@ import (
 )
 
 // The SerializedBuffer holds the types that get serialized when a buffer is saved
-// These are used for the savecursor and saveundo options
+// These are used for the savecursor and persistentundo options
 type SerializedBuffer struct {
 	EventHandler *EventHandler
 	Cursor       Loc
 	ModTime      time.Time
+	// Hash of the text the undo history leads to, empty in the files
+	// written by older versions
+	Hash [md5.Size]byte
 }
 
 // Serialize serializes the buffer to config.ConfigDir/buffers
 func (b *Buffer) Serialize() error {
-	if !b.Settings["savecursor"].(bool) && !b.Settings["saveundo"].(bool) {
+	if !b.Settings["savecursor"].(bool) && !b.Settings["persistentundo"].(bool) {
 		return nil
 	}
 	if b.Path == "" {
@@ -33,11 +37,15 @@ func (b *Buffer) Serialize() error {
 
 	name := config.ConfigDir + "/buffers/" + util.EscapePath(b.AbsPath)
 
+	var hash [md5.Size]byte
+	calcSavedHash(b, &hash)
+
 	return overwriteFile(name, encoding.Nop, func(file io.Writer) error {
 		err := gob.NewEncoder(file).Encode(SerializedBuffer{
 			b.EventHandler,
 			b.GetActiveCursor().Loc,
 			b.ModTime,
+			hash,
 		})
 		return err
 	}, false)
@@ -45,7 +53,7 @@ func (b *Buffer) Serialize() error {
 
 // Unserialize loads the buffer info from config.ConfigDir/buffers
 func (b *Buffer) Unserialize() error {
-	// If either savecursor or saveundo is turned on, we need to load the serialized information
+	// If either savecursor or persistentundo is turned on, we need to load the serialized information
 	// from ~/.config/micro/buffers
 	if b.Path == "" {
 		return nil
@@ -57,15 +65,20 @@ func (b *Buffer) Unserialize() error {
 		decoder := gob.NewDecoder(file)
 		err = decoder.Decode(&buffer)
 		if err != nil {
-			return errors.New(err.Error() + "\nYou may want to remove the files in ~/.config/micro/buffers (these files\nstore the information for the 'saveundo' and 'savecursor' options) if\nthis problem persists.\nThis may be caused by upgrading to version 2.0, and removing the 'buffers'\ndirectory will reset the cursor and undo history and solve the problem.")
+			return errors.New(err.Error() + "\nYou may want to remove the files in ~/.config/micro/buffers (these files\nstore the information for the 'persistentundo' and 'savecursor' options) if\nthis problem persists.\nThis may be caused by upgrading to version 2.0, and removing the 'buffers'\ndirectory will reset the cursor and undo history and solve the problem.")
 		}
 		if b.Settings["savecursor"].(bool) {
 			b.StartCursor = buffer.Cursor
 		}
 
-		if b.Settings["saveundo"].(bool) {
-			// We should only use last time's eventhandler if the file wasn't modified by someone else in the meantime
-			if b.ModTime == buffer.ModTime {
+		if b.Settings["persistentundo"].(bool) {
+			// We should only use last time's eventhandler if the text is the one it leads to,
+			// which is not the case if the file was modified by someone else in the meantime
+			// or if the buffer was closed without saving
+			var hash, oldHash [md5.Size]byte
+			calcSavedHash(b, &hash)
+			calcHash(b, &oldHash)
+			if hash == buffer.Hash || oldHash == buffer.Hash || (buffer.Hash == [md5.Size]byte{} && b.ModTime == buffer.ModTime) {
 				b.EventHandler = buffer.EventHandler
 				b.EventHandler.cursors = b.cursors
 				b.EventHandler.buf = b.SharedBuffer
@@ -74,3 +87,21 @@ func (b *Buffer) Unserialize() error {
 	}
 	return nil
 }
+
+// calcSavedHash calculates the md5 hash of the lines of the buffer without
+// the empty line after a final newline. The hash does not change when the
+// insertfinalnewline option adds the final newline to the file only
+func calcSavedHash(b *Buffer, out *[md5.Size]byte) {
+	lines := b.lines
+	if n := len(lines); n > 1 && len(lines[n-1].data) == 0 {
+		lines = lines[:n-1]
+	}
+	h := md5.New()
+	for i := range lines {
+		if i > 0 {
+			h.Write([]byte{'\n'})
+		}
+		h.Write(lines[i].data)
+	}
+	h.Sum((*out)[:0])
+}
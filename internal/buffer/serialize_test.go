@@ -0,0 +1,57 @@
+package buffer
+
+import (
+	"crypto/md5"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/zyedidia/micro/internal/config"
+	ulua "github.com/zyedidia/micro/internal/lua"
+)
+
+func TestSavedHashIgnoresFinalNewline(t *testing.T) {
+	ulua.L = lua.NewState()
+	config.InitGlobalSettings()
+	hash := func(text string) [md5.Size]byte {
+		var h [md5.Size]byte
+		calcSavedHash(NewBufferFromString(text, "", BTDefault), &h)
+		return h
+	}
+	assert.Equal(t, hash("a\nb"), hash("a\nb\n"))
+	assert.NotEqual(t, hash("a\nb"), hash("a\nb\n\n"))
+	assert.NotEqual(t, hash("a\nb"), hash("a\nc"))
+}
+
+func TestPersistentUndo(t *testing.T) {
+	ulua.L = lua.NewState()
+	config.InitGlobalSettings()
+	dir, err := ioutil.TempDir("", "micro")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	old := config.ConfigDir
+	defer func() { config.ConfigDir = old }()
+	config.ConfigDir = dir
+	path := filepath.Join(dir, "file.txt")
+
+	config.GlobalSettings["persistentundo"] = true
+	b := NewBufferFromString("abc", path, BTDefault)
+	b.Insert(Loc{3, 0}, "d")
+	assert.NoError(t, b.Serialize())
+	b.Close()
+
+	b = NewBufferFromString("abcd", path, BTDefault)
+	b.Undo()
+	assert.Equal(t, "abc", string(b.Bytes()))
+	b.Close()
+
+	// the history is not restored when the option is off
+	config.GlobalSettings["persistentundo"] = false
+	b = NewBufferFromString("abcd", path, BTDefault)
+	b.Undo()
+	assert.Equal(t, "abcd", string(b.Bytes()))
+	b.Close()
+}
@@ -1,11 +1,28 @@
 package buffer
 
 import (
+	"reflect"
+
 	"github.com/zyedidia/micro/internal/config"
 	"github.com/zyedidia/micro/internal/screen"
 )
 
+// SetOptionNative sets a given option to a native value just for this
+// buffer. Like the options set with setlocal, it is not overridden by the ft
+// and glob sections of settings.json
 func (b *Buffer) SetOptionNative(option string, nativeValue interface{}) error {
+	if b.localOptions == nil {
+		b.localOptions = make(map[string]bool)
+	}
+	b.localOptions[option] = true
+	return b.DoSetOptionNative(option, nativeValue)
+}
+
+// DoSetOptionNative sets a given option to a native value without making it
+// local to the buffer, for the options which follow the global settings and
+// settings.json
+func (b *Buffer) DoSetOptionNative(option string, nativeValue interface{}) error {
+	nativeValue = config.ConvertOldValue(option, nativeValue)
 	b.Settings[option] = nativeValue
 
 	if option == "fastdirty" {
@@ -19,6 +36,7 @@ func (b *Buffer) SetOptionNative(option string, nativeValue interface{}) error {
 		screen.Redraw()
 	} else if option == "filetype" {
 		b.UpdateRules()
+		b.UpdateFileSettings()
 	} else if option == "fileformat" {
 		switch b.Settings["fileformat"].(string) {
 		case "unix":
@@ -35,6 +53,8 @@ func (b *Buffer) SetOptionNative(option string, nativeValue interface{}) error {
 		}
 	} else if option == "encoding" {
 		b.isModified = true
+	} else if option == "diffgutter" || option == "diffgutterbase" {
+		b.UpdateDiffBase()
 	} else if option == "readonly" && b.Type.Kind == BTDefault.Kind {
 		b.Type.Readonly = nativeValue.(bool)
 	}
@@ -55,3 +75,33 @@ func (b *Buffer) SetOption(option, value string) error {
 
 	return b.SetOptionNative(option, nativeValue)
 }
+
+// UpdateFileSettings applies the options of the ft and glob sections of
+// settings.json matching the filetype and the path of the buffer. The
+// options which were set locally are kept, and the options which were given
+// only by the previous filetype go back to their global value
+func (b *Buffer) UpdateFileSettings() {
+	settings, _ := config.FileSettings(b.Settings["filetype"].(string), b.Path)
+	for k := range b.fileOptions {
+		global, ok := config.GlobalSettings[k]
+		if _, found := settings[k]; found || !ok || b.localOptions[k] {
+			continue
+		}
+		if !reflect.DeepEqual(b.Settings[k], global) {
+			b.DoSetOptionNative(k, global)
+		}
+	}
+
+	b.fileOptions = make(map[string]bool)
+	for k, v := range settings {
+		// the filetype itself is only set from these sections when the
+		// file is opened, changing it here could never settle
+		if _, ok := b.Settings[k]; !ok || k == "filetype" || b.localOptions[k] {
+			continue
+		}
+		b.fileOptions[k] = true
+		if !reflect.DeepEqual(b.Settings[k], v) {
+			b.DoSetOptionNative(k, v)
+		}
+	}
+}
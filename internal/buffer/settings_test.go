@@ -0,0 +1,48 @@
+package buffer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/zyedidia/micro/internal/config"
+	ulua "github.com/zyedidia/micro/internal/lua"
+)
+
+func TestUpdateFileSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "micro-settings")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	settings := `{"ft:go": {"tabsize": 2, "tabstospaces": true}, "ft:c": {"tabsize": 8}}`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "settings.json"), []byte(settings), 0644))
+
+	oldDir := config.ConfigDir
+	config.ConfigDir = dir
+	defer func() { config.ConfigDir = oldDir }()
+	ulua.L = lua.NewState()
+	assert.NoError(t, config.ReadSettings())
+	config.InitGlobalSettings()
+
+	b := NewBufferFromString("", "", BTDefault)
+	b.SetOptionNative("filetype", "go")
+	assert.Equal(t, float64(2), b.Settings["tabsize"])
+	assert.Equal(t, true, b.Settings["tabstospaces"])
+
+	// the options of the previous filetype go back to their global value
+	b.SetOptionNative("filetype", "c")
+	assert.Equal(t, float64(8), b.Settings["tabsize"])
+	assert.Equal(t, false, b.Settings["tabstospaces"])
+
+	// the options set natively are local and kept
+	b.SetOptionNative("filetype", "go")
+	b.SetOptionNative("tabsize", float64(3))
+	b.SetOptionNative("readonly", true)
+	b.UpdateFileSettings()
+	b.SetOptionNative("filetype", "c")
+	assert.Equal(t, float64(3), b.Settings["tabsize"])
+	assert.Equal(t, false, b.Settings["tabstospaces"])
+	assert.Equal(t, true, b.Settings["readonly"])
+}
@@ -0,0 +1,94 @@
+package buffer
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// StartTail starts following the file of the buffer like tail -f, reloading
+// it first if it changed on disk
+func (b *Buffer) StartTail() error {
+	if b.ExternallyModified() {
+		if err := b.ReOpen(); err != nil {
+			return err
+		}
+	}
+	info, err := os.Stat(b.Path)
+	if err != nil {
+		return err
+	}
+	b.tailInfo = info
+	b.tailSize = info.Size()
+	return nil
+}
+
+// StopTail stops following the file of the buffer
+func (b *Buffer) StopTail() {
+	b.tailInfo = nil
+}
+
+// Tailing returns whether the file of the buffer is followed
+func (b *Buffer) Tailing() bool {
+	return b.tailInfo != nil
+}
+
+// ReadTail appends the complete lines which were added at the end of the
+// followed file since the previous call. The file is reloaded if it shrank
+// or was replaced by another one, when a log is truncated or rotated, and
+// is waited for while it is missing. It returns whether the buffer changed
+func (b *Buffer) ReadTail() (bool, error) {
+	info, err := os.Stat(b.Path)
+	if os.IsNotExist(err) {
+		// the file is being rotated
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if !os.SameFile(info, b.tailInfo) || info.Size() < b.tailSize {
+		b.tailInfo = info
+		b.tailSize = info.Size()
+		return true, b.ReOpen()
+	}
+	if info.Size() == b.tailSize {
+		return false, nil
+	}
+
+	file, err := os.Open(b.Path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	data := make([]byte, info.Size()-b.tailSize)
+	if _, err := file.ReadAt(data, b.tailSize); err != nil && err != io.EOF {
+		return false, err
+	}
+	// a line which is still being written is read with the next one
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return false, nil
+	}
+	data = data[:end+1]
+
+	enc, err := htmlindex.Get(b.Settings["encoding"].(string))
+	if err != nil {
+		return false, err
+	}
+	text, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return false, err
+	}
+	if b.Settings["fileformat"] == "dos" {
+		text = bytes.Replace(text, []byte("\r\n"), []byte("\n"), -1)
+	}
+	b.tailInfo = info
+	b.tailSize += int64(len(data))
+
+	b.EventHandler.cursors = b.cursors
+	b.EventHandler.active = b.curCursor
+	b.EventHandler.Insert(b.End(), string(text))
+	b.MarkClean()
+	return true, b.UpdateModTime()
+}
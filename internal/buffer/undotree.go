@@ -0,0 +1,144 @@
+package buffer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// An UndoBranch is a sequence of changes which was undone and then left by
+// making a different change, so that it is not lost. The undo history is
+// a tree: the current path of changes is the undo stack followed by the
+// redo stack, and the branches fork from it
+type UndoBranch struct {
+	// Number of changes of the path before the branch forks from it
+	Depth int
+	// Changes of the branch, in the order to redo them
+	Redo *TEStack
+	// When the branch was left
+	Time time.Time
+	// Branches which fork from the changes of this branch
+	Branches []*UndoBranch
+}
+
+// forkBranch keeps the changes of the redo stack as a branch, before a new
+// change clears it
+func (eh *EventHandler) forkBranch() {
+	depth := eh.UndoStack.Len()
+	b := &UndoBranch{
+		Depth: depth,
+		Redo:  eh.RedoStack,
+		Time:  time.Now(),
+	}
+	// the branches which fork from the changes of the redo stack follow
+	// them into the new branch
+	var kept []*UndoBranch
+	for _, br := range eh.Branches {
+		if br.Depth > depth {
+			b.Branches = append(b.Branches, br)
+		} else {
+			kept = append(kept, br)
+		}
+	}
+	eh.Branches = append(kept, b)
+}
+
+// pathEvent returns the change at index i of the current path, or nil if
+// the path is shorter
+func (eh *EventHandler) pathEvent(i int) *TextEvent {
+	n := eh.UndoStack.Len()
+	var e *Element
+	if i < n {
+		e = eh.UndoStack.Top
+		for j := n - 1; j > i; j-- {
+			e = e.Next
+		}
+	} else {
+		e = eh.RedoStack.Top
+		for j := n; j < i && e != nil; j++ {
+			e = e.Next
+		}
+	}
+	if e == nil {
+		return nil
+	}
+	return e.Value
+}
+
+// branchOrder returns the time of the first change of a branch, which
+// orders the branches forking at the same point
+func branchOrder(b *UndoBranch) time.Time {
+	if t := b.Redo.Peek(); t != nil {
+		return t.Time
+	}
+	return b.Time
+}
+
+// SwitchBranch replaces the changes of the current path after the fork of
+// an undo branch by the changes of the branch. With older the branch is the
+// previous alternative to the current changes, otherwise the next one. It
+// returns false if there is no such branch
+func (eh *EventHandler) SwitchBranch(older bool) bool {
+	var target *UndoBranch
+	for _, b := range eh.Branches {
+		// the current path comes first if it ends where the branch forks
+		var cur time.Time
+		if t := eh.pathEvent(b.Depth); t != nil {
+			cur = t.Time
+		}
+		bt := branchOrder(b)
+		if older && bt.Before(cur) && (target == nil || bt.After(branchOrder(target))) {
+			target = b
+		} else if !older && bt.After(cur) && (target == nil || bt.Before(branchOrder(target))) {
+			target = b
+		}
+	}
+	if target == nil {
+		return false
+	}
+
+	for eh.UndoStack.Len() > target.Depth {
+		eh.UndoOneEvent()
+	}
+
+	var kept []*UndoBranch
+	for _, b := range eh.Branches {
+		if b != target {
+			kept = append(kept, b)
+		}
+	}
+	eh.Branches = kept
+	if eh.RedoStack.Len() > 0 {
+		eh.forkBranch()
+	}
+	eh.RedoStack = target.Redo
+	eh.Branches = append(eh.Branches, target.Branches...)
+
+	for eh.RedoStack.Len() > 0 {
+		eh.RedoOneEvent()
+	}
+	return true
+}
+
+// UndoList describes the undo history: the current path and the branches
+// forking from it, indented under the branch they fork from
+func (eh *EventHandler) UndoList() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Current path: %d changes, %d can be redone\n", eh.UndoStack.Len(), eh.RedoStack.Len())
+	if len(eh.Branches) == 0 {
+		sb.WriteString("No branches\n")
+		return sb.String()
+	}
+
+	var list func(branches []*UndoBranch, indent string)
+	list = func(branches []*UndoBranch, indent string) {
+		for _, b := range branches {
+			fmt.Fprintf(&sb, "%s* after change %d: %d changes, made at %s, left at %s\n", indent, b.Depth, b.Redo.Len(),
+				branchOrder(b).Format("15:04:05"), b.Time.Format("15:04:05"))
+			list(b.Branches, indent+"  ")
+		}
+	}
+	sb.WriteString("Branches:\n")
+	list(eh.Branches, "")
+	return sb.String()
+}
@@ -0,0 +1,60 @@
+package buffer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+	ulua "github.com/zyedidia/micro/internal/lua"
+)
+
+func newTestEventHandler(text string) *EventHandler {
+	// text events are passed to the plugins
+	if ulua.L == nil {
+		ulua.L = lua.NewState()
+	}
+	b := &Buffer{
+		SharedBuffer: new(SharedBuffer),
+		Settings:     map[string]interface{}{"tabsize": float64(4), "tabdisplaywidth": float64(0)},
+	}
+	b.LineArray = NewLineArray(uint64(len(text)), FFAuto, strings.NewReader(text))
+	return NewEventHandler(b.SharedBuffer, []*Cursor{{buf: b}})
+}
+
+func TestUndoBranches(t *testing.T) {
+	eh := newTestEventHandler("")
+	text := func() string { return string(eh.buf.Bytes()) }
+	insert := func(s string) {
+		// the times order the branches
+		time.Sleep(time.Millisecond)
+		eh.Insert(eh.buf.End(), s)
+	}
+
+	insert("a")
+	insert("b")
+	eh.UndoOneEvent()
+	insert("c")
+	assert.Equal(t, "ac", text())
+	assert.Equal(t, 1, len(eh.Branches))
+
+	eh.UndoOneEvent()
+	insert("d")
+	assert.Equal(t, "ad", text())
+	assert.Equal(t, 2, len(eh.Branches))
+
+	assert.False(t, eh.SwitchBranch(false))
+	assert.True(t, eh.SwitchBranch(true))
+	assert.Equal(t, "ac", text())
+	assert.True(t, eh.SwitchBranch(true))
+	assert.Equal(t, "ab", text())
+	assert.False(t, eh.SwitchBranch(true))
+	assert.True(t, eh.SwitchBranch(false))
+	assert.True(t, eh.SwitchBranch(false))
+	assert.Equal(t, "ad", text())
+	assert.Equal(t, 2, len(eh.Branches))
+
+	eh.ClearHistory()
+	assert.Empty(t, eh.Branches)
+}
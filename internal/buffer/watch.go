@@ -0,0 +1,41 @@
+package buffer
+
+import (
+	"time"
+
+	"github.com/zyedidia/micro/internal/util"
+)
+
+// watchInterval is the time between two checks of the files of the open
+// buffers for external modifications
+const watchInterval = time.Second
+
+// FileWatch receives a value every watchInterval, the main loop then checks
+// the files of the buffers using the autoreload option
+var FileWatch = make(chan bool)
+
+// StartFileWatch starts sending the ticks of FileWatch
+func StartFileWatch() {
+	go func() {
+		for {
+			time.Sleep(watchInterval)
+			FileWatch <- true
+		}
+	}()
+}
+
+// ExternalChangeSettled returns whether the file being edited was modified
+// by an external process and its modification time did not change since the
+// previous call. This debounces the changes so that a file which is written
+// in several steps is only reloaded once it is complete
+func (b *Buffer) ExternalChangeSettled() bool {
+	modTime, err := util.GetModTime(b.Path)
+	if err != nil || modTime == b.ModTime {
+		return false
+	}
+	if modTime != b.watchModTime {
+		b.watchModTime = modTime
+		return false
+	}
+	return true
+}
@@ -8,6 +8,10 @@ import (
 var Autosave chan bool
 var autotime int
 
+// autogen is incremented when the timer is restarted, to stop the previous
+// one
+var autogen int
+
 // lock for autosave
 var autolock sync.Mutex
 
@@ -28,15 +32,25 @@ func GetAutoTime() int {
 	return a
 }
 
+// StartAutoSave starts the timer which sends to the Autosave channel every
+// autotime seconds, replacing the timer started before if any. The timer
+// stops when autotime is set to 0
 func StartAutoSave() {
+	autolock.Lock()
+	autogen++
+	gen := autogen
+	autolock.Unlock()
+
+	running := func() bool {
+		autolock.Lock()
+		defer autolock.Unlock()
+		return gen == autogen && autotime >= 1
+	}
 	go func() {
-		for {
-			if autotime < 1 {
-				break
-			}
-			time.Sleep(time.Duration(autotime) * time.Second)
+		for running() {
+			time.Sleep(time.Duration(GetAutoTime()) * time.Second)
 			// it's possible autotime was changed while sleeping
-			if autotime < 1 {
+			if !running() {
 				break
 			}
 			Autosave <- true
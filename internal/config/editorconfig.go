@@ -0,0 +1,225 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// An editorConfigSection is a section of an .editorconfig file with the
+// properties applying to the files matched by its glob
+type editorConfigSection struct {
+	glob  *regexp.Regexp
+	props map[string]string
+}
+
+// parseEditorConfig parses the content of an .editorconfig file located in
+// dir. It returns whether the file is a root file, in which case the files
+// of the parent directories are ignored
+func parseEditorConfig(data []byte, dir string) (bool, []editorConfigSection) {
+	root := false
+	var sections []editorConfigSection
+	var cur *editorConfigSection
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			cur = nil
+			if g, err := editorConfigGlob(line[1:len(line)-1], dir); err == nil {
+				sections = append(sections, editorConfigSection{g, make(map[string]string)})
+				cur = &sections[len(sections)-1]
+			}
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		value := strings.ToLower(strings.TrimSpace(kv[1]))
+		if cur != nil {
+			cur.props[key] = value
+		} else if key == "root" {
+			root = value == "true"
+		}
+	}
+	return root, sections
+}
+
+// editorConfigGlob converts the glob of a section of an .editorconfig file
+// in dir to a regular expression matching absolute paths. Globs without a
+// slash match files in any subdirectory
+func editorConfigGlob(glob, dir string) (*regexp.Regexp, error) {
+	var re strings.Builder
+	re.WriteString("^" + regexp.QuoteMeta(filepath.ToSlash(dir)) + "/")
+	if strings.HasPrefix(glob, "/") {
+		glob = glob[1:]
+	} else if !strings.Contains(glob, "/") {
+		re.WriteString("(?:.*/)?")
+	}
+
+	inBraces := 0
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				re.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				re.WriteString(".*")
+				i++
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end == -1 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end
+		case '{':
+			end := strings.IndexByte(glob[i:], '}')
+			if end != -1 && regexp.MustCompile(`^\{[+-]?\d+\.\.[+-]?\d+\}$`).MatchString(glob[i:i+end+1]) {
+				// numeric ranges match any integer, the bounds are not checked
+				re.WriteString(`[+-]?\d+`)
+				i += end
+				continue
+			}
+			inBraces++
+			re.WriteString("(?:")
+		case '}':
+			if inBraces > 0 {
+				inBraces--
+				re.WriteString(")")
+			} else {
+				re.WriteString(`\}`)
+			}
+		case ',':
+			if inBraces > 0 {
+				re.WriteString("|")
+			} else {
+				re.WriteString(",")
+			}
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.Compile(re.String())
+}
+
+// editorConfigProperties returns the EditorConfig properties applying to
+// the file at the given absolute path, reading the .editorconfig files of
+// its directory and of all its parents up to a root file
+func editorConfigProperties(path string) map[string]string {
+	var files [][]editorConfigSection
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if data, err := ioutil.ReadFile(filepath.Join(dir, ".editorconfig")); err == nil {
+			root, sections := parseEditorConfig(data, dir)
+			files = append(files, sections)
+			if root {
+				break
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	// the closest files take precedence
+	props := make(map[string]string)
+	slashPath := filepath.ToSlash(path)
+	for i := len(files) - 1; i >= 0; i-- {
+		for _, s := range files[i] {
+			if s.glob.MatchString(slashPath) {
+				for k, v := range s.props {
+					props[k] = v
+				}
+			}
+		}
+	}
+	return props
+}
+
+// EditorConfigSettings returns the options defined by the .editorconfig
+// files for the file at the given absolute path. Options which the user set
+// explicitly in settings.json are left out, so they take precedence
+func EditorConfigSettings(path string) map[string]interface{} {
+	props := editorConfigProperties(path)
+	settings := make(map[string]interface{})
+	size := func(v string) (float64, bool) {
+		n, err := strconv.Atoi(v)
+		return float64(n), err == nil && n > 0
+	}
+
+	switch props["indent_style"] {
+	case "tab":
+		settings["tabstospaces"] = false
+	case "space":
+		settings["tabstospaces"] = true
+	}
+
+	// micro uses tabsize both for the indentation and the width of tabs
+	if n, ok := size(props["indent_size"]); ok {
+		settings["tabsize"] = n
+	}
+	if n, ok := size(props["tab_width"]); ok {
+		if _, set := settings["tabsize"]; !set || props["indent_style"] == "tab" {
+			settings["tabsize"] = n
+		}
+	}
+
+	switch props["end_of_line"] {
+	case "lf":
+		settings["fileformat"] = "unix"
+	case "crlf":
+		settings["fileformat"] = "dos"
+	}
+
+	switch charset := props["charset"]; charset {
+	case "", "unset":
+	case "utf-8-bom":
+		settings["encoding"] = "utf-8"
+	default:
+		settings["encoding"] = charset
+	}
+
+	for prop, option := range map[string]string{
+		"trim_trailing_whitespace": "rmtrailingws",
+		"insert_final_newline":     "insertfinalnewline",
+	} {
+		switch props[prop] {
+		case "true":
+			settings[option] = true
+		case "false":
+			settings[option] = false
+		}
+	}
+
+	for k := range settings {
+		if _, ok := parsedSettings[k]; ok {
+			delete(settings, k)
+		}
+	}
+	return settings
+}
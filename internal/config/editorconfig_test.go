@@ -0,0 +1,75 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorConfigGlob(t *testing.T) {
+	match := func(glob, path string) bool {
+		re, err := editorConfigGlob(glob, "/proj")
+		assert.NoError(t, err)
+		return re.MatchString(path)
+	}
+
+	assert.True(t, match("*", "/proj/a/b.go"))
+	assert.True(t, match("*.go", "/proj/a/b.go"))
+	assert.False(t, match("*.go", "/proj/a/b.c"))
+	assert.True(t, match("*.{js,py}", "/proj/x.py"))
+	assert.True(t, match("lib/*.c", "/proj/lib/x.c"))
+	assert.False(t, match("lib/*.c", "/proj/src/lib/x.c"))
+	assert.True(t, match("/Makefile", "/proj/Makefile"))
+	assert.False(t, match("/Makefile", "/proj/sub/Makefile"))
+	assert.True(t, match("src/**/*.c", "/proj/src/a/b/x.c"))
+	assert.True(t, match("file[0-9].txt", "/proj/file3.txt"))
+	assert.False(t, match("file[!0-9].txt", "/proj/file3.txt"))
+	assert.True(t, match("v{1..3}.txt", "/proj/v2.txt"))
+}
+
+func TestEditorConfigSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "editorconfig")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sub := filepath.Join(dir, "sub")
+	assert.NoError(t, os.Mkdir(sub, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".editorconfig"), []byte(`root = true
+
+[*]
+indent_style = space
+indent_size = 4
+end_of_line = crlf
+insert_final_newline = true
+
+[Makefile]
+indent_style = tab
+`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(sub, ".editorconfig"), []byte(`
+[*.go]
+indent_style = tab
+tab_width = 8
+charset = utf-8-bom
+trim_trailing_whitespace = false
+`), 0644))
+
+	s := EditorConfigSettings(filepath.Join(dir, "main.c"))
+	assert.Equal(t, true, s["tabstospaces"])
+	assert.Equal(t, float64(4), s["tabsize"])
+	assert.Equal(t, "dos", s["fileformat"])
+	assert.Equal(t, true, s["insertfinalnewline"])
+	assert.NotContains(t, s, "rmtrailingws")
+
+	s = EditorConfigSettings(filepath.Join(sub, "Makefile"))
+	assert.Equal(t, false, s["tabstospaces"])
+
+	s = EditorConfigSettings(filepath.Join(sub, "main.go"))
+	assert.Equal(t, false, s["tabstospaces"])
+	assert.Equal(t, float64(8), s["tabsize"])
+	assert.Equal(t, "utf-8", s["encoding"])
+	assert.Equal(t, false, s["rmtrailingws"])
+	assert.Equal(t, "dos", s["fileformat"])
+}
@@ -17,10 +17,11 @@ const (
 	RTHelp         = 2
 	RTPlugin       = 3
 	RTSyntaxHeader = 4
+	RTSnippet      = 5
 )
 
 var (
-	NumTypes = 5 // How many filetypes are there
+	NumTypes = 6 // How many filetypes are there
 )
 
 type RTFiletype int
@@ -167,6 +168,7 @@ func InitRuntimeFiles() {
 	add(RTSyntax, "syntax", "*.yaml")
 	add(RTSyntaxHeader, "syntax", "*.hdr")
 	add(RTHelp, "help", "*.md")
+	add(RTSnippet, "snippets", "*.snippets")
 
 	initlua := filepath.Join(ConfigDir, "init.lua")
 	if _, err := os.Stat(initlua); !os.IsNotExist(err) {
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -35,14 +36,45 @@ func init() {
 
 // Options with validators
 var optionValidators = map[string]optionValidator{
-	"autosave":     validateNonNegativeValue,
-	"tabsize":      validatePositiveValue,
-	"scrollmargin": validateNonNegativeValue,
-	"scrollspeed":  validateNonNegativeValue,
-	"colorscheme":  validateColorscheme,
-	"colorcolumn":  validateNonNegativeValue,
-	"fileformat":   validateLineEnding,
-	"encoding":     validateEncoding,
+	"autosave":         validateNonNegativeValue,
+	"tabsize":          validatePositiveValue,
+	"tabdisplaywidth":  validateNonNegativeValue,
+	"scrollmargin":     validateNonNegativeValue,
+	"scrollspeed":      validateNonNegativeValue,
+	"mousescrollspeed": validateNonNegativeValue,
+	"colorscheme":      validateColorscheme,
+	"colorcolumn":      validateColorColumn,
+	"diffgutterbase":   validateDiffGutterBase,
+	"fileformat":       validateLineEnding,
+	"encoding":         validateEncoding,
+	"relativeline":     validateRelativeLine,
+	"sortunmatched":    validateChoice,
+	"uuidformat":       validateChoice,
+	"wrapindent":       validateWrapIndent,
+}
+
+// The values of the options which can only take a few values, in the order
+// in which the toggle command cycles through them
+var optionChoices = map[string][]string{
+	"diffgutterbase": {"ondisk", "git"},
+	"fileformat":     {"unix", "dos"},
+	"relativeline":   {"off", "relative", "hybrid"},
+	"sortunmatched":  {"bottom", "top"},
+	"sucmd":          {"sudo", "doas"},
+	"uuidformat":     {"lower", "upper", "lowercompact", "uppercompact"},
+}
+
+// OptionChoices returns the values the given option can take, or nil if it
+// can take any value of its type
+func OptionChoices(option string) []string {
+	if option == "colorscheme" {
+		var names []string
+		for _, f := range ListRuntimeFiles(RTColorscheme) {
+			names = append(names, f.Name())
+		}
+		return names
+	}
+	return optionChoices[option]
 }
 
 func ReadSettings() error {
@@ -70,52 +102,153 @@ func ReadSettings() error {
 					}
 				}
 			}
+
+			// persistentundo used to be called saveundo
+			if v, ok := parsedSettings["saveundo"]; ok {
+				if _, ok := parsedSettings["persistentundo"]; !ok {
+					parsedSettings["persistentundo"] = v
+				}
+				delete(parsedSettings, "saveundo")
+			}
+
+			// colorcolumn used to be a single number, convert it to a string
+			convertColorColumn(parsedSettings)
+			for _, v := range parsedSettings {
+				if m, ok := v.(map[string]interface{}); ok {
+					convertColorColumn(m)
+				}
+			}
+			for _, r := range globRules() {
+				if m, ok := r["settings"].(map[string]interface{}); ok {
+					convertColorColumn(m)
+				}
+			}
 		}
 	}
 	return nil
 }
 
+func convertColorColumn(settings map[string]interface{}) {
+	if v, ok := settings["colorcolumn"]; ok {
+		settings["colorcolumn"] = ConvertOldValue("colorcolumn", v)
+	}
+}
+
+// ConvertOldValue converts a value given for an option in the format of
+// older versions to the current format, like the number which colorcolumn
+// used to be and which plugins may still set
+func ConvertOldValue(option string, value interface{}) interface{} {
+	if v, ok := value.(float64); ok && option == "colorcolumn" {
+		return strconv.Itoa(int(v))
+	}
+	return value
+}
+
+// ParseColorColumn parses a comma-separated list of columns
+// for the colorcolumn option
+func ParseColorColumn(value string) ([]int, error) {
+	var cols []int
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		c, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, errors.New("Invalid column: " + s)
+		}
+		if c < 0 {
+			return nil, errors.New("colorcolumn must be non-negative")
+		}
+		cols = append(cols, c)
+	}
+	return cols, nil
+}
+
 // InitGlobalSettings initializes the options map and sets all options to their default values
 // Must be called after ReadSettings
 func InitGlobalSettings() {
 	GlobalSettings = DefaultGlobalSettings()
 
 	for k, v := range parsedSettings {
-		if !strings.HasPrefix(reflect.TypeOf(v).String(), "map") {
+		if !strings.HasPrefix(reflect.TypeOf(v).String(), "map") && k != "globs" {
 			GlobalSettings[k] = v
 		}
 	}
 }
 
+// globRules returns the rules of the globs list of settings.json. Each rule
+// has a glob and the settings of the files matching it
+func globRules() []map[string]interface{} {
+	var rules []map[string]interface{}
+	list, _ := parsedSettings["globs"].([]interface{})
+	for _, r := range list {
+		rule, _ := r.(map[string]interface{})
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
 // InitLocalSettings scans the json in settings.json and sets the options locally based
 // on whether the filetype or path matches ft or glob local settings
 // Must be called after ReadSettings
 func InitLocalSettings(settings map[string]interface{}, path string) error {
+	local, err := FileSettings(settings["filetype"].(string), path)
+	for k, v := range local {
+		settings[k] = v
+	}
+	return err
+}
+
+// FileSettings returns the options of the ft and glob sections of
+// settings.json which apply to a buffer with the given filetype and path
+func FileSettings(filetype, path string) (map[string]interface{}, error) {
 	var parseError error
+	settings := make(map[string]interface{})
+	if v, ok := parsedSettings["ft:"+filetype].(map[string]interface{}); ok {
+		for k1, v1 := range v {
+			settings[k1] = v1
+		}
+	}
 	for k, v := range parsedSettings {
-		if strings.HasPrefix(reflect.TypeOf(v).String(), "map") {
-			if strings.HasPrefix(k, "ft:") {
-				if settings["filetype"].(string) == k[3:] {
-					for k1, v1 := range v.(map[string]interface{}) {
-						settings[k1] = v1
-					}
-				}
-			} else {
-				g, err := glob.Compile(k)
-				if err != nil {
-					parseError = errors.New("Error with glob setting " + k + ": " + err.Error())
-					continue
-				}
+		if strings.HasPrefix(reflect.TypeOf(v).String(), "map") && !strings.HasPrefix(k, "ft:") {
+			g, err := glob.Compile(k)
+			if err != nil {
+				parseError = errors.New("Error with glob setting " + k + ": " + err.Error())
+				continue
+			}
 
-				if g.MatchString(path) {
-					for k1, v1 := range v.(map[string]interface{}) {
-						settings[k1] = v1
-					}
+			if g.MatchString(path) {
+				for k1, v1 := range v.(map[string]interface{}) {
+					settings[k1] = v1
 				}
 			}
 		}
 	}
-	return parseError
+
+	// the rules of the globs list are applied in order, so that the later
+	// rules override the earlier ones
+	abs, _ := filepath.Abs(path)
+	for i, rule := range globRules() {
+		pattern, ok := rule["glob"].(string)
+		rs, ok2 := rule["settings"].(map[string]interface{})
+		if !ok || !ok2 {
+			parseError = fmt.Errorf("Error with glob rule %d: it must have a glob and settings", i+1)
+			continue
+		}
+		g, err := glob.Compile(pattern)
+		if err != nil {
+			parseError = errors.New("Error with glob rule " + pattern + ": " + err.Error())
+			continue
+		}
+
+		if path != "" && (g.MatchString(path) || g.MatchString(abs)) {
+			for k1, v1 := range rs {
+				settings[k1] = v1
+			}
+		}
+	}
+	return settings, parseError
 }
 
 // WriteSettings writes the settings to the specified filename as JSON
@@ -183,41 +316,79 @@ func GetGlobalOption(name string) interface{} {
 }
 
 var defaultCommonSettings = map[string]interface{}{
-	"autoindent":     true,
-	"backup":         true,
-	"basename":       false,
-	"colorcolumn":    float64(0),
-	"cursorline":     true,
-	"encoding":       "utf-8",
-	"eofnewline":     false,
-	"fastdirty":      true,
-	"fileformat":     "unix",
-	"filetype":       "unknown",
-	"ignorecase":     false,
-	"indentchar":     " ",
-	"keepautoindent": false,
-	"matchbrace":     true,
-	"mkparents":      false,
-	"readonly":       false,
-	"rmtrailingws":   false,
-	"ruler":          true,
-	"savecursor":     false,
-	"saveundo":       false,
-	"scrollbar":      false,
-	"scrollmargin":   float64(3),
-	"scrollspeed":    float64(2),
-	"smartpaste":     true,
-	"softwrap":       false,
-	"splitbottom":    true,
-	"splitright":     true,
-	"statusformatl":  "$(filename) $(modified)($(line),$(col)) $(status.paste)| ft:$(opt:filetype) | $(opt:fileformat) | $(opt:encoding)",
-	"statusformatr":  "$(bind:ToggleKeyMenu): bindings, $(bind:ToggleHelp): help",
-	"statusline":     true,
-	"syntax":         true,
-	"tabmovement":    false,
-	"tabsize":        float64(4),
-	"tabstospaces":   false,
-	"useprimary":     true,
+	"autoindent":            true,
+	"autoreload":            false,
+	"backup":                true,
+	"basename":              false,
+	"centercursor":          false,
+	"colorcolumn":           "0",
+	"completeacrossbuffers": false,
+	"cursorline":            true,
+	"cursorwrap":            true,
+	"dateformat":            "2006-01-02T15:04:05Z07:00",
+	"detectindent":          false,
+	"diffgutter":            false,
+	"diffgutterbase":        "ondisk",
+	"encoding":              "utf-8",
+	"eofnewline":            false,
+	"fastdirty":             true,
+	"fileformat":            "unix",
+	"filetype":              "unknown",
+	"ignorecase":            false,
+	"indentchar":            " ",
+	"insertfinalnewline":    false,
+	"indentguides":          false,
+	"jointrim":              true,
+	"keepautoindent":        false,
+	"keymap":                "",
+	"lintcmd":               "",
+	"lintwhitespace":        false,
+	"lspcmd":                "",
+	"matchbrace":            true,
+	"middleclickpaste":      true,
+	"minimap":               false,
+	"mkparents":             false,
+	"mousescrollspeed":      float64(0),
+	"multicursortrim":       false,
+	"persistentundo":        false,
+	"readonly":              false,
+	"relativeline":          "off",
+	"rmtrailingws":          false,
+	"ruler":                 true,
+	"runcmd":                "",
+	"savecursor":            false,
+	"scrollbar":             false,
+	"scrollmargin":          float64(3),
+	"scrollspeed":           float64(2),
+	"searchpath":            "",
+	"selectwordchars":       "",
+	"showwhitespace":        false,
+	"smarthome":             true,
+	"smartindent":           false,
+	"smartpaste":            true,
+	"softwrap":              false,
+	"sortunmatched":         "bottom",
+	"spacechar":             "·",
+	"spellcheck":            false,
+	"splitbottom":           true,
+	"splitkeepempty":        true,
+	"splitkeepindent":       true,
+	"splitright":            true,
+	"statusformatl":         "$(filename) $(modified)$(rec)($(line),$(col)) $(status.paste)| ft:$(opt:filetype) | $(opt:fileformat) | $(opt:encoding)",
+	"statusformatr":         "$(bind:ToggleKeyMenu): bindings, $(bind:ToggleHelp): help",
+	"statusline":            true,
+	"syntax":                true,
+	"tabchar":               "→",
+	"tabdisplaywidth":       float64(0),
+	"tabmovement":           false,
+	"tabsize":               float64(4),
+	"tabstospaces":          false,
+	"trimfinalnewlines":     false,
+	"useprimary":            true,
+	"uuidformat":            "lower",
+	"virtualedit":           false,
+	"wrapindent":            float64(-1),
+	"wrapword":              false,
 }
 
 func GetInfoBarOffset() int {
@@ -251,6 +422,7 @@ var defaultGlobalSettings = map[string]interface{}{
 	"mouse":          true,
 	"paste":          false,
 	"savehistory":    true,
+	"shuffleseed":    float64(0),
 	"sucmd":          "sudo",
 	"pluginchannels": []string{"https://raw.githubusercontent.com/micro-editor/plugin-channel/master/channel.json"},
 	"pluginrepos":    []string{},
@@ -369,6 +541,17 @@ func validateColorscheme(option string, value interface{}) error {
 	return nil
 }
 
+func validateColorColumn(option string, value interface{}) error {
+	cols, ok := ConvertOldValue(option, value).(string)
+
+	if !ok {
+		return errors.New("Expected string type for colorcolumn")
+	}
+
+	_, err := ParseColorColumn(cols)
+	return err
+}
+
 func validateLineEnding(option string, value interface{}) error {
 	endingType, ok := value.(string)
 
@@ -383,6 +566,62 @@ func validateLineEnding(option string, value interface{}) error {
 	return nil
 }
 
+func validateRelativeLine(option string, value interface{}) error {
+	mode, ok := value.(string)
+
+	if !ok {
+		return errors.New("Expected string type for relativeline")
+	}
+
+	if mode != "off" && mode != "relative" && mode != "hybrid" {
+		return errors.New("relativeline must be either 'off', 'relative' or 'hybrid'")
+	}
+
+	return nil
+}
+
+func validateDiffGutterBase(option string, value interface{}) error {
+	base, ok := value.(string)
+
+	if !ok {
+		return errors.New("Expected string type for diffgutterbase")
+	}
+
+	if base != "ondisk" && base != "git" {
+		return errors.New("diffgutterbase must be either 'ondisk' or 'git'")
+	}
+
+	return nil
+}
+
+// validateChoice checks that the value is one of the choices of the option
+func validateChoice(option string, value interface{}) error {
+	v, ok := value.(string)
+	if !ok {
+		return errors.New("Expected string type for " + option)
+	}
+	for _, c := range optionChoices[option] {
+		if v == c {
+			return nil
+		}
+	}
+	return errors.New(option + " must be one of " + strings.Join(optionChoices[option], ", "))
+}
+
+func validateWrapIndent(option string, value interface{}) error {
+	indent, ok := value.(float64)
+
+	if !ok {
+		return errors.New("Expected numeric type for " + option)
+	}
+
+	if indent < -1 {
+		return errors.New(option + " must be -1 or greater")
+	}
+
+	return nil
+}
+
 func validateEncoding(option string, value interface{}) error {
 	_, err := htmlindex.Get(value.(string))
 	return err
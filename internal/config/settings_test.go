@@ -0,0 +1,53 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSettings(t *testing.T) {
+	old := parsedSettings
+	defer func() { parsedSettings = old }()
+	parsedSettings = map[string]interface{}{
+		"tabsize": 4.0,
+		"ft:go": map[string]interface{}{
+			"tabsize":  8.0,
+			"softwrap": true,
+		},
+		"*.go": map[string]interface{}{
+			"tabsize": 2.0,
+		},
+		"globs": []interface{}{
+			map[string]interface{}{"glob": "*/vendor/*", "settings": map[string]interface{}{"readonly": true, "softwrap": false}},
+			map[string]interface{}{"glob": "*.go", "settings": map[string]interface{}{"softwrap": true}},
+		},
+	}
+
+	settings, err := FileSettings("go", "/src/vendor/a.go")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"tabsize":  2.0,
+		"softwrap": true,
+		"readonly": true,
+	}, settings)
+
+	settings, err = FileSettings("c", "/src/vendor/a.c")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"readonly": true,
+		"softwrap": false,
+	}, settings)
+
+	InitGlobalSettings()
+	_, ok := GlobalSettings["globs"]
+	assert.False(t, ok)
+}
+
+func TestColorColumnNumber(t *testing.T) {
+	assert.Equal(t, "80", ConvertOldValue("colorcolumn", float64(80)))
+	assert.Equal(t, "80,120", ConvertOldValue("colorcolumn", "80,120"))
+	assert.Equal(t, float64(4), ConvertOldValue("tabsize", float64(4)))
+	assert.NoError(t, OptionIsValid("colorcolumn", float64(80)))
+	assert.Error(t, OptionIsValid("colorcolumn", float64(-1)))
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A SnippetStop is a numbered tab stop of an expanded snippet. It holds the
+// ranges of all the placeholders with its number, as rune offsets from the
+// start of the snippet
+type SnippetStop struct {
+	Num    int
+	Ranges [][2]int
+}
+
+// ParseSnippets parses a snippets file and returns the body of every
+// snippet by trigger. Each snippet starts with a `snippet trigger` line,
+// followed by the lines of its body indented with a tab. Lines starting
+// with # are comments
+func ParseSnippets(data []byte) map[string]string {
+	snippets := make(map[string]string)
+
+	var trigger string
+	var body []string
+	finish := func() {
+		if trigger == "" {
+			return
+		}
+		for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+			body = body[:len(body)-1]
+		}
+		snippets[trigger] = strings.Join(body, "\n")
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case strings.HasPrefix(line, "snippet "):
+			finish()
+			trigger = strings.TrimSpace(strings.TrimPrefix(line, "snippet "))
+			body = nil
+		case strings.HasPrefix(line, "\t"):
+			body = append(body, line[1:])
+		case strings.TrimSpace(line) == "":
+			body = append(body, "")
+		case strings.HasPrefix(line, "#"):
+		default:
+			// anything else ends the current snippet
+			finish()
+			trigger = ""
+		}
+	}
+	finish()
+	return snippets
+}
+
+// GetSnippets returns the snippets defined for the given filetype in
+// $(ConfigDir)/snippets/filetype.snippets
+func GetSnippets(filetype string) map[string]string {
+	f := FindRuntimeFile(RTSnippet, filetype)
+	if f == nil {
+		return nil
+	}
+	data, err := f.Data()
+	if err != nil {
+		return nil
+	}
+	return ParseSnippets(data)
+}
+
+// ExpandSnippet replaces the placeholders of a snippet body with their
+// default text and returns the resulting text with its tab stops. Numbered
+// placeholders are written $1 or ${1:default}, a placeholder used several
+// times mirrors the first one. The stops are sorted by number except for $0,
+// the final position of the cursor, which is always last and is added at the
+// end of the text if the body has none. A literal $ is written \$
+func ExpandSnippet(body string) (string, []SnippetStop) {
+	// split the body into text and placeholders
+	var parts []interface{}
+	defaults := make(map[int]string)
+	var text strings.Builder
+	for len(body) > 0 {
+		r, size := utf8.DecodeRuneInString(body)
+		if r == '\\' && strings.HasPrefix(body[size:], "$") {
+			text.WriteByte('$')
+			body = body[size+1:]
+			continue
+		}
+		if r == '$' {
+			if p, n, ok := parsePlaceholder(body); ok {
+				parts = append(parts, text.String())
+				text.Reset()
+				// the first default text of a number is used by all its placeholders
+				if def, seen := defaults[p.num]; !seen || def == "" {
+					defaults[p.num] = p.def
+				}
+				parts = append(parts, p)
+				body = body[n:]
+				continue
+			}
+		}
+		text.WriteRune(r)
+		body = body[size:]
+	}
+	parts = append(parts, text.String())
+
+	// build the text and record the ranges of the stops
+	var out strings.Builder
+	offset := 0
+	stops := make(map[int]*SnippetStop)
+	for _, p := range parts {
+		switch p := p.(type) {
+		case string:
+			out.WriteString(p)
+			offset += utf8.RuneCountInString(p)
+		case placeholder:
+			def := defaults[p.num]
+			out.WriteString(def)
+			s := stops[p.num]
+			if s == nil {
+				s = &SnippetStop{Num: p.num}
+				stops[p.num] = s
+			}
+			s.Ranges = append(s.Ranges, [2]int{offset, offset + utf8.RuneCountInString(def)})
+			offset += utf8.RuneCountInString(def)
+		}
+	}
+	if stops[0] == nil {
+		stops[0] = &SnippetStop{Num: 0, Ranges: [][2]int{{offset, offset}}}
+	}
+
+	var result []SnippetStop
+	for _, s := range stops {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Num == 0 || result[j].Num == 0 {
+			return result[j].Num == 0 && result[i].Num != 0
+		}
+		return result[i].Num < result[j].Num
+	})
+	return out.String(), result
+}
+
+type placeholder struct {
+	num int
+	def string
+}
+
+// parsePlaceholder parses the placeholder at the start of s, which starts
+// with a $, and returns it with its length in bytes
+func parsePlaceholder(s string) (placeholder, int, bool) {
+	digits := func(s string) int {
+		n := 0
+		for n < len(s) && unicode.IsDigit(rune(s[n])) {
+			n++
+		}
+		return n
+	}
+
+	if n := digits(s[1:]); n > 0 {
+		num, _ := strconv.Atoi(s[1 : 1+n])
+		return placeholder{num: num}, 1 + n, true
+	}
+
+	if !strings.HasPrefix(s, "${") {
+		return placeholder{}, 0, false
+	}
+	n := digits(s[2:])
+	if n == 0 {
+		return placeholder{}, 0, false
+	}
+	num, _ := strconv.Atoi(s[2 : 2+n])
+	rest := s[2+n:]
+	if strings.HasPrefix(rest, "}") {
+		return placeholder{num: num}, 3 + n, true
+	}
+	if !strings.HasPrefix(rest, ":") {
+		return placeholder{}, 0, false
+	}
+	end := strings.IndexByte(rest, '}')
+	if end == -1 {
+		return placeholder{}, 0, false
+	}
+	return placeholder{num: num, def: rest[1:end]}, 2 + n + end + 1, true
+}
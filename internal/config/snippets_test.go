@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSnippets(t *testing.T) {
+	snippets := ParseSnippets([]byte(`# comment
+snippet if
+	if ${1:cond} {
+		$0
+	}
+
+snippet fn
+	func $1() {
+	}
+`))
+
+	assert.Equal(t, 2, len(snippets))
+	assert.Equal(t, "if ${1:cond} {\n\t$0\n}", snippets["if"])
+	assert.Equal(t, "func $1() {\n}", snippets["fn"])
+}
+
+func TestExpandSnippet(t *testing.T) {
+	text, stops := ExpandSnippet("for ${1:i} := 0; $1 < ${2:n}; $1++ {\n\t$0\n} \\$")
+	assert.Equal(t, "for i := 0; i < n; i++ {\n\t\n} $", text)
+	assert.Equal(t, []SnippetStop{
+		{1, [][2]int{{4, 5}, {12, 13}, {19, 20}}},
+		{2, [][2]int{{16, 17}}},
+		{0, [][2]int{{26, 26}}},
+	}, stops)
+
+	text, stops = ExpandSnippet("$2 and ${1}")
+	assert.Equal(t, " and ", text)
+	assert.Equal(t, []SnippetStop{
+		{1, [][2]int{{5, 5}}},
+		{2, [][2]int{{0, 0}}},
+		{0, [][2]int{{5, 5}}},
+	}, stops)
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zyedidia/micro/internal/util"
+)
+
+// systemDictionaries are the word lists that are searched for the spell checker
+var systemDictionaries = []string{
+	"/usr/share/dict/words",
+	"/usr/dict/words",
+}
+
+// A Dictionary is a set of correctly spelled words
+type Dictionary struct {
+	sync.RWMutex
+	words map[string]bool
+}
+
+// NewDictionary creates a dictionary containing the given words
+func NewDictionary(words ...string) *Dictionary {
+	d := &Dictionary{words: make(map[string]bool)}
+	for _, w := range words {
+		d.words[w] = true
+	}
+	return d
+}
+
+// Len returns the number of words in the dictionary
+func (d *Dictionary) Len() int {
+	d.RLock()
+	defer d.RUnlock()
+	return len(d.words)
+}
+
+// Add adds a word to the dictionary
+func (d *Dictionary) Add(word string) {
+	d.Lock()
+	d.words[word] = true
+	d.Unlock()
+}
+
+// Check returns true if the word is spelled correctly. Words are also
+// accepted in lowercase, so that capitalized words at the start of a
+// sentence are not flagged
+func (d *Dictionary) Check(word string) bool {
+	d.RLock()
+	defer d.RUnlock()
+
+	if d.words[word] || d.words[strings.ToLower(word)] {
+		return true
+	}
+	if strings.HasSuffix(word, "'s") {
+		base := strings.TrimSuffix(word, "'s")
+		return d.words[base] || d.words[strings.ToLower(base)]
+	}
+	return false
+}
+
+// Suggest returns at most max words of the dictionary that are close to
+// the given word, the closest first
+func (d *Dictionary) Suggest(word string, max int) []string {
+	d.RLock()
+	defer d.RUnlock()
+
+	type suggestion struct {
+		word string
+		dist int
+	}
+
+	lower := strings.ToLower(word)
+	length := utf8.RuneCountInString(lower)
+	seen := make(map[string]bool)
+	var suggestions []suggestion
+	for w := range d.words {
+		l := strings.ToLower(w)
+		if seen[l] || l == lower {
+			continue
+		}
+		if diff := utf8.RuneCountInString(l) - length; diff > 2 || diff < -2 {
+			continue
+		}
+		if dist := editDistance(lower, l); dist <= 2 {
+			seen[l] = true
+			suggestions = append(suggestions, suggestion{w, dist})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].dist != suggestions[j].dist {
+			return suggestions[i].dist < suggestions[j].dist
+		}
+		return suggestions[i].word < suggestions[j].word
+	})
+
+	// keep the capitalization of the misspelled word
+	r, _ := utf8.DecodeRuneInString(word)
+	capital := unicode.IsUpper(r)
+
+	var result []string
+	for i := 0; i < len(suggestions) && i < max; i++ {
+		s := suggestions[i].word
+		if capital {
+			r, size := utf8.DecodeRuneInString(s)
+			s = string(unicode.ToUpper(r)) + s[size:]
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// editDistance returns the Damerau-Levenshtein distance (optimal string
+// alignment) between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = util.Min(util.Min(d[i-1][j]+1, d[i][j-1]+1), d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = util.Min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+var (
+	dictionary     *Dictionary
+	dictionaryOnce sync.Once
+	// dictionaryLoaded is closed once the dictionary is loaded
+	dictionaryLoaded = make(chan struct{})
+)
+
+// PersonalDictionary returns the path of the file holding the words the
+// user added to the dictionary
+func PersonalDictionary() string {
+	return filepath.Join(ConfigDir, "dictionary")
+}
+
+// loadDictionary starts loading the dictionary in the background the first
+// time it is called, and then calls done
+func loadDictionary(done func()) {
+	dictionaryOnce.Do(func() {
+		go func() {
+			d := NewDictionary()
+			for _, f := range systemDictionaries {
+				if readWords(d, f) == nil {
+					break
+				}
+			}
+			readWords(d, PersonalDictionary())
+			dictionary = d
+			close(dictionaryLoaded)
+			if done != nil {
+				done()
+			}
+		}()
+	})
+}
+
+// GetDictionary returns the dictionary used by the spell checker. It is
+// made of the system word list and the personal dictionary, and is
+// loaded the first time it is needed, which this waits for
+func GetDictionary() *Dictionary {
+	loadDictionary(nil)
+	<-dictionaryLoaded
+	return dictionary
+}
+
+// LoadedDictionary returns the dictionary used by the spell checker if it
+// is loaded. Otherwise it returns nil and the dictionary is loaded in the
+// background, after which done is called
+func LoadedDictionary(done func()) *Dictionary {
+	loadDictionary(done)
+	select {
+	case <-dictionaryLoaded:
+		return dictionary
+	default:
+		return nil
+	}
+}
+
+func readWords(d *Dictionary, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if w := strings.TrimSpace(scanner.Text()); w != "" {
+			d.words[w] = true
+		}
+	}
+	return scanner.Err()
+}
+
+// AddToDictionary adds a word to the dictionary and saves it in the
+// personal dictionary
+func AddToDictionary(word string) error {
+	GetDictionary().Add(word)
+
+	f, err := os.OpenFile(PersonalDictionary(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(word + "\n")
+	return err
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDictionaryCheck(t *testing.T) {
+	d := NewDictionary("hello", "world", "Micro")
+
+	assert.True(t, d.Check("hello"))
+	assert.True(t, d.Check("Hello"))
+	assert.True(t, d.Check("Micro"))
+	assert.True(t, d.Check("world's"))
+	assert.False(t, d.Check("helo"))
+	assert.False(t, d.Check("micro"))
+}
+
+func TestDictionarySuggest(t *testing.T) {
+	d := NewDictionary("hello", "help", "world", "word", "sword")
+
+	assert.Equal(t, []string{"hello", "help"}, d.Suggest("helo", 5))
+	assert.Equal(t, []string{"World", "Word"}, d.Suggest("Wrold", 2))
+	assert.Empty(t, d.Suggest("xyz", 5))
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("same", "same"))
+	assert.Equal(t, 1, editDistance("teh", "the"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+}
+
+func TestLoadedDictionary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "micro-dict")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	words := filepath.Join(dir, "words")
+	assert.NoError(t, ioutil.WriteFile(words, []byte("hello\nworld\n"), 0644))
+
+	oldDicts, oldDir := systemDictionaries, ConfigDir
+	systemDictionaries, ConfigDir = []string{words}, dir
+	defer func() { systemDictionaries, ConfigDir = oldDicts, oldDir }()
+
+	// the dictionary is loaded in the background
+	loaded := make(chan bool, 1)
+	LoadedDictionary(func() { loaded <- true })
+	<-loaded
+	d := LoadedDictionary(nil)
+	assert.NotNil(t, d)
+	assert.True(t, d.Check("hello"))
+	assert.Equal(t, d, GetDictionary())
+}
@@ -1,7 +1,10 @@
 package display
 
 import (
+	"bytes"
 	"strconv"
+	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	runewidth "github.com/mattn/go-runewidth"
@@ -66,7 +69,7 @@ func (w *BufWindow) IsActive() bool {
 }
 
 func (w *BufWindow) getStartInfo(n, lineN int) ([]byte, int, int, *tcell.Style) {
-	tabsize := util.IntOpt(w.Buf.Settings["tabsize"])
+	tabsize := w.Buf.TabWidth()
 	width := 0
 	bloc := buffer.Loc{0, lineN}
 	b := w.Buf.LineBytes(lineN)
@@ -107,6 +110,19 @@ func (w *BufWindow) Clear() {
 	}
 }
 
+// bufWidth returns the number of columns available for the text of the
+// buffer, which excludes the scrollbar and the minimap
+func (w *BufWindow) bufWidth() int {
+	bufWidth := w.Width
+	if w.Buf.Settings["scrollbar"].(bool) && w.Buf.LinesNum() > w.Height {
+		bufWidth--
+	}
+	if w.hasMinimap() {
+		bufWidth -= minimapWidth
+	}
+	return bufWidth
+}
+
 // Bottomline returns the line number of the lowest line in the view
 // You might think that this is obviously just v.StartLine + v.Height
 // but if softwrap is enabled things get complicated since one buffer
@@ -143,19 +159,28 @@ func (w *BufWindow) Relocate() bool {
 	activeC := w.Buf.GetActiveCursor()
 	cy := activeC.Y
 	scrollmargin := int(b.Settings["scrollmargin"].(float64))
-	if cy < w.StartLine+scrollmargin && cy > scrollmargin-1 {
-		w.StartLine = cy - scrollmargin
-		ret = true
-	} else if cy < w.StartLine {
-		w.StartLine = cy
-		ret = true
-	}
-	if cy > w.StartLine+height-1-scrollmargin && cy < b.LinesNum()-scrollmargin {
-		w.StartLine = cy - height + 1 + scrollmargin
-		ret = true
-	} else if cy >= b.LinesNum()-scrollmargin && cy >= height {
-		w.StartLine = b.LinesNum() - height
-		ret = true
+	if b.Settings["centercursor"].(bool) {
+		// the view stops scrolling at the start and the end of the buffer
+		start := util.Clamp(cy-height/2, 0, util.Max(b.LinesNum()-height, 0))
+		if start != w.StartLine {
+			w.StartLine = start
+			ret = true
+		}
+	} else {
+		if cy < w.StartLine+scrollmargin && cy > scrollmargin-1 {
+			w.StartLine = cy - scrollmargin
+			ret = true
+		} else if cy < w.StartLine {
+			w.StartLine = cy
+			ret = true
+		}
+		if cy > w.StartLine+height-1-scrollmargin && cy < b.LinesNum()-scrollmargin {
+			w.StartLine = cy - height + 1 + scrollmargin
+			ret = true
+		} else if cy >= b.LinesNum()-scrollmargin && cy >= height {
+			w.StartLine = b.LinesNum() - height
+			ret = true
+		}
 	}
 
 	// horizontal relocation (scrolling)
@@ -165,8 +190,8 @@ func (w *BufWindow) Relocate() bool {
 			w.StartCol = cx
 			ret = true
 		}
-		if cx+w.gutterOffset+1 > w.StartCol+w.Width {
-			w.StartCol = cx - w.Width + w.gutterOffset + 1
+		if cx+w.gutterOffset+1 > w.StartCol+w.bufWidth() {
+			w.StartCol = cx - w.bufWidth() + w.gutterOffset + 1
 			ret = true
 		}
 	}
@@ -182,23 +207,21 @@ func (w *BufWindow) Relocate() bool {
 func (w *BufWindow) LocFromVisual(svloc buffer.Loc) buffer.Loc {
 	b := w.Buf
 
-	hasMessage := len(b.Messages) > 0
+	hasMessage := len(b.Messages) > 0 || b.Settings["lintwhitespace"].(bool)
 	bufHeight := w.Height
 	if w.drawStatus {
 		bufHeight--
 	}
 
-	bufWidth := w.Width
-	if w.Buf.Settings["scrollbar"].(bool) && w.Buf.LinesNum() > w.Height {
-		bufWidth--
-	}
+	bufWidth := w.bufWidth()
 
 	// We need to know the string length of the largest line number
 	// so we can pad appropriately when displaying line numbers
 	maxLineNumLength := len(strconv.Itoa(b.LinesNum()))
 
-	tabsize := int(b.Settings["tabsize"].(float64))
+	tabsize := b.TabWidth()
 	softwrap := b.Settings["softwrap"].(bool)
+	wrapword := softwrap && b.Settings["wrapword"].(bool)
 
 	// this represents the current draw position
 	// within the current window
@@ -207,14 +230,48 @@ func (w *BufWindow) LocFromVisual(svloc buffer.Loc) buffer.Loc {
 	// this represents the current draw position in the buffer (char positions)
 	bloc := buffer.Loc{X: -1, Y: w.StartLine}
 
+	diffgutter := b.Settings["diffgutter"].(bool)
+	showBlame := b.ShowingBlame()
+
 	for vloc.Y = 0; vloc.Y < bufHeight; vloc.Y++ {
 		vloc.X = 0
+		if showBlame {
+			vloc.X += blameWidth
+		}
+		if diffgutter {
+			vloc.X++
+		}
 		if hasMessage {
 			vloc.X += 2
 		}
 		if b.Settings["ruler"].(bool) {
 			vloc.X += maxLineNumLength + 1
 		}
+		wrapIndent := w.wrapIndent(bloc.Y, bufWidth-vloc.X)
+
+		// rows created by softwrap start at contStart
+		contStart := wrapIndent
+		if showBlame {
+			contStart += blameWidth
+		}
+		if diffgutter {
+			contStart++
+		}
+		if b.Settings["ruler"].(bool) {
+			contStart += maxLineNumLength + 1
+		}
+		rowStart := vloc.X
+		prevSpace := false
+
+		wrapRow := func() bool {
+			vloc.Y++
+			if vloc.Y >= bufHeight {
+				return false
+			}
+			vloc.X = contStart
+			rowStart = vloc.X
+			return true
+		}
 
 		line := b.LineBytes(bloc.Y)
 		line, nColsBeforeStart, bslice := util.SliceVisualEnd(line, w.StartCol, tabsize)
@@ -238,6 +295,21 @@ func (w *BufWindow) LocFromVisual(svloc buffer.Loc) buffer.Loc {
 			}
 
 			r, size := utf8.DecodeRune(line)
+			space := r == ' ' || r == '\t'
+
+			// break before a word that does not fit in the rest of the row
+			if wrapword && prevSpace && !space && vloc.X > rowStart {
+				if ww := wordWidth(line); vloc.X+ww > bufWidth && ww <= bufWidth-contStart {
+					if vloc.Y+w.Y == svloc.Y {
+						return bloc
+					}
+					if !wrapRow() {
+						break
+					}
+				}
+			}
+			prevSpace = space
+
 			draw()
 			width := 0
 
@@ -265,18 +337,11 @@ func (w *BufWindow) LocFromVisual(svloc buffer.Loc) buffer.Loc {
 
 			// If we reach the end of the window then we either stop or we wrap for softwrap
 			if vloc.X >= bufWidth {
-				if !softwrap {
+				if !softwrap || !wrapRow() {
 					break
-				} else {
-					vloc.Y++
-					if vloc.Y >= bufHeight {
-						break
-					}
-					vloc.X = 0
-					// This will draw an empty line number because the current line is wrapped
-					if b.Settings["ruler"].(bool) {
-						vloc.X += maxLineNumLength + 1
-					}
+				}
+				if vloc.Y+w.Y == svloc.Y && svloc.X < vloc.X+w.X {
+					return bloc
 				}
 			}
 		}
@@ -295,6 +360,9 @@ func (w *BufWindow) LocFromVisual(svloc buffer.Loc) buffer.Loc {
 	return buffer.Loc{}
 }
 
+// blameWidth is the width of the margin showing the git blame of the lines
+const blameWidth = 20
+
 func (w *BufWindow) drawGutter(vloc *buffer.Loc, bloc *buffer.Loc) {
 	char := ' '
 	s := config.DefStyle
@@ -305,14 +373,95 @@ func (w *BufWindow) drawGutter(vloc *buffer.Loc, bloc *buffer.Loc) {
 			break
 		}
 	}
+	if char == ' ' && w.Buf.Settings["lintwhitespace"].(bool) {
+		if _, ok := util.WhitespaceIssue(w.Buf.LineBytes(bloc.Y)); ok {
+			if style, ok := config.Colorscheme["gutter-warning"]; ok {
+				s = style
+			}
+			char = '~'
+		}
+	}
 	screen.SetContent(w.X+vloc.X, w.Y+vloc.Y, char, nil, s)
 	vloc.X++
 	screen.SetContent(w.X+vloc.X, w.Y+vloc.Y, char, nil, s)
 	vloc.X++
 }
 
+// drawBlame draws the short hash and the author of the commit which last
+// changed the line in the blame margin
+func (w *BufWindow) drawBlame(softwrapped bool, lineNumStyle tcell.Style, vloc *buffer.Loc, bloc *buffer.Loc) {
+	var text []rune
+	if !softwrapped {
+		if bl := w.Buf.BlameAt(bloc.Y); bl != nil {
+			text = []rune(bl.Hash[:7] + " " + bl.Author)
+		} else {
+			text = []rune("uncommitted")
+		}
+	}
+
+	s := lineNumStyle
+	if style, ok := config.Colorscheme["git-blame"]; ok {
+		s = style
+	}
+	// the last column separates the margin from the text
+	for i := 0; i < blameWidth; i++ {
+		r := ' '
+		if i < len(text) && i < blameWidth-1 {
+			r = text[i]
+		}
+		screen.SetContent(w.X+vloc.X, w.Y+vloc.Y, r, nil, s)
+		vloc.X++
+	}
+}
+
+// drawDiffGutter draws the marker showing whether the line was added,
+// modified or had lines deleted around it compared with the diff base
+func (w *BufWindow) drawDiffGutter(softwrapped bool, vloc *buffer.Loc, bloc *buffer.Loc) {
+	char := ' '
+	group := ""
+	var color tcell.Color
+	switch w.Buf.DiffStatus(bloc.Y) {
+	case buffer.DSAdded:
+		char, group, color = '▎', "diff-added", tcell.ColorGreen
+	case buffer.DSModified:
+		char, group, color = '▎', "diff-modified", tcell.ColorYellow
+	case buffer.DSDeletedAbove:
+		if !softwrapped {
+			char, group, color = '▔', "diff-deleted", tcell.ColorRed
+		}
+	case buffer.DSDeletedBelow:
+		if !softwrapped {
+			char, group, color = '▁', "diff-deleted", tcell.ColorRed
+		}
+	}
+
+	s := config.DefStyle
+	if group != "" {
+		if style, ok := config.Colorscheme[group]; ok {
+			color, _, _ = style.Decompose()
+		}
+		s = s.Foreground(color)
+	}
+	screen.SetContent(w.X+vloc.X, w.Y+vloc.Y, char, nil, s)
+	vloc.X++
+}
+
 func (w *BufWindow) drawLineNum(lineNumStyle tcell.Style, softwrapped bool, maxLineNumLength int, vloc *buffer.Loc, bloc *buffer.Loc) {
-	lineNum := strconv.Itoa(bloc.Y + 1)
+	cursorY := w.Buf.GetActiveCursor().Y
+
+	var lineNum string
+	switch w.Buf.Settings["relativeline"].(string) {
+	case "relative":
+		lineNum = strconv.Itoa(util.Abs(bloc.Y - cursorY))
+	case "hybrid":
+		if bloc.Y == cursorY {
+			lineNum = strconv.Itoa(bloc.Y + 1)
+		} else {
+			lineNum = strconv.Itoa(util.Abs(bloc.Y - cursorY))
+		}
+	default:
+		lineNum = strconv.Itoa(bloc.Y + 1)
+	}
 
 	// Write the spaces before the line number if necessary
 	for i := 0; i < maxLineNumLength-len(lineNum); i++ {
@@ -344,6 +493,82 @@ func (w *BufWindow) getStyle(style tcell.Style, bloc buffer.Loc, r rune) (tcell.
 	return style, false
 }
 
+// indentGuideSearch is the number of lines searched above and below a
+// blank line for the indentation of its indent guides
+const indentGuideSearch = 100
+
+// indentGuideWidth returns the visual width up to which indent guides should
+// be drawn on the given line. Blank lines use the deepest indentation of the
+// closest non-blank lines above and below so that guides are not interrupted.
+// The second value is the line after the run of blank lines containing y,
+// whose lines have the same width
+func (w *BufWindow) indentGuideWidth(y, tabsize int) (int, int) {
+	b := w.Buf
+	indent := func(l []byte) int {
+		ws := util.GetLeadingWhitespace(l)
+		return util.StringWidth(ws, utf8.RuneCount(ws), tabsize)
+	}
+
+	if l := b.LineBytes(y); !util.IsBytesWhitespace(l) {
+		return indent(l), y + 1
+	}
+
+	width, end := 0, y+1
+	for _, dir := range []int{-1, 1} {
+		i := y + dir
+		for ; i >= 0 && i < b.LinesNum() && util.Abs(i-y) <= indentGuideSearch; i += dir {
+			if l := b.LineBytes(i); !util.IsBytesWhitespace(l) {
+				width = util.Max(width, indent(l))
+				break
+			}
+		}
+		if dir > 0 {
+			end = i
+		}
+	}
+	return width, end
+}
+
+// wrapIndent returns the number of columns by which the rows created by
+// softwrapping line y are indented, given the width available for the text
+func (w *BufWindow) wrapIndent(y, width int) int {
+	b := w.Buf
+	extra := util.IntOpt(b.Settings["wrapindent"])
+	if !b.Settings["softwrap"].(bool) || extra < 0 {
+		return 0
+	}
+
+	tabsize := b.TabWidth()
+	ws := util.GetLeadingWhitespace(b.LineBytes(y))
+	indent := util.StringWidth(ws, utf8.RuneCount(ws), tabsize) + extra
+
+	// always leave at least half of the row for the text
+	return util.Max(0, util.Min(indent, width/2))
+}
+
+// wordWidth returns the visual width of the word at the start of line
+func wordWidth(line []byte) int {
+	width := 0
+	for len(line) > 0 {
+		r, size := utf8.DecodeRune(line)
+		if r == ' ' || r == '\t' {
+			break
+		}
+		width += runewidth.RuneWidth(r)
+		line = line[size:]
+	}
+	return width
+}
+
+// whitespaceRune returns the first rune of the given setting value or
+// the default rune if the setting is empty
+func whitespaceRune(setting string, def rune) rune {
+	if r, size := utf8.DecodeRuneInString(setting); size > 0 && r != utf8.RuneError {
+		return r
+	}
+	return def
+}
+
 func (w *BufWindow) showCursor(x, y int, main bool) {
 	if w.active {
 		if main {
@@ -362,16 +587,15 @@ func (w *BufWindow) displayBuffer() {
 		return
 	}
 
-	hasMessage := len(b.Messages) > 0
+	hasMessage := len(b.Messages) > 0 || b.Settings["lintwhitespace"].(bool)
+	diffgutter := b.Settings["diffgutter"].(bool)
+	showBlame := b.ShowingBlame()
 	bufHeight := w.Height
 	if w.drawStatus {
 		bufHeight--
 	}
 
-	bufWidth := w.Width
-	if w.Buf.Settings["scrollbar"].(bool) && w.Buf.LinesNum() > w.Height {
-		bufWidth--
-	}
+	bufWidth := w.bufWidth()
 
 	if b.Settings["syntax"].(bool) && b.SyntaxDef != nil {
 		for _, r := range b.Modifications {
@@ -428,8 +652,55 @@ func (w *BufWindow) displayBuffer() {
 	maxLineNumLength := len(strconv.Itoa(b.LinesNum()))
 
 	softwrap := b.Settings["softwrap"].(bool)
-	tabsize := util.IntOpt(b.Settings["tabsize"])
-	colorcolumn := util.IntOpt(b.Settings["colorcolumn"])
+	wrapword := softwrap && b.Settings["wrapword"].(bool)
+	tabsize := b.TabWidth()
+	// the guides follow the indentation, which is made of tabs or of
+	// tabsize spaces
+	guidestep := tabsize
+	if b.Settings["tabstospaces"].(bool) {
+		guidestep = util.IntOpt(b.Settings["tabsize"])
+	}
+	colorcolumns, _ := config.ParseColorColumn(b.Settings["colorcolumn"].(string))
+	isColorColumn := func(col int) bool {
+		for _, c := range colorcolumns {
+			if c != 0 && col == c {
+				return true
+			}
+		}
+		return false
+	}
+
+	indentguides := b.Settings["indentguides"].(bool)
+	// the width of the guides of the run of blank lines before blankEnd
+	blankWidth, blankEnd := 0, -1
+	guideStyle := func(style tcell.Style) tcell.Style {
+		if s, ok := config.Colorscheme["indent-guide"]; ok {
+			fg, _, _ := s.Decompose()
+			return style.Foreground(fg)
+		} else if s, ok := config.Colorscheme["indent-char"]; ok {
+			fg, _, _ := s.Decompose()
+			return style.Foreground(fg)
+		}
+		return style
+	}
+
+	showwhitespace := b.Settings["showwhitespace"].(bool)
+	spacechar := whitespaceRune(b.Settings["spacechar"].(string), '·')
+	tabchar := whitespaceRune(b.Settings["tabchar"].(string), '→')
+
+	// spell checking covers the whole buffer for prose and only the
+	// comments for code
+	var dict *config.Dictionary
+	if b.Settings["spellcheck"].(bool) {
+		// the dictionary is loaded in the background the first time, and
+		// the words are checked once it is there
+		if d := config.LoadedDictionary(screen.Redraw); d != nil && d.Len() > 0 {
+			dict = d
+		}
+	}
+	ft := b.Settings["filetype"].(string)
+	spellAll := b.SyntaxDef == nil || ft == "markdown" || ft == "unknown"
+	curGroup := ""
 
 	// this represents the current draw position
 	// within the current window
@@ -444,6 +715,14 @@ func (w *BufWindow) displayBuffer() {
 	for vloc.Y = 0; vloc.Y < bufHeight; vloc.Y++ {
 		vloc.X = 0
 
+		if showBlame {
+			w.drawBlame(false, lineNumStyle, &vloc, &bloc)
+		}
+
+		if diffgutter {
+			w.drawDiffGutter(false, &vloc, &bloc)
+		}
+
 		if hasMessage {
 			w.drawGutter(&vloc, &bloc)
 		}
@@ -460,6 +739,7 @@ func (w *BufWindow) displayBuffer() {
 		}
 
 		w.gutterOffset = vloc.X
+		wrapIndent := w.wrapIndent(bloc.Y, bufWidth-w.gutterOffset)
 
 		line, nColsBeforeStart, bslice, startStyle := w.getStartInfo(w.StartCol, bloc.Y)
 		if startStyle != nil {
@@ -467,8 +747,114 @@ func (w *BufWindow) displayBuffer() {
 		}
 		bloc.X = bslice
 
+		// cursorLine is true if this line should get the cursor-line highlight
+		cursorLine := false
+		if b.Settings["cursorline"].(bool) && w.active {
+			for _, c := range cursors {
+				if !c.HasSelection() && c.Y == bloc.Y {
+					cursorLine = true
+					break
+				}
+			}
+		}
+
+		lineStyle := config.DefStyle
+		if cursorLine {
+			if s, ok := config.Colorscheme["cursor-line"]; ok {
+				fg, _, _ := s.Decompose()
+				lineStyle = lineStyle.Background(fg)
+			}
+		}
+
+		// rows created by softwrap start at contStart
+		contStart := wrapIndent
+		if showBlame {
+			contStart += blameWidth
+		}
+		if diffgutter {
+			contStart++
+		}
+		if b.Settings["ruler"].(bool) {
+			contStart += maxLineNumLength + 1
+		}
+		rowStart := vloc.X
+		prevSpace := false
+
+		wrapRow := func() bool {
+			vloc.Y++
+			if vloc.Y >= bufHeight {
+				return false
+			}
+			vloc.X = 0
+			if showBlame {
+				w.drawBlame(true, lineNumStyle, &vloc, &bloc)
+			}
+			if diffgutter {
+				w.drawDiffGutter(true, &vloc, &bloc)
+			}
+			// This will draw an empty line number because the current line is wrapped
+			if b.Settings["ruler"].(bool) {
+				w.drawLineNum(lineNumStyle, true, maxLineNumLength, &vloc, &bloc)
+			}
+			for i := 0; i < wrapIndent; i++ {
+				screen.SetContent(w.X+vloc.X, w.Y+vloc.Y, ' ', nil, lineStyle)
+				vloc.X++
+			}
+			rowStart = vloc.X
+			return true
+		}
+
+		// the chars before misspelledEnd belong to a misspelled word
+		misspelledEnd := -1
+		prevWordChar := false
+
+		// vcol is the visual column within the line (with tabs expanded)
+		// of the next character to be drawn
+		vcol := w.StartCol - nColsBeforeStart
+
+		// the trailing whitespace of the line spans the char positions
+		// from trailingStart up to lineLen
+		var trailingStart, lineLen int
+		if showwhitespace {
+			l := b.LineBytes(bloc.Y)
+			lineLen = utf8.RuneCount(l)
+			trailingStart = utf8.RuneCount(bytes.TrimRightFunc(l, unicode.IsSpace))
+		}
+
+		// indent guides are drawn in the leading whitespace of the line
+		// at every tabstop before the guide width
+		var leadingLen, guideWidth int
+		blank := false
+		if indentguides {
+			l := b.LineBytes(bloc.Y)
+			blank = util.IsBytesWhitespace(l)
+			leadingLen = utf8.RuneCount(util.GetLeadingWhitespace(l))
+			if blank && bloc.Y < blankEnd {
+				guideWidth = blankWidth
+			} else {
+				var end int
+				guideWidth, end = w.indentGuideWidth(bloc.Y, tabsize)
+				if blank {
+					blankWidth, blankEnd = guideWidth, end
+				}
+			}
+		}
+		isGuide := func(x, col int) bool {
+			return indentguides && x < leadingLen && col < guideWidth && col%guidestep == 0
+		}
+
 		draw := func(r rune, style tcell.Style, showcursor bool) {
 			if nColsBeforeStart <= 0 {
+				if showwhitespace && bloc.X >= trailingStart && bloc.X < lineLen {
+					if s, ok := config.Colorscheme["trailing-whitespace"]; ok {
+						fg, _, _ := s.Decompose()
+						style = style.Background(fg)
+					} else {
+						style = style.Reverse(true)
+					}
+				}
+
+				selected := false
 				for _, c := range cursors {
 					if c.HasSelection() &&
 						(bloc.GreaterEqual(c.CurSelection[0]) && bloc.LessThan(c.CurSelection[1]) ||
@@ -479,15 +865,25 @@ func (w *BufWindow) displayBuffer() {
 						if s, ok := config.Colorscheme["selection"]; ok {
 							style = s
 						}
+						selected = true
 					}
+				}
 
-					if b.Settings["cursorline"].(bool) && w.active &&
-						!c.HasSelection() && c.Y == bloc.Y {
-						if s, ok := config.Colorscheme["cursor-line"]; ok {
-							fg, _, _ := s.Decompose()
-							style = style.Background(fg)
-						}
+				// Every cursor without a selection highlights its line, but
+				// the highlight must not hide another cursor's selection
+				if cursorLine && !selected {
+					if s, ok := config.Colorscheme["cursor-line"]; ok {
+						fg, _, _ := s.Decompose()
+						style = style.Background(fg)
+					}
+				}
+
+				if bloc.X < misspelledEnd {
+					if s, ok := config.Colorscheme["spell-error"]; ok {
+						fg, _, _ := s.Decompose()
+						style = style.Foreground(fg)
 					}
+					style = style.Underline(true)
 				}
 
 				for _, m := range b.Messages {
@@ -513,7 +909,7 @@ func (w *BufWindow) displayBuffer() {
 				}
 
 				if s, ok := config.Colorscheme["color-column"]; ok {
-					if colorcolumn != 0 && vloc.X-w.gutterOffset == colorcolumn {
+					if isColorColumn(vcol) {
 						fg, _, _ := s.Decompose()
 						style = style.Background(fg)
 					}
@@ -530,21 +926,70 @@ func (w *BufWindow) displayBuffer() {
 				if showcursor {
 					for _, c := range cursors {
 						if c.X == bloc.X && c.Y == bloc.Y && !c.HasSelection() {
-							w.showCursor(w.X+vloc.X, w.Y+vloc.Y, c.Num == 0)
+							// past the end of the line with virtualedit
+							x := util.Min(vloc.X+c.Virtual, bufWidth-1)
+							w.showCursor(w.X+x, w.Y+vloc.Y, c.Num == 0)
 						}
 					}
 				}
 				vloc.X++
 			}
 			nColsBeforeStart--
+			vcol++
 		}
 
 		totalwidth := w.StartCol - nColsBeforeStart
 		for len(line) > 0 {
 			r, size := utf8.DecodeRune(line)
+			space := r == ' ' || r == '\t'
+
+			// break before a word that does not fit in the rest of the row
+			if wrapword && prevSpace && !space && vloc.X > rowStart {
+				if ww := wordWidth(line); vloc.X+ww > bufWidth && ww <= bufWidth-contStart {
+					for ; vloc.X < bufWidth; vloc.X++ {
+						screen.SetContent(w.X+vloc.X, w.Y+vloc.Y, ' ', nil, lineStyle)
+					}
+					if !wrapRow() {
+						break
+					}
+				}
+			}
+			prevSpace = space
+
 			curStyle, _ = w.getStyle(curStyle, bloc, r)
 
-			draw(r, curStyle, true)
+			if dict != nil {
+				if g, ok := b.Match(bloc.Y)[bloc.X]; ok {
+					curGroup = g.String()
+				}
+				if !prevWordChar && unicode.IsLetter(r) && (spellAll || strings.HasPrefix(curGroup, "comment")) {
+					word := util.SpellWord(line)
+					if n := utf8.RuneCount(word); n > 1 && !dict.Check(string(word)) {
+						misspelledEnd = bloc.X + n
+					}
+				}
+				prevWordChar = util.IsWordChar(r) || r == '\''
+			}
+
+			if isGuide(bloc.X, totalwidth) {
+				draw('│', guideStyle(curStyle), true)
+			} else if showwhitespace && (r == ' ' || r == '\t') {
+				style := curStyle
+				if s, ok := config.Colorscheme["whitespace"]; ok {
+					fg, _, _ := s.Decompose()
+					style = style.Foreground(fg)
+				} else if s, ok := config.Colorscheme["indent-char"]; ok {
+					fg, _, _ := s.Decompose()
+					style = style.Foreground(fg)
+				}
+				if r == ' ' {
+					draw(spacechar, style, true)
+				} else {
+					draw(tabchar, style, true)
+				}
+			} else {
+				draw(r, curStyle, true)
+			}
 
 			width := 0
 
@@ -561,7 +1006,11 @@ func (w *BufWindow) displayBuffer() {
 			// Draw any extra characters either spaces for tabs or @ for incomplete wide runes
 			if width > 1 {
 				for i := 1; i < width; i++ {
-					draw(char, curStyle, false)
+					if r == '\t' && isGuide(bloc.X, totalwidth+i) {
+						draw('│', guideStyle(curStyle), false)
+					} else {
+						draw(char, curStyle, false)
+					}
 				}
 			}
 			bloc.X++
@@ -571,41 +1020,30 @@ func (w *BufWindow) displayBuffer() {
 
 			// If we reach the end of the window then we either stop or we wrap for softwrap
 			if vloc.X >= bufWidth {
-				if !softwrap {
+				if !softwrap || !wrapRow() {
 					break
-				} else {
-					vloc.Y++
-					if vloc.Y >= bufHeight {
-						break
-					}
-					vloc.X = 0
-					// This will draw an empty line number because the current line is wrapped
-					if b.Settings["ruler"].(bool) {
-						w.drawLineNum(lineNumStyle, true, maxLineNumLength, &vloc, &bloc)
-					}
 				}
 			}
 		}
 
-		style := config.DefStyle
-		for _, c := range cursors {
-			if b.Settings["cursorline"].(bool) && w.active &&
-				!c.HasSelection() && c.Y == bloc.Y {
-				if s, ok := config.Colorscheme["cursor-line"]; ok {
-					fg, _, _ := s.Decompose()
-					style = style.Background(fg)
-				}
-			}
-		}
 		for i := vloc.X; i < bufWidth; i++ {
-			curStyle := style
+			curStyle := lineStyle
 			if s, ok := config.Colorscheme["color-column"]; ok {
-				if colorcolumn != 0 && i-w.gutterOffset == colorcolumn {
+				if isColorColumn(vcol + i - vloc.X) {
 					fg, _, _ := s.Decompose()
-					curStyle = style.Background(fg)
+					curStyle = lineStyle.Background(fg)
+				}
+			}
+			r := ' '
+			if blank && !softwrap {
+				// blank lines continue the guides of the surrounding lines
+				col := w.StartCol + i - w.gutterOffset
+				if col < guideWidth && col%guidestep == 0 {
+					r = '│'
+					curStyle = guideStyle(curStyle)
 				}
 			}
-			screen.SetContent(i+w.X, vloc.Y+w.Y, ' ', nil, curStyle)
+			screen.SetContent(i+w.X, vloc.Y+w.Y, r, nil, curStyle)
 		}
 
 		if vloc.X != bufWidth {
@@ -662,5 +1100,6 @@ func (w *BufWindow) displayScrollBar() {
 func (w *BufWindow) Display() {
 	w.displayStatusLine()
 	w.displayScrollBar()
+	w.displayMinimap()
 	w.displayBuffer()
 }
@@ -0,0 +1,105 @@
+package display
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/screen"
+	"github.com/zyedidia/micro/internal/util"
+)
+
+// gitRefreshInterval is how long the git information of a directory is
+// cached before it is fetched again
+const gitRefreshInterval = 5 * time.Second
+
+// gitStatus holds the cached git information for a directory
+type gitStatus struct {
+	branch string
+	dirty  bool
+
+	// when the information was last fetched
+	updated time.Time
+	// the modification time of the buffer when the information was fetched
+	// so that saving the buffer refreshes it
+	modTime  time.Time
+	updating bool
+}
+
+var (
+	gitCache = make(map[string]*gitStatus)
+	gitLock  sync.Mutex
+)
+
+// readGitBranch returns the checked out branch of the given git directory,
+// or the abbreviated commit hash if the HEAD is detached
+func readGitBranch(gitDir string) string {
+	data, err := ioutil.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	head := strings.TrimSpace(string(data))
+	if strings.HasPrefix(head, "ref:") {
+		ref := strings.TrimSpace(head[len("ref:"):])
+		return strings.TrimPrefix(ref, "refs/heads/")
+	}
+	if len(head) > 7 {
+		return head[:7]
+	}
+	return head
+}
+
+// fetchGitStatus reads the git information of the given directory and
+// stores it in the cache
+func fetchGitStatus(dir string, st *gitStatus) {
+	var branch string
+	var dirty bool
+
+	if root, gitDir, err := util.GitRepo(dir); err == nil {
+		branch = readGitBranch(gitDir)
+
+		cmd := exec.Command("git", "status", "--porcelain")
+		cmd.Dir = root
+		if out, err := cmd.Output(); err == nil {
+			dirty = len(strings.TrimSpace(string(out))) > 0
+		}
+	}
+
+	gitLock.Lock()
+	st.branch = branch
+	st.dirty = dirty
+	st.updated = time.Now()
+	st.updating = false
+	gitLock.Unlock()
+
+	screen.Redraw()
+}
+
+// getGitStatus returns the cached git information of the buffer's directory
+// and starts fetching it in the background if it is out of date
+func getGitStatus(b *buffer.Buffer) (string, bool) {
+	if b.AbsPath == "" || b.Type != buffer.BTDefault {
+		return "", false
+	}
+	dir := filepath.Dir(b.AbsPath)
+
+	gitLock.Lock()
+	defer gitLock.Unlock()
+
+	st, ok := gitCache[dir]
+	if !ok {
+		st = new(gitStatus)
+		gitCache[dir] = st
+	}
+	stale := time.Since(st.updated) > gitRefreshInterval || !st.modTime.Equal(b.ModTime)
+	if stale && !st.updating {
+		st.updating = true
+		st.modTime = b.ModTime
+		go fetchGitStatus(dir, st)
+	}
+	return st.branch, st.dirty
+}
@@ -235,7 +235,7 @@ func (i *InfoWindow) Display() {
 		}
 	}
 
-	if i.HasSuggestions && len(i.Suggestions) > 1 {
+	if i.HasSuggestions && len(i.Suggestions) > 0 {
 		i.scrollToSuggestion()
 
 		x := -i.hscroll
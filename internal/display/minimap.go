@@ -0,0 +1,144 @@
+package display
+
+import (
+	"unicode/utf8"
+
+	runewidth "github.com/mattn/go-runewidth"
+	"github.com/zyedidia/micro/internal/buffer"
+	"github.com/zyedidia/micro/internal/config"
+	"github.com/zyedidia/micro/internal/screen"
+	"github.com/zyedidia/micro/internal/util"
+)
+
+const (
+	// minimapWidth is the number of columns taken by the minimap
+	minimapWidth = 12
+	// minimapColScale is the number of text columns shown by one minimap cell
+	minimapColScale = 8
+	// minimapMaxSamples is the maximum number of lines inspected for each
+	// row of the minimap, this keeps drawing fast for very large files
+	minimapMaxSamples = 4
+)
+
+// the glyphs used to show the density of a minimap cell, from empty to full
+var minimapGlyphs = []rune{' ', '░', '▒', '▓', '█'}
+
+// hasMinimap returns true if the minimap is enabled and there is enough
+// room in the window to draw it
+func (w *BufWindow) hasMinimap() bool {
+	return w.Buf.Settings["minimap"].(bool) && w.Width > 3*minimapWidth
+}
+
+// minimapX returns the screen column where the minimap starts
+func (w *BufWindow) minimapX() int {
+	x := w.X + w.Width - minimapWidth
+	if w.Buf.Settings["scrollbar"].(bool) && w.Buf.LinesNum() > w.Height {
+		x--
+	}
+	return x
+}
+
+// minimapScale returns the number of buffer lines shown by each row
+// of the minimap
+func (w *BufWindow) minimapScale(height int) int {
+	if height <= 0 {
+		return 1
+	}
+	return util.Max(1, (w.Buf.LinesNum()+height-1)/height)
+}
+
+func (w *BufWindow) minimapHeight() int {
+	h := w.Height
+	if w.drawStatus {
+		h--
+	}
+	return h
+}
+
+// MinimapLine returns the buffer line corresponding to the given screen
+// location if that location is inside the minimap
+func (w *BufWindow) MinimapLine(svloc buffer.Loc) (int, bool) {
+	if !w.hasMinimap() {
+		return 0, false
+	}
+	height := w.minimapHeight()
+	x := w.minimapX()
+	if svloc.X < x || svloc.X >= x+minimapWidth || svloc.Y < w.Y || svloc.Y >= w.Y+height {
+		return 0, false
+	}
+	line := (svloc.Y - w.Y) * w.minimapScale(height)
+	return util.Clamp(line, 0, w.Buf.LinesNum()-1), true
+}
+
+// displayMinimap draws a downscaled overview of the buffer where every cell
+// shows how much text the area it covers contains, and marks the lines
+// which are currently in view
+func (w *BufWindow) displayMinimap() {
+	if !w.hasMinimap() {
+		return
+	}
+
+	b := w.Buf
+	height := w.minimapHeight()
+	scale := w.minimapScale(height)
+	tabsize := b.TabWidth()
+	startX := w.minimapX()
+
+	style := config.DefStyle
+	viewStyle := config.DefStyle.Reverse(true)
+	if s, ok := config.Colorscheme["minimap-viewport"]; ok {
+		fg, _, _ := s.Decompose()
+		viewStyle = style.Background(fg)
+	} else if s, ok := config.Colorscheme["cursor-line"]; ok {
+		fg, _, _ := s.Decompose()
+		viewStyle = style.Background(fg)
+	}
+
+	bottom := w.Bottomline()
+	stride := util.Max(1, scale/minimapMaxSamples)
+	for y := 0; y < height; y++ {
+		start := y * scale
+		end := util.Min(start+scale, b.LinesNum())
+
+		var counts [minimapWidth]int
+		samples := 0
+		for l := start; l < end; l += stride {
+			samples++
+			col := 0
+			for line := b.LineBytes(l); len(line) > 0; {
+				r, size := utf8.DecodeRune(line)
+				line = line[size:]
+
+				cell := col / minimapColScale
+				if cell >= minimapWidth {
+					break
+				}
+				if r == '\t' {
+					col += tabsize - (col % tabsize)
+					continue
+				}
+				if r != ' ' {
+					counts[cell]++
+				}
+				col += runewidth.RuneWidth(r)
+			}
+		}
+
+		s := style
+		if start <= bottom && end > w.StartLine && start < b.LinesNum() {
+			s = viewStyle
+		}
+		for x := 0; x < minimapWidth; x++ {
+			glyph := ' '
+			if samples > 0 {
+				density := float64(counts[x]) / float64(samples*minimapColScale)
+				i := int(density*float64(len(minimapGlyphs)-1) + 0.5)
+				if counts[x] > 0 && i == 0 {
+					i = 1
+				}
+				glyph = minimapGlyphs[util.Min(i, len(minimapGlyphs)-1)]
+			}
+			screen.SetContent(startX+x, w.Y+y, glyph, nil, s)
+		}
+	}
+}
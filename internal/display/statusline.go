@@ -43,6 +43,22 @@ var statusInfo = map[string]func(*buffer.Buffer) string{
 	"col": func(b *buffer.Buffer) string {
 		return strconv.Itoa(b.GetActiveCursor().X + 1)
 	},
+	"percent": func(b *buffer.Buffer) string {
+		return strconv.Itoa((b.GetActiveCursor().Y+1)*100/b.LinesNum()) + "%"
+	},
+	"filetype": func(b *buffer.Buffer) string {
+		return b.Settings["filetype"].(string)
+	},
+	"gitbranch": func(b *buffer.Buffer) string {
+		branch, _ := getGitStatus(b)
+		return branch
+	},
+	"gitdirty": func(b *buffer.Buffer) string {
+		if _, dirty := getGitStatus(b); dirty {
+			return "*"
+		}
+		return ""
+	},
 	"modified": func(b *buffer.Buffer) string {
 		if b.Modified() {
 			return "+ "
@@ -54,6 +70,12 @@ var statusInfo = map[string]func(*buffer.Buffer) string{
 	},
 }
 
+// SetStatusInfoFn registers a function which returns the text of $(name) in
+// the statusline formats
+func SetStatusInfoFn(name string, fn func(*buffer.Buffer) string) {
+	statusInfo[name] = fn
+}
+
 func SetStatusInfoFnLua(fn string) {
 	luaFn := strings.Split(fn, ".")
 	if len(luaFn) <= 1 {
@@ -96,7 +118,9 @@ func (s *StatusLine) FindOpt(opt string) interface{} {
 	return "null"
 }
 
-var formatParser = regexp.MustCompile(`\$\(.+?\)`)
+// formatParser matches the $(verb) directives of the statusline format as
+// well as $$, which is an escaped literal $
+var formatParser = regexp.MustCompile(`\$\$|\$\(.+?\)`)
 
 // Display draws the statusline to the screen
 func (s *StatusLine) Display() {
@@ -142,6 +166,9 @@ func (s *StatusLine) Display() {
 	}
 
 	formatter := func(match []byte) []byte {
+		if match[1] == '$' {
+			return []byte{'$'}
+		}
 		name := match[2 : len(match)-1]
 		if bytes.HasPrefix(name, []byte("opt")) {
 			option := name[4:]
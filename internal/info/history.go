@@ -3,6 +3,7 @@ package info
 import (
 	"encoding/gob"
 	"os"
+	"strings"
 
 	"github.com/zyedidia/micro/internal/config"
 )
@@ -77,3 +78,22 @@ func (i *InfoBuf) DownHistory(history []string) {
 		i.Buffer.GetActiveCursor().GotoLoc(i.End())
 	}
 }
+
+// SearchUpHistory fetches the closest previous item in the history which
+// contains query and differs from the current response. It returns false if
+// there is no such item
+func (i *InfoBuf) SearchUpHistory(history []string, query string) bool {
+	if !i.HasPrompt || i.HasYN {
+		return false
+	}
+	cur := string(i.LineBytes(0))
+	for n := i.HistoryNum - 1; n >= 0; n-- {
+		if history[n] != cur && strings.Contains(history[n], query) {
+			i.HistoryNum = n
+			i.Replace(i.Start(), i.End(), history[n])
+			i.Buffer.GetActiveCursor().GotoLoc(i.End())
+			return true
+		}
+	}
+	return false
+}
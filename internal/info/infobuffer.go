@@ -135,20 +135,25 @@ func (i *InfoBuf) DonePrompt(canceled bool) {
 	i.HasYN = false
 	i.HasGutter = false
 	if !hadYN {
-		if i.PromptCallback != nil {
+		resp := string(i.LineBytes(0))
+		i.Replace(i.Start(), i.End(), "")
+
+		// The callback is run last because it may start a new prompt
+		if cb := i.PromptCallback; cb != nil {
+			i.PromptCallback = nil
+			h := i.History[i.PromptType]
 			if canceled {
-				i.PromptCallback("", true)
-				h := i.History[i.PromptType]
 				i.History[i.PromptType] = h[:len(h)-1]
+				cb("", true)
+			} else if len(h) > 1 && h[len(h)-2] == resp {
+				// don't repeat the previous entry of the history
+				i.History[i.PromptType] = h[:len(h)-1]
+				cb(resp, false)
 			} else {
-				resp := string(i.LineBytes(0))
-				i.PromptCallback(resp, false)
-				h := i.History[i.PromptType]
 				h[len(h)-1] = resp
+				cb(resp, false)
 			}
-			i.PromptCallback = nil
 		}
-		i.Replace(i.Start(), i.End(), "")
 	}
 	if i.YNCallback != nil && hadYN {
 		i.YNCallback(i.YNResp, canceled)
@@ -0,0 +1,293 @@
+// Package lsp implements a minimal client for the Language Server Protocol.
+// It speaks JSON-RPC to a server started as a child process over its
+// standard input and output
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	shellquote "github.com/kballard/go-shellquote"
+)
+
+// requestTimeout is how long a request waits for the response of the server
+const requestTimeout = 3 * time.Second
+
+var errTimeout = errors.New("language server did not respond in time")
+
+// A Client is a connection to a running language server
+type Client struct {
+	sync.Mutex
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	nextID int
+	// pending maps the ids of the requests to the channels waiting for
+	// their responses
+	pending map[int]chan *message
+	// versions holds the version of each document opened on the server
+	versions map[string]int
+	// syncLock keeps the changes of the documents in the order of their
+	// versions when they are sent from several goroutines
+	syncLock sync.Mutex
+	closed   bool
+
+	// OnDiagnostics is called from the goroutine reading the messages of
+	// the server when it publishes the diagnostics of a file
+	OnDiagnostics func(path string, diags []Diagnostic)
+}
+
+// message is a JSON-RPC request, notification or response
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  interface{}      `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return e.Message
+}
+
+// Start runs the given command line and initializes the language server
+// for the root directory
+func Start(cmdline, root string, onDiagnostics func(string, []Diagnostic)) (*Client, error) {
+	args, err := shellquote.Split(cmdline)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty language server command")
+	}
+
+	c := &Client{
+		cmd:           exec.Command(args[0], args[1:]...),
+		pending:       make(map[int]chan *message),
+		versions:      make(map[string]int),
+		OnDiagnostics: onDiagnostics,
+	}
+	c.stdin, err = c.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cmd.Start(); err != nil {
+		return nil, err
+	}
+	go c.readLoop(bufio.NewReader(stdout))
+
+	_, err = c.request("initialize", map[string]interface{}{
+		"processId": nil,
+		"rootUri":   URI(root),
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"completion": map[string]interface{}{
+					"completionItem": map[string]interface{}{"snippetSupport": false},
+				},
+				"hover": map[string]interface{}{
+					"contentFormat": []string{"plaintext"},
+				},
+				"publishDiagnostics": map[string]interface{}{},
+			},
+		},
+	})
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, c.notify("initialized", map[string]interface{}{})
+}
+
+// Alive returns false once the server has exited or the client was closed
+func (c *Client) Alive() bool {
+	c.Lock()
+	defer c.Unlock()
+	return !c.closed
+}
+
+// Close asks the server to shut down and exit, and closes the connection.
+// The server answers the shutdown request before it is told to exit
+func (c *Client) Close() {
+	c.request("shutdown", nil)
+	c.notify("exit", nil)
+	c.Lock()
+	c.closed = true
+	c.Unlock()
+	c.stdin.Close()
+}
+
+// write sends a message to the server with the header required by the protocol
+func (c *Client) write(msg *message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if c.closed {
+		return errors.New("language server is not running")
+	}
+	_, err = fmt.Fprintf(c.stdin, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// notify sends a notification, which has no response
+func (c *Client) notify(method string, params interface{}) error {
+	return c.write(&message{Method: method, Params: params})
+}
+
+// request sends a request and waits for its result
+func (c *Client) request(method string, params interface{}) (json.RawMessage, error) {
+	ch := make(chan *message, 1)
+	c.Lock()
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	c.Unlock()
+
+	defer func() {
+		c.Lock()
+		delete(c.pending, id)
+		c.Unlock()
+	}()
+
+	raw := json.RawMessage(strconv.Itoa(id))
+	if err := c.write(&message{ID: &raw, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp == nil {
+			return nil, errors.New("language server exited")
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-time.After(requestTimeout):
+		return nil, errTimeout
+	}
+}
+
+// readMessage reads the next message sent by the server
+func readMessage(r *bufio.Reader) (*message, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, errors.New("invalid Content-Length header")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	var msg struct {
+		message
+		Params json.RawMessage `json:"params,omitempty"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	msg.message.Params = msg.Params
+	return &msg.message, nil
+}
+
+// readLoop dispatches the messages of the server until it exits
+func (c *Client) readLoop(r *bufio.Reader) {
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			break
+		}
+
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			c.handleRequest(msg)
+		case msg.Method != "":
+			c.handleNotification(msg)
+		case msg.ID != nil:
+			id, _ := strconv.Atoi(string(*msg.ID))
+			c.Lock()
+			ch := c.pending[id]
+			c.Unlock()
+			if ch != nil {
+				select {
+				case ch <- msg:
+				default:
+				}
+			}
+		}
+	}
+
+	c.Lock()
+	c.closed = true
+	for _, ch := range c.pending {
+		select {
+		case ch <- nil:
+		default:
+		}
+	}
+	c.Unlock()
+	c.cmd.Wait()
+}
+
+// handleRequest answers the requests of the server. None of them are
+// supported, but workspace/configuration expects one result per item
+func (c *Client) handleRequest(msg *message) {
+	var result interface{}
+	if msg.Method == "workspace/configuration" {
+		var params struct {
+			Items []interface{} `json:"items"`
+		}
+		if raw, ok := msg.Params.(json.RawMessage); ok {
+			json.Unmarshal(raw, &params)
+		}
+		result = make([]interface{}, len(params.Items))
+	}
+
+	resp, _ := json.Marshal(result)
+	c.write(&message{ID: msg.ID, Result: resp})
+}
+
+func (c *Client) handleNotification(msg *message) {
+	if msg.Method != "textDocument/publishDiagnostics" || c.OnDiagnostics == nil {
+		return
+	}
+	raw, ok := msg.Params.(json.RawMessage)
+	if !ok {
+		return
+	}
+
+	var params struct {
+		URI         string       `json:"uri"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+	if json.Unmarshal(raw, &params) == nil {
+		c.OnDiagnostics(Path(params.URI), params.Diagnostics)
+	}
+}
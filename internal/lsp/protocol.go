@@ -0,0 +1,248 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Diagnostic severities
+const (
+	SeverityError   = 1
+	SeverityWarning = 2
+)
+
+// Position is a location in a document. Character counts UTF-16 code units
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span of a document
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a file
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// Diagnostic is a problem reported by the server
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// CompletionItem is one of the suggestions returned for a completion
+type CompletionItem struct {
+	Label            string `json:"label"`
+	InsertText       string `json:"insertText"`
+	InsertTextFormat int    `json:"insertTextFormat"`
+}
+
+// Text returns the text inserted by the completion. Snippets are not
+// supported so the label is used for them
+func (item CompletionItem) Text() string {
+	if item.InsertText != "" && item.InsertTextFormat != 2 {
+		return item.InsertText
+	}
+	return item.Label
+}
+
+// URI returns the file URI of the given path
+func URI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// windows drive letter
+		path = "/" + path
+	}
+	u := url.URL{Scheme: "file", Path: path}
+	return u.String()
+}
+
+// Path returns the path of the given file URI
+func Path(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		// windows drive letter
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// UTF16Offset converts the char position x in line to the number of UTF-16
+// code units before it
+func UTF16Offset(line []byte, x int) int {
+	n := 0
+	for i := 0; i < x && len(line) > 0; i++ {
+		r, size := utf8.DecodeRune(line)
+		line = line[size:]
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return n
+}
+
+// CharOffset converts a number of UTF-16 code units of line to a char
+// position
+func CharOffset(line []byte, units int) int {
+	x := 0
+	for units > 0 && len(line) > 0 {
+		r, size := utf8.DecodeRune(line)
+		line = line[size:]
+		units -= len(utf16.Encode([]rune{r}))
+		x++
+	}
+	return x
+}
+
+func textDocument(path string) map[string]interface{} {
+	return map[string]interface{}{"uri": URI(path)}
+}
+
+func positionParams(path string, pos Position) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": textDocument(path),
+		"position":     pos,
+	}
+}
+
+// Sync sends the text of a file to the server, opening the document the
+// first time
+func (c *Client) Sync(path, languageID string, text []byte) error {
+	c.syncLock.Lock()
+	defer c.syncLock.Unlock()
+	c.Lock()
+	version, open := c.versions[path]
+	version++
+	c.versions[path] = version
+	c.Unlock()
+
+	if !open {
+		return c.notify("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"uri":        URI(path),
+				"languageId": languageID,
+				"version":    version,
+				"text":       string(text),
+			},
+		})
+	}
+	return c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":     URI(path),
+			"version": version,
+		},
+		"contentChanges": []interface{}{
+			map[string]interface{}{"text": string(text)},
+		},
+	})
+}
+
+// DidSave notifies the server that a file was saved
+func (c *Client) DidSave(path string) error {
+	return c.notify("textDocument/didSave", map[string]interface{}{
+		"textDocument": textDocument(path),
+	})
+}
+
+// Completion returns the completions at the given position
+func (c *Client) Completion(path string, pos Position) ([]CompletionItem, error) {
+	result, err := c.request("textDocument/completion", positionParams(path, pos))
+	if err != nil {
+		return nil, err
+	}
+
+	// the result is either a list of items or a CompletionList
+	var items []CompletionItem
+	if json.Unmarshal(result, &items) == nil {
+		return items, nil
+	}
+	var list struct {
+		Items []CompletionItem `json:"items"`
+	}
+	err = json.Unmarshal(result, &list)
+	return list.Items, err
+}
+
+// Definition returns the location of the definition of the symbol at the
+// given position
+func (c *Client) Definition(path string, pos Position) (Location, error) {
+	result, err := c.request("textDocument/definition", positionParams(path, pos))
+	if err != nil {
+		return Location{}, err
+	}
+
+	// the result is a Location, a list of them or a list of LocationLinks
+	var locs []struct {
+		Location
+		TargetURI            string `json:"targetUri"`
+		TargetSelectionRange Range  `json:"targetSelectionRange"`
+	}
+	if json.Unmarshal(result, &locs) != nil {
+		var loc Location
+		if err := json.Unmarshal(result, &loc); err != nil || loc.URI == "" {
+			return Location{}, errors.New("No definition found")
+		}
+		return loc, nil
+	}
+	if len(locs) == 0 {
+		return Location{}, errors.New("No definition found")
+	}
+	if locs[0].TargetURI != "" {
+		return Location{URI: locs[0].TargetURI, Range: locs[0].TargetSelectionRange}, nil
+	}
+	return locs[0].Location, nil
+}
+
+// Hover returns the documentation of the symbol at the given position
+func (c *Client) Hover(path string, pos Position) (string, error) {
+	result, err := c.request("textDocument/hover", positionParams(path, pos))
+	if err != nil {
+		return "", err
+	}
+
+	var hover struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := json.Unmarshal(result, &hover); err != nil || hover.Contents == nil {
+		return "", errors.New("No information available")
+	}
+	return strings.TrimSpace(markedString(hover.Contents)), nil
+}
+
+// markedString returns the text of hover contents, which can be a string,
+// a MarkedString, MarkupContent or a list of them
+func markedString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) == nil {
+		var parts []string
+		for _, item := range list {
+			parts = append(parts, markedString(item))
+		}
+		return strings.Join(parts, "\n")
+	}
+	var content struct {
+		Value string `json:"value"`
+	}
+	json.Unmarshal(raw, &content)
+	return content.Value
+}
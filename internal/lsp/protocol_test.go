@@ -0,0 +1,44 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsets(t *testing.T) {
+	line := []byte("a😀b să")
+
+	assert.Equal(t, 0, UTF16Offset(line, 0))
+	assert.Equal(t, 3, UTF16Offset(line, 2))
+	assert.Equal(t, 7, UTF16Offset(line, 6))
+	assert.Equal(t, 7, UTF16Offset(line, 10))
+
+	assert.Equal(t, 2, CharOffset(line, 3))
+	assert.Equal(t, 6, CharOffset(line, 7))
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("/tmp/some dir/main.go")
+	assert.Equal(t, "file:///tmp/some%20dir/main.go", uri)
+	assert.Equal(t, "/tmp/some dir/main.go", Path(uri))
+}
+
+func TestReadMessage(t *testing.T) {
+	body := `{"jsonrpc":"2.0","id":3,"result":{"contents":["one",{"language":"go","value":"two"}]}}`
+	r := bufio.NewReader(strings.NewReader("Content-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n" + body))
+
+	msg, err := readMessage(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", string(*msg.ID))
+
+	var hover struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	assert.NoError(t, json.Unmarshal(msg.Result, &hover))
+	assert.Equal(t, "one\ntwo", markedString(hover.Contents))
+}
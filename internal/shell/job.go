@@ -54,8 +54,15 @@ func JobStart(cmd string, onStdout, onStderr, onExit func(string, []interface{})
 // JobSpawn starts a process with args in the background with the given callbacks
 // It returns an *exec.Cmd as the job id
 func JobSpawn(cmdName string, cmdArgs []string, onStdout, onStderr, onExit func(string, []interface{}), userargs ...interface{}) *exec.Cmd {
+	return JobSpawnIn("", cmdName, cmdArgs, onStdout, onStderr, onExit, userargs...)
+}
+
+// JobSpawnIn is like JobSpawn but runs the process in the given directory, or
+// in the working directory if dir is empty
+func JobSpawnIn(dir string, cmdName string, cmdArgs []string, onStdout, onStderr, onExit func(string, []interface{}), userargs ...interface{}) *exec.Cmd {
 	// Set up everything correctly if the functions have been provided
 	proc := exec.Command(cmdName, cmdArgs...)
+	proc.Dir = dir
 	var outbuf bytes.Buffer
 	if onStdout != nil {
 		proc.Stdout = &CallbackFile{&outbuf, onStdout, userargs}
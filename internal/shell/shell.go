@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"runtime"
 	"strings"
 
 	shellquote "github.com/kballard/go-shellquote"
@@ -17,8 +20,15 @@ import (
 // ExecCommand executes a command using exec
 // It returns any output/errors
 func ExecCommand(name string, arg ...string) (string, error) {
+	return execCommand("", name, arg...)
+}
+
+// execCommand executes a command in the given directory, or in the working
+// directory if dir is empty
+func execCommand(dir string, name string, arg ...string) (string, error) {
 	var err error
 	cmd := exec.Command(name, arg...)
+	cmd.Dir = dir
 	outputBytes := &bytes.Buffer{}
 	cmd.Stdout = outputBytes
 	cmd.Stderr = outputBytes
@@ -33,6 +43,12 @@ func ExecCommand(name string, arg ...string) (string, error) {
 
 // RunCommand executes a shell command and returns the output/error
 func RunCommand(input string) (string, error) {
+	return RunCommandIn("", input)
+}
+
+// RunCommandIn executes a shell command in the given directory, or in the
+// working directory if dir is empty, and returns the output/error
+func RunCommandIn(dir string, input string) (string, error) {
 	args, err := shellquote.Split(input)
 	if err != nil {
 		return "", err
@@ -42,13 +58,66 @@ func RunCommand(input string) (string, error) {
 	}
 	inputCmd := args[0]
 
-	return ExecCommand(inputCmd, args[1:]...)
+	return execCommand(dir, inputCmd, args[1:]...)
+}
+
+// OpenURL opens the given URL with the default application of the system,
+// usually the web browser, without waiting for it
+func OpenURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// RevealFile opens the file manager of the system on the directory of the
+// given file, with the file selected when the file manager supports it
+func RevealFile(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", "-R", path)
+	case "windows":
+		cmd = exec.Command("explorer", "/select,"+path)
+	default:
+		// file managers implementing the FileManager1 interface select the
+		// file, the others are only opened on its directory
+		uri := (&url.URL{Scheme: "file", Path: path}).String()
+		err := exec.Command("dbus-send", "--session", "--dest=org.freedesktop.FileManager1",
+			"--type=method_call", "/org/freedesktop/FileManager1",
+			"org.freedesktop.FileManager1.ShowItems", "array:string:"+uri, "string:").Run()
+		if err == nil {
+			return nil
+		}
+		cmd = exec.Command("xdg-open", filepath.Dir(path))
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
 }
 
 // RunBackgroundShell runs a shell command in the background
 // It returns a function which will run the command and returns a string
 // message result
 func RunBackgroundShell(input string) (func() string, error) {
+	return RunBackgroundShellIn("", input)
+}
+
+// RunBackgroundShellIn is like RunBackgroundShell but runs the command in the
+// given directory, or in the working directory if dir is empty
+func RunBackgroundShellIn(dir string, input string) (func() string, error) {
 	args, err := shellquote.Split(input)
 	if err != nil {
 		return nil, err
@@ -58,7 +127,7 @@ func RunBackgroundShell(input string) (func() string, error) {
 	}
 	inputCmd := args[0]
 	return func() string {
-		output, err := RunCommand(input)
+		output, err := RunCommandIn(dir, input)
 		totalLines := strings.Split(output, "\n")
 
 		str := output
@@ -75,6 +144,12 @@ func RunBackgroundShell(input string) (func() string, error) {
 
 // RunInteractiveShell runs a shellcommand interactively
 func RunInteractiveShell(input string, wait bool, getOutput bool) (string, error) {
+	return RunInteractiveShellIn("", input, wait, getOutput)
+}
+
+// RunInteractiveShellIn is like RunInteractiveShell but runs the command in
+// the given directory, or in the working directory if dir is empty
+func RunInteractiveShellIn(dir string, input string, wait bool, getOutput bool) (string, error) {
 	args, err := shellquote.Split(input)
 	if err != nil {
 		return "", err
@@ -92,6 +167,7 @@ func RunInteractiveShell(input string, wait bool, getOutput bool) (string, error
 	// Set up everything for the command
 	outputBytes := &bytes.Buffer{}
 	cmd := exec.Command(inputCmd, args...)
+	cmd.Dir = dir
 	cmd.Stdin = os.Stdin
 	if getOutput {
 		cmd.Stdout = io.MultiWriter(os.Stdout, outputBytes)
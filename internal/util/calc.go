@@ -0,0 +1,226 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// calcParser is a recursive descent parser for arithmetic expressions,
+// which computes their value while parsing
+type calcParser struct {
+	s   []rune
+	pos int
+}
+
+// EvalMath computes the value of an arithmetic expression made of numbers,
+// parentheses and the +, -, *, /, % and ^ operators
+func EvalMath(expr string) (float64, error) {
+	p := &calcParser{s: []rune(expr)}
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	if p.skipSpaces(); p.pos < len(p.s) {
+		return 0, p.unexpected()
+	}
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, errors.New("The result is not a number")
+	}
+	return v, nil
+}
+
+// FormatNumber formats the result of EvalMath, without decimals for whole
+// numbers
+func FormatNumber(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func (p *calcParser) skipSpaces() {
+	for p.pos < len(p.s) && unicode.IsSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+// next returns the next rune which is not a space, or 0 at the end
+func (p *calcParser) next() rune {
+	p.skipSpaces()
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *calcParser) unexpected() error {
+	if p.pos >= len(p.s) {
+		return errors.New("Unexpected end of expression")
+	}
+	return fmt.Errorf("Unexpected %q at column %d", p.s[p.pos], p.pos+1)
+}
+
+// expr parses a sum of terms
+func (p *calcParser) expr() (float64, error) {
+	v, err := p.term()
+	for err == nil {
+		op := p.next()
+		if op != '+' && op != '-' {
+			break
+		}
+		p.pos++
+		var w float64
+		if w, err = p.term(); op == '+' {
+			v += w
+		} else {
+			v -= w
+		}
+	}
+	return v, err
+}
+
+// term parses a product of factors
+func (p *calcParser) term() (float64, error) {
+	v, err := p.unary()
+	for err == nil {
+		op := p.next()
+		if op != '*' && op != '/' && op != '%' {
+			break
+		}
+		p.pos++
+		var w float64
+		if w, err = p.unary(); err != nil {
+			break
+		}
+		switch {
+		case op == '*':
+			v *= w
+		case w == 0:
+			err = errors.New("Division by zero")
+		case op == '/':
+			v /= w
+		default:
+			v = math.Mod(v, w)
+		}
+	}
+	return v, err
+}
+
+// unary parses a factor with optional signs
+func (p *calcParser) unary() (float64, error) {
+	switch p.next() {
+	case '-':
+		p.pos++
+		v, err := p.unary()
+		return -v, err
+	case '+':
+		p.pos++
+		return p.unary()
+	}
+	return p.power()
+}
+
+// power parses a number or a parenthesized expression, raised to an
+// optional power. The ^ operator is right associative
+func (p *calcParser) power() (float64, error) {
+	v, err := p.primary()
+	if err == nil && p.next() == '^' {
+		p.pos++
+		var w float64
+		if w, err = p.unary(); err == nil {
+			v = math.Pow(v, w)
+		}
+	}
+	return v, err
+}
+
+func (p *calcParser) primary() (float64, error) {
+	if p.next() == '(' {
+		p.pos++
+		v, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if p.next() != ')' {
+			return 0, p.unexpected()
+		}
+		p.pos++
+		return v, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && (unicode.IsDigit(p.s[p.pos]) || strings.ContainsRune("._", p.s[p.pos])) {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, p.unexpected()
+	}
+	v, err := strconv.ParseFloat(strings.Replace(string(p.s[start:p.pos]), "_", "", -1), 64)
+	if err != nil {
+		p.pos = start
+		return 0, p.unexpected()
+	}
+	return v, nil
+}
+
+var numberRegex = regexp.MustCompile(`[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// NumbersIn returns the numbers written in text, ignoring anything else
+func NumbersIn(text []byte) []float64 {
+	var nums []float64
+	for _, m := range numberRegex.FindAll(text, -1) {
+		if v, err := strconv.ParseFloat(string(m), 64); err == nil {
+			nums = append(nums, v)
+		}
+	}
+	return nums
+}
+
+// NumberAt finds the integer at rune index x of line, or the first one after
+// x, and returns its rune range. A minus sign just before the digits is part
+// of the number
+func NumberAt(line []byte, x int) (start, end int, ok bool) {
+	runes := []rune(string(line))
+	start = Max(x, 0)
+	for start < len(runes) && start > 0 && unicode.IsDigit(runes[start-1]) {
+		start--
+	}
+	for start < len(runes) && !unicode.IsDigit(runes[start]) {
+		start++
+	}
+	if start >= len(runes) {
+		return 0, 0, false
+	}
+	end = start
+	for end < len(runes) && unicode.IsDigit(runes[end]) {
+		end++
+	}
+	if start > 0 && runes[start-1] == '-' {
+		start--
+	}
+	return start, end, true
+}
+
+// AddToNumber adds n to the integer written in num, keeping the width of a
+// number with leading zeros
+func AddToNumber(num string, n int64) (string, error) {
+	v, err := strconv.ParseInt(num, 10, 64)
+	if err != nil {
+		return "", err
+	}
+	v += n
+	digits := strings.TrimPrefix(num, "-")
+	if len(digits) > 1 && digits[0] == '0' {
+		width := len(digits)
+		if v < 0 {
+			return fmt.Sprintf("-%0*d", width, -v), nil
+		}
+		return fmt.Sprintf("%0*d", width, v), nil
+	}
+	return strconv.FormatInt(v, 10), nil
+}
@@ -0,0 +1,65 @@
+package util
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvalMath(t *testing.T) {
+	for expr, want := range map[string]float64{
+		"1 + 2 * 3":     7,
+		"(1 + 2) * 3":   9,
+		"10 / 4":        2.5,
+		"-2 ^ 2":        -4,
+		"2 ^ 3 ^ 2":     512,
+		"17 % 5 - -1":   3,
+		" 1_000 * 1.5 ": 1500,
+	} {
+		v, err := EvalMath(expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, want, v, expr)
+	}
+
+	for _, expr := range []string{"", "1 +", "(1 + 2", "1 2", "2 * x", "1 / 0", "1..2"} {
+		_, err := EvalMath(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	assert.Equal(t, "42", FormatNumber(42))
+	assert.Equal(t, "-0.5", FormatNumber(-0.5))
+	assert.Equal(t, "1e+20", FormatNumber(1e20))
+}
+
+func TestNumbersIn(t *testing.T) {
+	assert.Equal(t, []float64{12, -3.5, 0.25, 1e3}, NumbersIn([]byte("apples 12\npears -3.5, .25 kg\n1e3 total")))
+	assert.Empty(t, NumbersIn([]byte("no numbers - here.")))
+}
+
+func TestNumberAt(t *testing.T) {
+	line := []byte("x = -12 + 345")
+	start, end, ok := NumberAt(line, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 4, start)
+	assert.Equal(t, 7, end)
+
+	start, end, ok = NumberAt(line, 11)
+	assert.True(t, ok)
+	assert.Equal(t, 10, start)
+	assert.Equal(t, 13, end)
+
+	_, _, ok = NumberAt(line, 13)
+	assert.False(t, ok)
+}
+
+func TestAddToNumber(t *testing.T) {
+	for _, c := range [][3]string{{"0", "3", "3"}, {"-2", "3", "1"}, {"007", "5", "012"}, {"9", "-10", "-1"}} {
+		n, _ := strconv.ParseInt(c[1], 10, 64)
+		s, err := AddToNumber(c[0], n)
+		assert.NoError(t, err)
+		assert.Equal(t, c[2], s)
+	}
+}
@@ -1,9 +1,12 @@
 package util
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"os/user"
 	"path/filepath"
 	"regexp"
@@ -15,6 +18,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/blang/semver"
+	shellquote "github.com/kballard/go-shellquote"
 	runewidth "github.com/mattn/go-runewidth"
 )
 
@@ -257,6 +261,158 @@ func IsBytesWhitespace(b []byte) bool {
 	return true
 }
 
+// CountWords returns the number of words in the given bytes, where words
+// are separated by whitespace
+func CountWords(b []byte) int {
+	words := 0
+	inWord := false
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+
+		if unicode.IsSpace(r) {
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			words++
+		}
+	}
+	return words
+}
+
+// WhitespaceIssue returns the char position of the first whitespace issue
+// in the given line: indentation mixing tabs and spaces, or trailing
+// whitespace. It returns false if the line has no issue
+func WhitespaceIssue(b []byte) (int, bool) {
+	ws := GetLeadingWhitespace(b)
+	if bytes.IndexByte(ws, ' ') != -1 && bytes.IndexByte(ws, '\t') != -1 {
+		return 0, true
+	}
+
+	if trimmed := bytes.TrimRightFunc(b, unicode.IsSpace); len(trimmed) < len(b) {
+		return utf8.RuneCount(trimmed), true
+	}
+	return 0, false
+}
+
+// SpellWord returns the word at the start of b that should be spell
+// checked. It is made of letters which may be joined by apostrophes
+func SpellWord(b []byte) []byte {
+	n := 0
+	for n < len(b) {
+		r, size := utf8.DecodeRune(b[n:])
+		if r == '\'' && n > 0 {
+			if next, _ := utf8.DecodeRune(b[n+size:]); unicode.IsLetter(next) {
+				n += size
+				continue
+			}
+			break
+		} else if !unicode.IsLetter(r) {
+			break
+		}
+		n += size
+	}
+	return b[:n]
+}
+
+// isPathRune returns whether r can be part of a file path written in text,
+// where paths are often quoted or between brackets
+func isPathRune(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune("\"'`<>()[]{},;|", r)
+}
+
+// PathAt returns the path-like token around the rune at index x of line,
+// without the punctuation which usually ends a sentence or a location in a
+// compiler message. The token may end with a :line or :line:col location
+func PathAt(line []byte, x int) string {
+	runes := []rune(string(line))
+	if x < 0 || x >= len(runes) || !isPathRune(runes[x]) {
+		return ""
+	}
+
+	start, end := x, x+1
+	for start > 0 && isPathRune(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && isPathRune(runes[end]) {
+		end++
+	}
+	return strings.TrimRight(string(runes[start:end]), ".:")
+}
+
+var urlRegex = regexp.MustCompile("(?i)(?:https?://|www\\.)[^\\s\"'`<>]+")
+
+// URLAt returns the http(s):// or www. URL around the rune at index x of
+// line, without the punctuation which may follow it in a sentence, or an
+// empty string if there is no URL at x
+func URLAt(line []byte, x int) string {
+	if x < 0 {
+		return ""
+	}
+	offset := len(string([]rune(string(line))[:Min(x, utf8.RuneCount(line))]))
+	for _, m := range urlRegex.FindAllIndex(line, -1) {
+		url := strings.TrimRight(string(line[m[0]:m[1]]), ".,;:!?")
+		for _, pair := range []string{"()", "[]", "{}"} {
+			// keep the brackets which are part of the URL
+			if strings.HasSuffix(url, pair[1:]) && strings.Count(url, pair[:1]) < strings.Count(url, pair[1:]) {
+				url = strings.TrimRight(url[:len(url)-1], ".,;:!?")
+			}
+		}
+		if offset >= m[0] && offset < m[0]+len(url) {
+			return url
+		}
+	}
+	return ""
+}
+
+// NewUUID returns a version 4 UUID made of random bytes read from r, in the
+// usual lowercase form with hyphens
+func NewUUID(r io.Reader) (string, error) {
+	var b [16]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// FuzzyMatch reports whether all the runes of pattern appear in str in the
+// same order (ignoring case) and returns a score for the match. Runes which
+// match consecutively or at the start of a word give a higher score
+func FuzzyMatch(pattern, str string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	score := 0
+	pi := 0
+	consecutive := false
+	prev := rune(0)
+	for i, r := range str {
+		if pi < len(p) && unicode.ToLower(r) == p[pi] {
+			score++
+			if consecutive {
+				score += 5
+			}
+			if i == 0 || !IsWordChar(prev) || (unicode.IsUpper(r) && unicode.IsLower(prev)) {
+				score += 3
+			}
+			pi++
+			consecutive = true
+		} else {
+			consecutive = false
+		}
+		prev = r
+	}
+
+	if pi < len(p) {
+		return 0, false
+	}
+	return score, true
+}
+
 // RunePos returns the rune index of a given byte index
 // Make sure the byte index is not between code points
 func RunePos(b []byte, i int) int {
@@ -329,12 +485,96 @@ func GetModTime(path string) (time.Time, error) {
 	return info.ModTime(), nil
 }
 
+// GitRepo returns the root of the work tree of the git repository which
+// contains dir and the absolute path of its git directory. git finds them
+// itself so that worktrees and submodules are handled
+func GitRepo(dir string) (string, string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel", "--absolute-git-dir").Output()
+	if err != nil {
+		return "", "", err
+	}
+	paths := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(paths) != 2 {
+		return "", "", errors.New("Unexpected output of git rev-parse")
+	}
+	return paths[0], paths[1], nil
+}
+
 // EscapePath replaces every path separator in a given path with a %
 func EscapePath(path string) string {
 	path = filepath.ToSlash(path)
 	return strings.Replace(path, "/", "%", -1)
 }
 
+// ExpandFileNames replaces every % in s with the path of the current file
+// and every # with the path of the alternate file, quoted for the shell. The
+// modifiers :h, :t and :r following them take the directory, the base name
+// and the path without extension, and can be combined like %:t:r. %% and ##
+// are a literal % and #. It fails if a file which is used has no path
+func ExpandFileNames(s, current, alternate string) (string, error) {
+	return expandFileNames(s, current, alternate, true)
+}
+
+// ExpandFileNameArgs is like ExpandFileNames for the arguments of a command
+// which were already split, the paths are not quoted
+func ExpandFileNameArgs(args []string, current, alternate string) ([]string, error) {
+	expanded := make([]string, len(args))
+	for i, a := range args {
+		e, err := expandFileNames(a, current, alternate, false)
+		if err != nil {
+			return nil, err
+		}
+		expanded[i] = e
+	}
+	return expanded, nil
+}
+
+func expandFileNames(s, current, alternate string, quote bool) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '%' && c != '#' {
+			sb.WriteByte(c)
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == c {
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+
+		path := current
+		if c == '#' {
+			path = alternate
+		}
+		if path == "" {
+			if c == '#' {
+				return "", errors.New("No alternate file name to substitute for #")
+			}
+			return "", errors.New("No file name to substitute for %")
+		}
+	modifiers:
+		for i+2 < len(s) && s[i+1] == ':' {
+			switch s[i+2] {
+			case 'h':
+				path = filepath.Dir(path)
+			case 't':
+				path = filepath.Base(path)
+			case 'r':
+				path = strings.TrimSuffix(path, filepath.Ext(path))
+			default:
+				break modifiers
+			}
+			i += 2
+		}
+		if quote {
+			path = shellquote.Join(path)
+		}
+		sb.WriteString(path)
+	}
+	return sb.String(), nil
+}
+
 // GetLeadingWhitespace returns the leading whitespace of the given byte array
 func GetLeadingWhitespace(b []byte) []byte {
 	ws := []byte{}
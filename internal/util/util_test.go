@@ -1,6 +1,11 @@
 package util
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,3 +36,137 @@ func TestSliceVisualEnd(t *testing.T) {
 	assert.Equal(t, []byte("ello"), slc)
 	assert.Equal(t, 0, n)
 }
+
+func TestCountWords(t *testing.T) {
+	assert.Equal(t, 0, CountWords([]byte("")))
+	assert.Equal(t, 0, CountWords([]byte(" \t\n ")))
+	assert.Equal(t, 1, CountWords([]byte("hello")))
+	assert.Equal(t, 3, CountWords([]byte("  hello,\tworld\nagain  ")))
+	assert.Equal(t, 4, CountWords([]byte("Pot să mănânc\u00a0sticlă")))
+}
+
+func TestWhitespaceIssue(t *testing.T) {
+	_, ok := WhitespaceIssue([]byte("\tfoo := bar"))
+	assert.False(t, ok)
+	_, ok = WhitespaceIssue([]byte(""))
+	assert.False(t, ok)
+
+	x, ok := WhitespaceIssue([]byte("\t  foo"))
+	assert.True(t, ok)
+	assert.Equal(t, 0, x)
+
+	x, ok = WhitespaceIssue([]byte("    să \t"))
+	assert.True(t, ok)
+	assert.Equal(t, 6, x)
+}
+
+func TestSpellWord(t *testing.T) {
+	assert.Equal(t, []byte("hello"), SpellWord([]byte("hello, world")))
+	assert.Equal(t, []byte("don't"), SpellWord([]byte("don't stop")))
+	assert.Equal(t, []byte("dogs"), SpellWord([]byte("dogs' bones")))
+	assert.Equal(t, []byte("mănânc"), SpellWord([]byte("mănânc2")))
+	assert.Empty(t, SpellWord([]byte("'quoted'")))
+}
+
+func TestPathAt(t *testing.T) {
+	line := []byte(`#include "foo/bar.h"`)
+	assert.Equal(t, "foo/bar.h", PathAt(line, 12))
+	assert.Equal(t, "", PathAt(line, 8))
+
+	line = []byte("\tat main.go:12:5: undefined")
+	assert.Equal(t, "main.go:12:5", PathAt(line, 4))
+	assert.Equal(t, "see", PathAt([]byte("see ~/notes.txt."), 0))
+	assert.Equal(t, "~/notes.txt", PathAt([]byte("see ~/notes.txt."), 6))
+}
+
+func TestURLAt(t *testing.T) {
+	line := []byte("// see https://example.com/a_(b)/c?d=1, or (www.example.org).")
+	assert.Equal(t, "https://example.com/a_(b)/c?d=1", URLAt(line, 10))
+	assert.Equal(t, "www.example.org", URLAt(line, 50))
+	assert.Equal(t, "", URLAt(line, 4))
+	assert.Equal(t, "", URLAt(line, 61))
+	assert.Equal(t, "", URLAt([]byte("httpd://x"), 2))
+}
+
+func TestExpandFileNames(t *testing.T) {
+	s, err := ExpandFileNames("go test %", "main_test.go", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "go test main_test.go", s)
+
+	s, err = ExpandFileNames("echo 100%% %%% ##", "a.txt", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "echo 100% %a.txt #", s)
+
+	s, err = ExpandFileNames("cd %:h && diff %:t:r.go #:t", "src/my file.c", "other/b.go")
+	assert.NoError(t, err)
+	assert.Equal(t, "cd src && diff 'my file'.go b.go", s)
+
+	s, err = ExpandFileNames("%:x", "a.txt", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "a.txt:x", s)
+
+	_, err = ExpandFileNames("diff % #", "a.txt", "")
+	assert.Error(t, err)
+	_, err = ExpandFileNames("echo %", "", "")
+	assert.Error(t, err)
+}
+
+func TestExpandFileNameArgs(t *testing.T) {
+	args, err := ExpandFileNameArgs([]string{"diff", "%:t", "#", "100%%"}, "src/my file.c", "b.c")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"diff", "my file.c", "b.c", "100%"}, args)
+
+	_, err = ExpandFileNameArgs([]string{"cat", "#"}, "a.c", "")
+	assert.Error(t, err)
+}
+
+func TestNewUUID(t *testing.T) {
+	r := bytes.NewReader([]byte("0123456789abcdef"))
+	uuid, err := NewUUID(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "30313233-3435-4637-b839-616263646566", uuid)
+
+	_, err = NewUUID(r)
+	assert.Error(t, err)
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	_, ok := FuzzyMatch("", "anything")
+	assert.True(t, ok)
+
+	_, ok = FuzzyMatch("asv", "SaveAs")
+	assert.False(t, ok)
+
+	_, ok = FuzzyMatch("svas", "SaveAs")
+	assert.True(t, ok)
+
+	_, ok = FuzzyMatch("sa", "ShellMode")
+	assert.False(t, ok)
+
+	// consecutive matches and matches at word starts are preferred
+	s1, _ := FuzzyMatch("find", "FindNext")
+	s2, _ := FuzzyMatch("find", "FileInsideDir")
+	assert.True(t, s1 > s2)
+
+	s1, _ = FuzzyMatch("sa", "SelectAll")
+	s2, _ = FuzzyMatch("sa", "Unsaved")
+	assert.True(t, s1 > s2)
+}
+
+func TestGitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "micro")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dir, _ = filepath.EvalSymlinks(dir)
+
+	assert.NoError(t, exec.Command("git", "init", "-q", dir).Run())
+	sub := filepath.Join(dir, "sub")
+	assert.NoError(t, os.Mkdir(sub, 0755))
+	root, gitDir, err := GitRepo(sub)
+	assert.NoError(t, err)
+	assert.Equal(t, dir, root)
+	assert.Equal(t, filepath.Join(dir, ".git"), gitDir)
+}
@@ -3,6 +3,8 @@ package views
 import (
 	"fmt"
 	"strings"
+
+	"github.com/zyedidia/micro/internal/util"
 )
 
 type SplitType uint8
@@ -13,6 +15,12 @@ const (
 	STUndef = 2
 )
 
+// The minimum size of a split, a split cannot be resized below it
+const (
+	MinSplitWidth  = 10
+	MinSplitHeight = 3
+)
+
 var idcounter uint64
 
 // NewID returns a new unique id
@@ -122,6 +130,11 @@ func (n *Node) Children() []*Node {
 	return n.children
 }
 
+// Parent returns this node's parent or nil if it is the root
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
 // GetNode returns the node with the given id in the tree of children
 // that this node has access to or nil if the node with that id cannot be found
 func (n *Node) GetNode(id uint64) *Node {
@@ -151,7 +164,7 @@ func (n *Node) vResizeSplit(i int, size int) bool {
 		c1, c2 = n.children[i], n.children[i+1]
 	}
 	toth := c1.H + c2.H
-	if size >= toth {
+	if size < MinSplitHeight || toth-size < MinSplitHeight {
 		return false
 	}
 	c2.Y = c1.Y + size
@@ -172,7 +185,7 @@ func (n *Node) hResizeSplit(i int, size int) bool {
 		c1, c2 = n.children[i], n.children[i+1]
 	}
 	totw := c1.W + c2.W
-	if size >= totw {
+	if size < MinSplitWidth || totw-size < MinSplitWidth {
 		return false
 	}
 	c2.X = c1.X + size
@@ -201,6 +214,84 @@ func (n *Node) ResizeSplit(size int) bool {
 	return n.parent.hResizeSplit(ind, size)
 }
 
+// ResizeSplitBy grows this split by delta, or shrinks it if delta is
+// negative, taking the space from its next sibling, or from its previous
+// sibling for the last split. The size is clamped so that neither split
+// gets smaller than the minimum size
+func (n *Node) ResizeSplitBy(delta int) bool {
+	if n.parent == nil || len(n.parent.children) <= 1 {
+		return false
+	}
+	ind := 0
+	for i, c := range n.parent.children {
+		if c.id == n.id {
+			ind = i
+		}
+	}
+
+	// the size given to vResizeSplit and hResizeSplit is the one of the
+	// first split of the pair
+	var c1, c2 *Node
+	if ind == len(n.parent.children)-1 {
+		c1, c2 = n.parent.children[ind-1], n
+		delta = -delta
+	} else {
+		c1, c2 = n, n.parent.children[ind+1]
+	}
+	size, tot, minSize := c1.W, c1.W+c2.W, MinSplitWidth
+	if n.parent.Kind == STVert {
+		size, tot, minSize = c1.H, c1.H+c2.H, MinSplitHeight
+	}
+	newSize := util.Clamp(size+delta, minSize, tot-minSize)
+	if newSize == size {
+		return false
+	}
+
+	if n.parent.Kind == STVert {
+		return n.parent.vResizeSplit(ind, newSize)
+	}
+	return n.parent.hResizeSplit(ind, newSize)
+}
+
+// SwapNext exchanges the position of this split with its next sibling, or
+// with its first sibling for the last split. The sibling may itself be split
+func (n *Node) SwapNext() bool {
+	if n.parent == nil || len(n.parent.children) <= 1 {
+		return false
+	}
+	ind := 0
+	for i, c := range n.parent.children {
+		if c.id == n.id {
+			ind = i
+		}
+	}
+	next := (ind + 1) % len(n.parent.children)
+
+	p := n.parent
+	p.children[ind], p.children[next] = p.children[next], p.children[ind]
+	p.Resize(p.W, p.H)
+	return true
+}
+
+// Equalize gives the same size to all the children of this node, and of all
+// its descendants
+func (n *Node) Equalize() {
+	var equalize func(n *Node)
+	equalize = func(n *Node) {
+		for _, c := range n.children {
+			c.propW, c.propH = 1, 1
+			if n.Kind == STVert {
+				c.propH = 1 / float64(len(n.children))
+			} else {
+				c.propW = 1 / float64(len(n.children))
+			}
+			equalize(c)
+		}
+	}
+	equalize(n)
+	n.Resize(n.W, n.H)
+}
+
 // Resize sets this node's size and resizes all children accordlingly
 func (n *Node) Resize(w, h int) {
 	n.W, n.H = w, h
@@ -3,6 +3,8 @@ package views
 import (
 	"fmt"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestHSplit(t *testing.T) {
@@ -14,3 +16,39 @@ func TestHSplit(t *testing.T) {
 
 	fmt.Println(root.String())
 }
+
+func TestResizeSplitBy(t *testing.T) {
+	root := NewRoot(0, 0, 80, 24)
+	n1 := root.id
+	n2 := root.VSplit(true)
+
+	assert.True(t, root.GetNode(n1).ResizeSplitBy(4))
+	assert.Equal(t, 44, root.GetNode(n1).W)
+	assert.Equal(t, 36, root.GetNode(n2).W)
+
+	// the last split takes the space from its previous sibling
+	assert.True(t, root.GetNode(n2).ResizeSplitBy(6))
+	assert.Equal(t, 42, root.GetNode(n2).W)
+
+	// a split cannot be shrunk below the minimum size
+	assert.True(t, root.GetNode(n1).ResizeSplitBy(-100))
+	assert.Equal(t, MinSplitWidth, root.GetNode(n1).W)
+	assert.False(t, root.GetNode(n1).ResizeSplitBy(-1))
+
+	root.Equalize()
+	assert.Equal(t, 40, root.GetNode(n1).W)
+	assert.Equal(t, 40, root.GetNode(n2).W)
+}
+
+func TestSwapNext(t *testing.T) {
+	root := NewRoot(0, 0, 80, 24)
+	n1 := root.id
+	n2 := root.VSplit(true)
+	root.GetNode(n1).ResizeSplitBy(10)
+
+	assert.True(t, root.GetNode(n1).SwapNext())
+	assert.Equal(t, 0, root.GetNode(n2).X)
+	assert.Equal(t, 30, root.GetNode(n2).W)
+	assert.Equal(t, 30, root.GetNode(n1).X)
+	assert.Equal(t, 50, root.GetNode(n1).W)
+}